@@ -1,13 +1,20 @@
 package e2e
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/rcarmo/go-ooxml/pkg/document"
+	"github.com/rcarmo/go-ooxml/pkg/packaging"
 	"github.com/rcarmo/go-ooxml/pkg/presentation"
 	"github.com/rcarmo/go-ooxml/pkg/spreadsheet"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
 )
 
 func TestWordWorkflow_TechnicalReport(t *testing.T) {
@@ -366,3 +373,255 @@ func TestPowerPointWorkflow_TemplateUpdate(t *testing.T) {
 		t.Error("Expected appended slide text after round-trip")
 	}
 }
+
+func TestWordWorkflow_UpdateChartDataWorkbook(t *testing.T) {
+	dir := t.TempDir()
+
+	data, err := spreadsheet.New()
+	if err != nil {
+		t.Fatalf("spreadsheet.New() error = %v", err)
+	}
+	sheet, _ := data.SheetRaw(0)
+	sheet.Cell("A1").SetValue("Q1")
+	sheet.Cell("B1").SetValue(100)
+	dataPath := filepath.Join(dir, "data.xlsx")
+	if err := data.SaveAs(dataPath); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	data.Close()
+	workbookData, err := os.ReadFile(dataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := document.New()
+	if err != nil {
+		t.Fatalf("document.New() error = %v", err)
+	}
+	if _, err := doc.Body().AddChart(5486400, 3200400, "Revenue"); err != nil {
+		t.Fatalf("AddChart() error = %v", err)
+	}
+	reportPath := filepath.Join(dir, "report.docx")
+	if err := doc.SaveAs(reportPath); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	doc.Close()
+
+	// Attach the data workbook to the chart as Word does.
+	pkg, err := packaging.Open(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const embedPath = "word/embeddings/Microsoft_Excel_Worksheet.xlsx"
+	if _, err := pkg.AddPart(embedPath, packaging.ContentTypeXLSX, workbookData); err != nil {
+		t.Fatal(err)
+	}
+	pkg.AddRelationship("word/charts/chart1.xml", "../embeddings/Microsoft_Excel_Worksheet.xlsx", packaging.RelTypePackage)
+	if err := pkg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	pkg.Close()
+
+	report, err := document.Open(reportPath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	objects := report.EmbeddedObjects()
+	if len(objects) != 1 || objects[0].PartPath != embedPath || objects[0].ProgID != "Excel.Sheet.12" {
+		t.Fatalf("EmbeddedObjects() = %+v", objects)
+	}
+	embedded, err := spreadsheet.OpenEmbedded(objects[0])
+	if err != nil {
+		t.Fatalf("OpenEmbedded() error = %v", err)
+	}
+	sheet, _ = embedded.SheetRaw(0)
+	sheet.Cell("B1").SetValue(250)
+	if err := embedded.Save(); err != nil {
+		t.Fatalf("embedded Save() error = %v", err)
+	}
+	embedded.Close()
+	if err := report.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	report.Close()
+
+	report, err = document.Open(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer report.Close()
+	embedded, err = spreadsheet.OpenEmbedded(report.EmbeddedObjects()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer embedded.Close()
+	sheet, _ = embedded.SheetRaw(0)
+	if got, err := sheet.Cell("B1").Float64(); err != nil || got != 250 {
+		t.Errorf("B1 = %v, %v, want 250", got, err)
+	}
+	if got := sheet.Cell("A1").String(); got != "Q1" {
+		t.Errorf("A1 = %q, want %q", got, "Q1")
+	}
+
+	// SaveWithOptions writes back into the parent just like Save.
+	sheet.Cell("B1").SetValue(300)
+	if err := embedded.SaveWithOptions(&packaging.SaveOptions{Deterministic: true}); err != nil {
+		t.Fatalf("embedded SaveWithOptions() error = %v", err)
+	}
+	if err := report.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	report.Close()
+
+	report, err = document.Open(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer report.Close()
+	reopened, err := spreadsheet.OpenEmbedded(report.EmbeddedObjects()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	sheet, _ = reopened.SheetRaw(0)
+	if got, err := sheet.Cell("B1").Float64(); err != nil || got != 300 {
+		t.Errorf("B1 after SaveWithOptions = %v, %v, want 300", got, err)
+	}
+}
+
+func TestInMemoryWorkflow_WriteToOpenBytes(t *testing.T) {
+	var _ io.WriterTo = document.Document(nil)
+	var _ io.WriterTo = spreadsheet.Workbook(nil)
+	var _ io.WriterTo = presentation.Presentation(nil)
+
+	doc, err := document.New()
+	if err != nil {
+		t.Fatalf("document.New() error = %v", err)
+	}
+	doc.AddParagraph().SetText("In memory")
+	var docBuf bytes.Buffer
+	if n, err := doc.WriteTo(&docBuf); err != nil || n != int64(docBuf.Len()) {
+		t.Fatalf("Document.WriteTo() = %d, %v; wrote %d bytes", n, err, docBuf.Len())
+	}
+	doc2, err := document.OpenBytes(docBuf.Bytes())
+	if err != nil {
+		t.Fatalf("document.OpenBytes() error = %v", err)
+	}
+	defer doc2.Close()
+	if got := doc2.Paragraphs()[0].Text(); got != "In memory" {
+		t.Errorf("paragraph text = %q, want %q", got, "In memory")
+	}
+
+	wb, err := spreadsheet.New()
+	if err != nil {
+		t.Fatalf("spreadsheet.New() error = %v", err)
+	}
+	sheet, _ := wb.Sheet(0)
+	sheet.Cell("A1").SetValue("in memory")
+	var wbBuf bytes.Buffer
+	if _, err := wb.WriteTo(&wbBuf); err != nil {
+		t.Fatalf("Workbook.WriteTo() error = %v", err)
+	}
+	wb2, err := spreadsheet.OpenBytes(wbBuf.Bytes())
+	if err != nil {
+		t.Fatalf("spreadsheet.OpenBytes() error = %v", err)
+	}
+	defer wb2.Close()
+	sheet2, _ := wb2.Sheet(0)
+	if got := sheet2.Cell("A1").String(); got != "in memory" {
+		t.Errorf("A1 = %q, want %q", got, "in memory")
+	}
+
+	pres, err := presentation.New()
+	if err != nil {
+		t.Fatalf("presentation.New() error = %v", err)
+	}
+	pres.AddSlide(0)
+	var presBuf bytes.Buffer
+	if _, err := pres.WriteTo(&presBuf); err != nil {
+		t.Fatalf("Presentation.WriteTo() error = %v", err)
+	}
+	pres2, err := presentation.OpenBytes(presBuf.Bytes())
+	if err != nil {
+		t.Fatalf("presentation.OpenBytes() error = %v", err)
+	}
+	defer pres2.Close()
+	if got := pres2.SlideCount(); got != 1 {
+		t.Errorf("SlideCount() = %d, want 1", got)
+	}
+}
+
+func TestInMemoryWorkflow_WriteToEncrypted(t *testing.T) {
+	ctx := context.Background()
+	opts := &packaging.SaveOptions{Password: "in-memory"}
+	open := &packaging.OpenOptions{Password: "in-memory"}
+
+	doc, err := document.New()
+	if err != nil {
+		t.Fatalf("document.New() error = %v", err)
+	}
+	doc.AddParagraph().SetText("Encrypted")
+	if _, err := doc.WriteToContext(ctx, io.Discard, opts); err != nil {
+		t.Fatalf("Document.WriteToContext() error = %v", err)
+	}
+	// The password is remembered, so WriteTo encrypts like Save does.
+	var docBuf bytes.Buffer
+	if _, err := doc.WriteTo(&docBuf); err != nil {
+		t.Fatalf("Document.WriteTo() error = %v", err)
+	}
+	if _, err := document.OpenBytes(docBuf.Bytes()); !errors.Is(err, utils.ErrPasswordRequired) {
+		t.Fatalf("document.OpenBytes() error = %v, want ErrPasswordRequired", err)
+	}
+	doc2, err := document.OpenReaderWithOptions(bytes.NewReader(docBuf.Bytes()), int64(docBuf.Len()), open)
+	if err != nil {
+		t.Fatalf("document.OpenReaderWithOptions() error = %v", err)
+	}
+	defer doc2.Close()
+	if got := doc2.Paragraphs()[0].Text(); got != "Encrypted" {
+		t.Errorf("paragraph text = %q, want %q", got, "Encrypted")
+	}
+
+	wb, err := spreadsheet.New()
+	if err != nil {
+		t.Fatalf("spreadsheet.New() error = %v", err)
+	}
+	sheet, _ := wb.Sheet(0)
+	sheet.Cell("A1").SetValue("encrypted")
+	var wbBuf bytes.Buffer
+	if _, err := wb.WriteToContext(ctx, &wbBuf, opts); err != nil {
+		t.Fatalf("Workbook.WriteToContext() error = %v", err)
+	}
+	if _, err := spreadsheet.OpenBytes(wbBuf.Bytes()); !errors.Is(err, utils.ErrPasswordRequired) {
+		t.Fatalf("spreadsheet.OpenBytes() error = %v, want ErrPasswordRequired", err)
+	}
+	wb2, err := spreadsheet.OpenReaderWithOptions(bytes.NewReader(wbBuf.Bytes()), int64(wbBuf.Len()), open)
+	if err != nil {
+		t.Fatalf("spreadsheet.OpenReaderWithOptions() error = %v", err)
+	}
+	defer wb2.Close()
+	sheet2, _ := wb2.Sheet(0)
+	if got := sheet2.Cell("A1").String(); got != "encrypted" {
+		t.Errorf("A1 = %q, want %q", got, "encrypted")
+	}
+
+	pres, err := presentation.New()
+	if err != nil {
+		t.Fatalf("presentation.New() error = %v", err)
+	}
+	pres.AddSlide(0)
+	var presBuf bytes.Buffer
+	if _, err := pres.WriteToContext(ctx, &presBuf, opts); err != nil {
+		t.Fatalf("Presentation.WriteToContext() error = %v", err)
+	}
+	if _, err := presentation.OpenBytes(presBuf.Bytes()); !errors.Is(err, utils.ErrPasswordRequired) {
+		t.Fatalf("presentation.OpenBytes() error = %v, want ErrPasswordRequired", err)
+	}
+	pres2, err := presentation.OpenReaderWithOptions(bytes.NewReader(presBuf.Bytes()), int64(presBuf.Len()), open)
+	if err != nil {
+		t.Fatalf("presentation.OpenReaderWithOptions() error = %v", err)
+	}
+	defer pres2.Close()
+	if got := pres2.SlideCount(); got != 1 {
+		t.Errorf("SlideCount() = %d, want 1", got)
+	}
+}
@@ -2,8 +2,11 @@
 package document
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
 	"io"
+	"io/fs"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +26,8 @@ type documentImpl struct {
 	comments *wml.Comments
 	commentsExtended *wml.CommentsEx
 	numbering *wml.Numbering
+	footnotes *wml.Footnotes
+	endnotes  *wml.Endnotes
 
 	// Tracking
 	trackChanges     bool
@@ -84,23 +89,98 @@ func New() (Document, error) {
 
 // Open opens an existing Word document.
 func Open(path string) (Document, error) {
-	pkg, err := packaging.Open(path)
+	return OpenWithOptions(path, nil)
+}
+
+// OpenWithOptions opens an existing Word document using opts, e.g. to supply the
+// password of an encrypted file.
+func OpenWithOptions(path string, opts *OpenOptions) (Document, error) {
+	pkg, err := packaging.OpenWithOptions(path, opts)
 	if err != nil {
 		return nil, err
 	}
-	return openFromPackage(pkg)
+	return openFromPackage(context.Background(), pkg)
+}
+
+// OpenContext opens an existing Word document like OpenWithOptions, giving up
+// with ctx.Err() once ctx is done. opts may be nil.
+func OpenContext(ctx context.Context, path string, opts *OpenOptions) (Document, error) {
+	pkg, err := packaging.OpenContext(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := openFromPackage(ctx, pkg)
+	if err == nil {
+		// Optional parts are parsed leniently; report a cancellation they hid.
+		err = ctx.Err()
+	}
+	if err != nil {
+		pkg.Close()
+		return nil, err
+	}
+	return doc, nil
+}
+
+// OpenBytes opens a Word document held in memory.
+func OpenBytes(data []byte) (Document, error) {
+	return OpenReader(bytes.NewReader(data), int64(len(data)))
 }
 
 // OpenReader opens a Word document from an io.ReaderAt.
+//
+// Parts are read from r on demand, so r must remain readable until the
+// package is closed.
 func OpenReader(r io.ReaderAt, size int64) (Document, error) {
-	pkg, err := packaging.OpenReader(r, size)
+	return OpenReaderWithOptions(r, size, nil)
+}
+
+// OpenReaderWithOptions opens a Word document from an io.ReaderAt using opts.
+// Unless the package is encrypted, r must remain readable until it is
+// closed.
+func OpenReaderWithOptions(r io.ReaderAt, size int64, opts *OpenOptions) (Document, error) {
+	pkg, err := packaging.OpenReaderWithOptions(r, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	return openFromPackage(context.Background(), pkg)
+}
+
+// OpenEmbedded opens a Word document embedded in another package. Save writes
+// the changes back into the parent, which must then be saved itself.
+func OpenEmbedded(obj *EmbeddedObject) (Document, error) {
+	pkg, err := obj.Open()
+	if err != nil {
+		return nil, err
+	}
+	return openFromPackage(context.Background(), pkg)
+}
+
+// OpenFS opens a Word document from an unzipped directory tree, such as one written
+// by WriteDir or bundled with embed.FS.
+func OpenFS(fsys fs.FS) (Document, error) {
+	pkg, err := packaging.OpenFS(fsys)
 	if err != nil {
 		return nil, err
 	}
-	return openFromPackage(pkg)
+	return openFromPackage(context.Background(), pkg)
+}
+
+// OpenFlatOPC opens a Word document saved as a single Flat OPC XML file.
+func OpenFlatOPC(r io.Reader) (Document, error) {
+	return OpenFlatOPCWithOptions(r, nil)
 }
 
-func openFromPackage(pkg *packaging.Package) (*documentImpl, error) {
+// OpenFlatOPCWithOptions opens a Word document saved as a single Flat OPC XML file
+// using opts, e.g. to enforce resource limits on untrusted input.
+func OpenFlatOPCWithOptions(r io.Reader, opts *OpenOptions) (Document, error) {
+	pkg, err := packaging.OpenFlatOPCWithOptions(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return openFromPackage(context.Background(), pkg)
+}
+
+func openFromPackage(ctx context.Context, pkg *packaging.Package) (*documentImpl, error) {
 	doc := &documentImpl{
 		pkg:     pkg,
 		headers: make(map[string]*headerImpl),
@@ -109,24 +189,27 @@ func openFromPackage(pkg *packaging.Package) (*documentImpl, error) {
 	}
 
 	// Parse document.xml
-	if err := doc.parseDocument(); err != nil {
+	if err := doc.parseDocument(ctx); err != nil {
 		return nil, err
 	}
 
 	// Parse styles.xml (optional)
-	_ = doc.parseStyles()
+	doc.parseOptional(ctx, packaging.RelTypeStyles, doc.parseStyles, func() { doc.styles = nil })
 
 	// Parse settings.xml (optional)
-	_ = doc.parseSettings()
+	doc.parseOptional(ctx, packaging.RelTypeSettings, doc.parseSettings, func() { doc.settings = nil })
 
 	// Parse comments.xml (optional)
-	_ = doc.parseComments()
-	_ = doc.parseCommentsExtended()
+	doc.parseOptional(ctx, packaging.RelTypeComments, doc.parseComments, func() { doc.comments = nil })
+	doc.parseOptional(ctx, packaging.RelTypeCommentsExtended, doc.parseCommentsExtended, func() { doc.commentsExtended = nil })
 	// Parse numbering.xml (optional)
-	_ = doc.parseNumbering()
+	doc.parseOptional(ctx, packaging.RelTypeNumbering, doc.parseNumbering, func() { doc.numbering = nil })
+	// Parse footnotes.xml and endnotes.xml (optional)
+	doc.parseOptional(ctx, packaging.RelTypeFootnotes, doc.parseFootnotes, func() { doc.footnotes = nil })
+	doc.parseOptional(ctx, packaging.RelTypeEndnotes, doc.parseEndnotes, func() { doc.endnotes = nil })
 	doc.parseBookmarks()
-	_ = doc.parseHeaders()
-	_ = doc.parseFooters()
+	_ = doc.parseHeaders(ctx)
+	_ = doc.parseFooters(ctx)
 	doc.initDrawingCounters()
 
 	return doc, nil
@@ -134,7 +217,7 @@ func openFromPackage(pkg *packaging.Package) (*documentImpl, error) {
 
 // Save saves the document to its original path.
 func (d *documentImpl) Save() error {
-	if err := d.updatePackage(); err != nil {
+	if err := d.updatePackage(context.Background()); err != nil {
 		return err
 	}
 	return d.pkg.Save()
@@ -142,12 +225,140 @@ func (d *documentImpl) Save() error {
 
 // SaveAs saves the document to a new path.
 func (d *documentImpl) SaveAs(path string) error {
-	if err := d.updatePackage(); err != nil {
+	if err := d.updatePackage(context.Background()); err != nil {
 		return err
 	}
 	return d.pkg.SaveAs(path)
 }
 
+// SaveWithOptions saves the document to its original path using opts.
+func (d *documentImpl) SaveWithOptions(opts *SaveOptions) error {
+	return d.saveWithOptions(context.Background(), opts)
+}
+
+func (d *documentImpl) saveWithOptions(ctx context.Context, opts *SaveOptions) error {
+	if err := d.prepareSave(ctx, opts); err != nil {
+		return err
+	}
+	return d.pkg.SaveContext(ctx, opts)
+}
+
+// SaveAsWithOptions saves the document to a new path using opts.
+func (d *documentImpl) SaveAsWithOptions(path string, opts *SaveOptions) error {
+	return d.saveAsWithOptions(context.Background(), path, opts)
+}
+
+func (d *documentImpl) saveAsWithOptions(ctx context.Context, path string, opts *SaveOptions) error {
+	if err := d.prepareSave(ctx, opts); err != nil {
+		return err
+	}
+	return d.pkg.SaveAsContext(ctx, path, opts)
+}
+
+// WriteTo writes the document to w as a ZIP archive without touching disk,
+// encrypted like SaveAs when a password is set, and returns the number of
+// bytes written, implementing io.WriterTo.
+func (d *documentImpl) WriteTo(w io.Writer) (int64, error) {
+	return d.writeTo(context.Background(), w, nil)
+}
+
+// SaveContext saves the document to its original path like SaveWithOptions,
+// stopping once ctx is done. The target is only replaced once complete.
+func (d *documentImpl) SaveContext(ctx context.Context, opts *SaveOptions) error {
+	return d.saveWithOptions(ctx, opts)
+}
+
+// SaveAsContext saves the document to a new path like SaveAsWithOptions,
+// stopping once ctx is done. The target is only replaced once complete.
+func (d *documentImpl) SaveAsContext(ctx context.Context, path string, opts *SaveOptions) error {
+	return d.saveAsWithOptions(ctx, path, opts)
+}
+
+// WriteToContext writes the document to w as a ZIP archive, stopping once
+// ctx is done, and returns the number of bytes written.
+func (d *documentImpl) WriteToContext(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error) {
+	return d.writeTo(ctx, w, opts)
+}
+
+// writeTo runs the SaveAsWithOptions pipeline against w instead of a file.
+func (d *documentImpl) writeTo(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error) {
+	if err := d.prepareSave(ctx, opts); err != nil {
+		return 0, err
+	}
+	return d.pkg.WriteToContext(ctx, w, opts)
+}
+
+// prepareSave writes the document state into the package before it is saved
+// with opts.
+func (d *documentImpl) prepareSave(ctx context.Context, opts *SaveOptions) error {
+	if err := d.updatePackage(ctx); err != nil {
+		return err
+	}
+	if opts != nil && opts.UpdateExtendedProperties {
+		return d.UpdateExtendedProperties()
+	}
+	return nil
+}
+
+// SaveAsTemplate saves the document as a template (.dotx or .dotm, keeping
+// macros) to a new path. The document remains a template afterwards.
+func (d *documentImpl) SaveAsTemplate(path string) error {
+	if err := d.SetVariant(d.Variant().WithTemplate(true)); err != nil {
+		return err
+	}
+	return d.SaveAs(path)
+}
+
+// Variant reports whether the document is a regular, template or macro-enabled file.
+func (d *documentImpl) Variant() Variant {
+	return d.pkg.Variant()
+}
+
+// SetVariant converts the document to another variant. Converting to a variant
+// that is not macro-enabled removes the VBA project.
+func (d *documentImpl) SetVariant(v Variant) error {
+	return d.pkg.SetVariant(v)
+}
+
+// HasMacros reports whether the document contains a VBA project.
+func (d *documentImpl) HasMacros() bool {
+	return d.pkg.HasMacros()
+}
+
+// IsStrict reports whether the document was read from a Strict Open XML file.
+// Save with SaveOptions.Strict to keep that conformance class.
+func (d *documentImpl) IsStrict() bool {
+	return d.pkg.IsStrict()
+}
+
+// Diagnostics returns the problems recovered from when the document was
+// opened with OpenOptions.Recover.
+func (d *documentImpl) Diagnostics() []Diagnostic {
+	return d.pkg.Diagnostics()
+}
+
+// EmbeddedObjects lists the OLE objects and packages embedded in the document.
+func (d *documentImpl) EmbeddedObjects() []*EmbeddedObject {
+	return d.pkg.EmbeddedObjects()
+}
+
+// WriteDir writes the document as an unzipped directory tree, optionally with
+// indented XML for review. OpenFS reads it back.
+func (d *documentImpl) WriteDir(dir string, pretty bool) error {
+	if err := d.updatePackage(context.Background()); err != nil {
+		return err
+	}
+	return d.pkg.WriteDir(dir, pretty)
+}
+
+// WriteFlatOPC writes the document as a single Flat OPC XML file.
+func (d *documentImpl) WriteFlatOPC(w io.Writer) error {
+	if err := d.updatePackage(context.Background()); err != nil {
+		return err
+	}
+	return d.pkg.WriteFlatOPC(w)
+}
+
 // Close closes the document.
 func (d *documentImpl) Close() error {
 	return d.pkg.Close()
@@ -197,6 +408,42 @@ func (d *documentImpl) SetCoreProperties(props *common.CoreProperties) error {
 	return d.pkg.SetCoreProperties(props)
 }
 
+// CustomProperties returns the document custom properties (docProps/custom.xml).
+func (d *documentImpl) CustomProperties() (*common.CustomProperties, error) {
+	return d.pkg.CustomProperties()
+}
+
+// SetCustomProperties replaces all document custom properties.
+func (d *documentImpl) SetCustomProperties(props *common.CustomProperties) error {
+	return d.pkg.SetCustomProperties(props)
+}
+
+// CustomProperty returns a custom property by name (case-insensitive).
+func (d *documentImpl) CustomProperty(name string) (*common.CustomProperty, error) {
+	return d.pkg.CustomProperty(name)
+}
+
+// SetCustomProperty adds or replaces a custom property. The vt: type follows
+// the Go type of value: string, int, float64, bool or time.Time.
+func (d *documentImpl) SetCustomProperty(name string, value interface{}) error {
+	return d.pkg.SetCustomProperty(name, value)
+}
+
+// DeleteCustomProperty removes a custom property.
+func (d *documentImpl) DeleteCustomProperty(name string) error {
+	return d.pkg.DeleteCustomProperty(name)
+}
+
+// Thumbnail returns the package thumbnail image and its content type.
+func (d *documentImpl) Thumbnail() (string, []byte, error) {
+	return d.pkg.Thumbnail()
+}
+
+// SetThumbnail sets the package thumbnail image shown by file browsers.
+func (d *documentImpl) SetThumbnail(contentType string, data []byte) error {
+	return d.pkg.SetThumbnail(contentType, data)
+}
+
 // Properties returns the document properties (core properties).
 func (d *documentImpl) Properties() DocumentProperties {
 	props, _ := d.pkg.CoreProperties()
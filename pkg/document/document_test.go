@@ -1,12 +1,18 @@
 package document
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
+	"github.com/rcarmo/go-ooxml/pkg/packaging"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
 )
 
 // =============================================================================
@@ -83,6 +89,296 @@ func TestDocument_CoreProperties(t *testing.T) {
 	}
 }
 
+func TestDocument_Thumbnail(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(nil)
+
+	png := []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}
+	if err := doc.SetThumbnail("image/png", png); err != nil {
+		t.Fatalf("SetThumbnail() error = %v", err)
+	}
+	path := h.SaveDocument(doc, "thumbnail.docx")
+	doc.Close()
+
+	doc2 := h.OpenDocument(path)
+	defer doc2.Close()
+	contentType, data, err := doc2.Thumbnail()
+	if err != nil {
+		t.Fatalf("Thumbnail() error = %v", err)
+	}
+	if contentType != "image/png" {
+		t.Errorf("content type = %q, want image/png", contentType)
+	}
+	if string(data) != string(png) {
+		t.Errorf("thumbnail data = %v, want %v", data, png)
+	}
+}
+
+func TestDocument_OpenReaderWithLimits(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(nil)
+	doc.AddParagraph().SetText("untrusted upload")
+	path := h.SaveDocument(doc, "limits.docx")
+	doc.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// document.xml nests w:document/w:body/w:p/w:r/w:t.
+	if _, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), &OpenOptions{MaxXMLDepth: 4}); !errors.Is(err, utils.ErrLimitExceeded) {
+		t.Fatalf("OpenReaderWithOptions() error = %v, want ErrLimitExceeded", err)
+	}
+
+	doc2, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), &OpenOptions{
+		MaxTotalSize: 10 << 20,
+		MaxParts:     100,
+		MaxXMLDepth:  64,
+	})
+	if err != nil {
+		t.Fatalf("OpenReaderWithOptions() error = %v", err)
+	}
+	defer doc2.Close()
+	if got := doc2.Paragraphs()[0].Text(); got != "untrusted upload" {
+		t.Errorf("Text() = %q, want %q", got, "untrusted upload")
+	}
+}
+
+func TestDocument_FlatOPCRoundTrip(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(nil)
+	doc.AddParagraph().SetText("Flat XML")
+	doc.AddTable(2, 2).Cell(1, 1).SetText("cell")
+
+	var buf bytes.Buffer
+	if err := doc.WriteFlatOPC(&buf); err != nil {
+		t.Fatalf("WriteFlatOPC() error = %v", err)
+	}
+	doc.Close()
+	if !strings.Contains(buf.String(), `<?mso-application progid="Word.Document"?>`) {
+		t.Error("flat output missing Word program ID")
+	}
+
+	doc2, err := OpenFlatOPC(&buf)
+	if err != nil {
+		t.Fatalf("OpenFlatOPC() error = %v", err)
+	}
+	defer doc2.Close()
+	if got := doc2.Paragraphs()[0].Text(); got != "Flat XML" {
+		t.Errorf("paragraph text = %q, want %q", got, "Flat XML")
+	}
+	if got := doc2.Tables()[0].Cell(1, 1).Text(); got != "cell" {
+		t.Errorf("cell text = %q, want %q", got, "cell")
+	}
+	path := filepath.Join(h.tempDir, "from_flat.docx")
+	if err := doc2.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	h.OpenDocument(path).Close()
+}
+
+func TestDocument_StrictRoundTrip(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(nil)
+	doc.AddParagraph().SetText("Strict Open XML")
+	doc.AddTable(1, 1).Cell(0, 0).SetText("cell")
+	strictPath := filepath.Join(h.tempDir, "strict.docx")
+	if err := doc.SaveAsWithOptions(strictPath, &SaveOptions{Strict: true}); err != nil {
+		t.Fatalf("SaveAsWithOptions() error = %v", err)
+	}
+	doc.Close()
+
+	doc2 := h.OpenDocument(strictPath)
+	defer doc2.Close()
+	if !doc2.IsStrict() {
+		t.Error("IsStrict() = false, want true")
+	}
+	if got := doc2.Paragraphs()[0].Text(); got != "Strict Open XML" {
+		t.Errorf("paragraph text = %q, want %q", got, "Strict Open XML")
+	}
+	if got := doc2.Tables()[0].Cell(0, 0).Text(); got != "cell" {
+		t.Errorf("cell text = %q, want %q", got, "cell")
+	}
+
+	path := filepath.Join(h.tempDir, "transitional.docx")
+	if err := doc2.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	doc3 := h.OpenDocument(path)
+	defer doc3.Close()
+	if doc3.IsStrict() {
+		t.Error("SaveAs() should write Transitional by default")
+	}
+	if got := doc3.Paragraphs()[0].Text(); got != "Strict Open XML" {
+		t.Errorf("paragraph text = %q after conversion", got)
+	}
+}
+
+func TestDocument_WriteDirRoundTrip(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(nil)
+	doc.AddParagraph().SetText("Unzipped")
+	dir := filepath.Join(h.tempDir, "unzipped")
+	if err := doc.WriteDir(dir, true); err != nil {
+		t.Fatalf("WriteDir() error = %v", err)
+	}
+	doc.Close()
+
+	doc2, err := OpenFS(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("OpenFS() error = %v", err)
+	}
+	defer doc2.Close()
+	if got := doc2.Paragraphs()[0].Text(); got != "Unzipped" {
+		t.Errorf("paragraph text = %q, want %q", got, "Unzipped")
+	}
+	path := filepath.Join(h.tempDir, "zipped.docx")
+	if err := doc2.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	doc3 := h.OpenDocument(path)
+	defer doc3.Close()
+	if got := doc3.Paragraphs()[0].Text(); got != "Unzipped" {
+		t.Errorf("paragraph text = %q after zipping", got)
+	}
+}
+
+func TestDocument_OpenRecover(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(nil)
+	doc.AddParagraph().SetText("Salvaged")
+	if _, err := doc.Comments().Add("Lost", "Reviewer", "Salvaged"); err != nil {
+		t.Fatal(err)
+	}
+	var src bytes.Buffer
+	if _, err := doc.WriteTo(&src); err != nil {
+		t.Fatal(err)
+	}
+	doc.Close()
+
+	pkg, err := packaging.OpenBytes(src.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = pkg.AddPart("word/comments.xml", packaging.ContentTypeComments, []byte("<w:comments"))
+	var damaged bytes.Buffer
+	if err := pkg.WriteTo(&damaged); err != nil {
+		t.Fatal(err)
+	}
+
+	doc2, err := OpenReaderWithOptions(bytes.NewReader(damaged.Bytes()), int64(damaged.Len()), &OpenOptions{Recover: true})
+	if err != nil {
+		t.Fatalf("OpenReaderWithOptions() error = %v", err)
+	}
+	defer doc2.Close()
+	diags := doc2.Diagnostics()
+	if len(diags) != 1 {
+		t.Fatalf("Diagnostics() = %v, want one", diags)
+	}
+	if d := diags[0]; d.PartURI != "word/comments.xml" || d.Severity != SeverityWarning || d.Action != "dropped comments part" || d.Err == nil {
+		t.Errorf("Diagnostics()[0] = %+v", d)
+	}
+	if got := doc2.Paragraphs()[0].Text(); got != "Salvaged" {
+		t.Errorf("paragraph text = %q, want %q", got, "Salvaged")
+	}
+
+	var out bytes.Buffer
+	if _, err := doc2.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	doc3, err := OpenBytes(out.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer doc3.Close()
+	if len(doc3.Diagnostics()) != 0 || len(doc3.Comments().All()) != 0 {
+		t.Errorf("reopened document has diagnostics %v and %d comments", doc3.Diagnostics(), len(doc3.Comments().All()))
+	}
+}
+
+func TestDocument_CustomProperties(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(nil)
+
+	if err := doc.SetCustomProperty("ClientID", "C-1001"); err != nil {
+		t.Fatalf("SetCustomProperty() error = %v", err)
+	}
+	if err := doc.SetCustomProperty("Retention", 10); err != nil {
+		t.Fatalf("SetCustomProperty() error = %v", err)
+	}
+	path := h.SaveDocument(doc, "custom_props.docx")
+	doc.Close()
+
+	doc2 := h.OpenDocument(path)
+	defer doc2.Close()
+	prop, err := doc2.CustomProperty("ClientID")
+	if err != nil {
+		t.Fatalf("CustomProperty() error = %v", err)
+	}
+	if prop.String() != "C-1001" {
+		t.Errorf("ClientID = %q, want C-1001", prop.String())
+	}
+	prop, err = doc2.CustomProperty("Retention")
+	if err != nil {
+		t.Fatalf("CustomProperty() error = %v", err)
+	}
+	if n, err := prop.Int(); err != nil || n != 10 {
+		t.Errorf("Retention = %d, %v; want 10", n, err)
+	}
+	if err := doc2.DeleteCustomProperty("ClientID"); err != nil {
+		t.Fatalf("DeleteCustomProperty() error = %v", err)
+	}
+	props, err := doc2.CustomProperties()
+	if err != nil {
+		t.Fatalf("CustomProperties() error = %v", err)
+	}
+	if names := props.Names(); len(names) != 1 || names[0] != "Retention" {
+		t.Errorf("Names() = %v, want [Retention]", names)
+	}
+}
+
+func TestDocument_UpdateExtendedProperties(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(nil)
+
+	doc.AddParagraph().SetText("The quick brown fox")
+	doc.AddParagraph()
+	table := doc.AddTable(1, 1)
+	table.Cell(0, 0).SetText("jumps")
+	if err := doc.SetCoreProperties(&common.CoreProperties{Title: "Fox Report"}); err != nil {
+		t.Fatalf("SetCoreProperties() error = %v", err)
+	}
+	if err := doc.SetExtendedProperties(&common.ExtendedProperties{Company: "ACME", Manager: "Wile E."}); err != nil {
+		t.Fatalf("SetExtendedProperties() error = %v", err)
+	}
+
+	path := filepath.Join(h.tempDir, "stats.docx")
+	if err := doc.SaveAsWithOptions(path, &SaveOptions{UpdateExtendedProperties: true}); err != nil {
+		t.Fatalf("SaveAsWithOptions() error = %v", err)
+	}
+	doc.Close()
+
+	doc2 := h.OpenDocument(path)
+	defer doc2.Close()
+	props, err := doc2.ExtendedProperties()
+	if err != nil {
+		t.Fatalf("ExtendedProperties() error = %v", err)
+	}
+	if props.Company != "ACME" || props.Manager != "Wile E." {
+		t.Errorf("Company/Manager = %q/%q", props.Company, props.Manager)
+	}
+	if props.Words != 5 || props.Paragraphs != 2 || props.Pages != 1 {
+		t.Errorf("Words/Paragraphs/Pages = %d/%d/%d, want 5/2/1", props.Words, props.Paragraphs, props.Pages)
+	}
+	if props.Characters != 21 || props.CharactersWithSpaces != 24 {
+		t.Errorf("Characters = %d/%d, want 21/24", props.Characters, props.CharactersWithSpaces)
+	}
+	if titles := props.PartTitles("Title"); len(titles) != 1 || titles[0] != "Fox Report" {
+		t.Errorf("Title part = %v", titles)
+	}
+}
+
 // =============================================================================
 // Paragraph Tests - Parameterized
 // =============================================================================
@@ -104,6 +400,25 @@ func TestParagraph_SetText(t *testing.T) {
 	}
 }
 
+func TestParagraph_ChartRoundTrip(t *testing.T) {
+	doc, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := doc.Body().AddChart(5486400, 3200400, "Revenue"); err != nil {
+		t.Fatalf("AddChart() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	reopened, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer reopened.Close()
+}
+
 func TestParagraph_SetStyle(t *testing.T) {
 	for _, tc := range CommonHeadingStyleCases {
 		t.Run(tc.Name, func(t *testing.T) {
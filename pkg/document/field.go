@@ -2,14 +2,59 @@
 package document
 
 import (
+	"sort"
+	"strings"
+	"unicode"
+
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
 	"github.com/rcarmo/go-ooxml/pkg/utils"
 )
 
-// Field represents a Word field.
+// Field represents a Word field: either a complex field, whose runs hold
+// begin, instruction, separate and end markers around the cached result, or
+// a simple field (w:fldSimple).
 type Field struct {
+	// Instruction is the field code, e.g. `DATE \@ "d MMMM yyyy"`. Nested
+	// fields appear in braces, e.g. `IF {MERGEFIELD Title} = "Dr" ...`.
 	Instruction string
-	Display     string
+	// Display is the cached result text.
+	Display string
+	// Type is the upper-cased field type, e.g. "MERGEFIELD".
+	Type string
+	// Arguments are the unquoted arguments after the type, with nested
+	// fields replaced by their results.
+	Arguments []string
+	// Switches are the field switches in instruction order.
+	Switches []FieldSwitch
+
+	doc       *documentImpl
+	simple    *wml.FldSimple
+	container *[]interface{} // content holding a simple field
+	begin     *fieldRun
+	sep       *fieldRun
+	end       *fieldRun
+	result    []*fieldRun
+	instr     []fieldPiece
+}
+
+// FieldSwitch is a field switch such as `\@ "d MMMM yyyy"` or `\h`.
+type FieldSwitch struct {
+	// Name is the switch with its backslash, e.g. `\@`.
+	Name string
+	// Value is the switch argument, or "" for a flag.
+	Value string
+}
+
+// fieldRun is a run of a complex field and the content slice holding it.
+type fieldRun struct {
+	r       *wml.R
+	content *[]interface{}
+}
+
+// fieldPiece is instruction text or a nested field.
+type fieldPiece struct {
+	text  string
+	field *Field
 }
 
 // AddField inserts a field with instruction and optional display text.
@@ -23,11 +68,372 @@ func (p *paragraphImpl) AddField(instruction, display string) (*Field, error) {
 	sep := &wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharSeparate}}}
 	end := &wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharEnd}}}
 
+	field := &Field{
+		doc:   p.doc,
+		begin: &fieldRun{r: begin, content: &p.p.Content},
+		sep:   &fieldRun{r: sep, content: &p.p.Content},
+		end:   &fieldRun{r: end, content: &p.p.Content},
+		instr: []fieldPiece{{text: instruction}},
+	}
 	p.p.Content = append(p.p.Content, begin, instr, sep)
 	if display != "" {
-		p.p.Content = append(p.p.Content, &wml.R{Content: []interface{}{wml.NewT(display)}})
+		result := &wml.R{Content: []interface{}{wml.NewT(display)}}
+		p.p.Content = append(p.p.Content, result)
+		field.result = append(field.result, &fieldRun{r: result, content: &p.p.Content})
 	}
 	p.p.Content = append(p.p.Content, end)
 
-	return &Field{Instruction: instruction, Display: display}, nil
+	field.parse()
+	field.Instruction = instruction
+	return field, nil
+}
+
+// Fields returns the fields of the document body, headers and footers in
+// document order. A nested field follows the field that contains it.
+func (d *documentImpl) Fields() []*Field {
+	var fields []*Field
+	for _, s := range d.scanFields() {
+		fields = append(fields, s.fields...)
+	}
+	return fields
+}
+
+// IsSimple reports whether the field is stored as a w:fldSimple element.
+func (f *Field) IsSimple() bool {
+	return f.simple != nil
+}
+
+// Locked reports whether the field is locked against updates.
+func (f *Field) Locked() bool {
+	if f.simple != nil {
+		return f.simple.FldLock != nil && *f.simple.FldLock
+	}
+	for _, elem := range f.begin.r.Content {
+		if fc, ok := elem.(*wml.FldChar); ok && fc.FldCharType == wml.FldCharBegin {
+			return fc.Lock != nil && *fc.Lock
+		}
+	}
+	return false
+}
+
+// Switch returns the value of the first switch with the given name, which
+// may be given with or without its backslash. Switch names are not case
+// sensitive.
+func (f *Field) Switch(name string) (string, bool) {
+	name = strings.TrimPrefix(name, `\`)
+	for _, sw := range f.Switches {
+		if strings.EqualFold(strings.TrimPrefix(sw.Name, `\`), name) {
+			return sw.Value, true
+		}
+	}
+	return "", false
+}
+
+// ResultRuns returns the runs holding the cached result.
+func (f *Field) ResultRuns() []Run {
+	var runs []Run
+	for _, r := range f.resultRuns() {
+		runs = append(runs, &runImpl{doc: f.doc, r: r})
+	}
+	return runs
+}
+
+func (f *Field) resultRuns() []*wml.R {
+	var runs []*wml.R
+	if f.simple != nil {
+		for _, elem := range f.simple.Content {
+			if r, ok := elem.(*wml.R); ok {
+				runs = append(runs, r)
+			}
+		}
+		return runs
+	}
+	for _, fr := range f.result {
+		runs = append(runs, fr.r)
+	}
+	return runs
+}
+
+// resultText returns the current result text.
+func (f *Field) resultText() string {
+	var sb strings.Builder
+	for _, r := range f.resultRuns() {
+		sb.WriteString(textFromRun(r))
+	}
+	return sb.String()
+}
+
+// code returns the raw instruction, with nested fields in braces.
+func (f *Field) code() string {
+	if f.simple != nil {
+		return f.simple.Instr
+	}
+	var sb strings.Builder
+	for _, piece := range f.instr {
+		if piece.field != nil {
+			sb.WriteString("{" + piece.field.code() + "}")
+		} else {
+			sb.WriteString(piece.text)
+		}
+	}
+	return sb.String()
+}
+
+// parse fills the exported fields from the field's runs.
+func (f *Field) parse() {
+	f.Instruction = strings.TrimSpace(f.code())
+	f.Display = f.resultText()
+
+	pieces := f.instr
+	if f.simple != nil {
+		pieces = []fieldPiece{{text: f.simple.Instr}}
+	}
+	tokens := tokenizeField(pieces)
+	f.Type, f.Arguments, f.Switches = "", nil, nil
+	if len(tokens) == 0 {
+		return
+	}
+	f.Type = strings.ToUpper(tokens[0].text)
+	var last *FieldSwitch
+	for _, tok := range tokens[1:] {
+		if !tok.quoted && len(tok.text) > 1 && tok.text[0] == '\\' {
+			f.Switches = append(f.Switches, FieldSwitch{Name: tok.text})
+			last = &f.Switches[len(f.Switches)-1]
+			continue
+		}
+		// Arguments precede switches, so a value after a switch is its
+		// argument unless the switch already has one.
+		if last != nil && last.Value == "" {
+			last.Value = tok.text
+			continue
+		}
+		f.Arguments = append(f.Arguments, tok.text)
+	}
+}
+
+// fieldToken is a word of a field instruction.
+type fieldToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeField splits an instruction into words. Quoted text is one word,
+// with \" and \\ escapes, and the result of a nested field is never split.
+func tokenizeField(pieces []fieldPiece) []fieldToken {
+	var (
+		tokens  []fieldToken
+		cur     strings.Builder
+		started bool
+		quoted  bool
+		inQuote bool
+	)
+	flush := func() {
+		if started {
+			tokens = append(tokens, fieldToken{text: cur.String(), quoted: quoted})
+		}
+		cur.Reset()
+		started, quoted = false, false
+	}
+	for _, piece := range pieces {
+		if piece.field != nil {
+			cur.WriteString(piece.field.resultText())
+			started = true
+			continue
+		}
+		runes := []rune(piece.text)
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			switch {
+			case inQuote && r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\'):
+				i++
+				cur.WriteRune(runes[i])
+			case r == '"':
+				inQuote = !inQuote
+				started, quoted = true, true
+			case !inQuote && unicode.IsSpace(r):
+				flush()
+			default:
+				cur.WriteRune(r)
+				started = true
+			}
+		}
+	}
+	flush()
+	return tokens
+}
+
+// fieldScan holds the fields and bookmarks found in one story.
+type fieldScan struct {
+	doc     *documentImpl
+	content *[]interface{}
+	fields  []*Field // in order of their beginning
+	done    []*Field // in order of their end, nested fields first
+	open    []*Field
+
+	bookmarkNames map[int]string
+	openMarks     map[string]bool
+	bookmarks     map[string]*strings.Builder // keyed by lower-cased name
+}
+
+// scanFields scans the body, then the headers and footers, for fields.
+func (d *documentImpl) scanFields() []*fieldScan {
+	var scans []*fieldScan
+	for _, content := range d.stories() {
+		scans = append(scans, d.scanStory(content))
+	}
+	return scans
+}
+
+// stories returns the content of the body, then of the headers and footers
+// in relationship ID order.
+func (d *documentImpl) stories() []*[]interface{} {
+	var stories []*[]interface{}
+	if d.document != nil && d.document.Body != nil {
+		stories = append(stories, &d.document.Body.Content)
+	}
+	headerIDs := make([]string, 0, len(d.headers))
+	for id := range d.headers {
+		headerIDs = append(headerIDs, id)
+	}
+	sort.Strings(headerIDs)
+	for _, id := range headerIDs {
+		stories = append(stories, &d.headers[id].header.Content)
+	}
+	footerIDs := make([]string, 0, len(d.footers))
+	for id := range d.footers {
+		footerIDs = append(footerIDs, id)
+	}
+	sort.Strings(footerIDs)
+	for _, id := range footerIDs {
+		stories = append(stories, &d.footers[id].footer.Content)
+	}
+	return stories
+}
+
+// scanStory scans the content of a body, header or footer.
+func (d *documentImpl) scanStory(content *[]interface{}) *fieldScan {
+	s := &fieldScan{
+		doc:           d,
+		content:       content,
+		bookmarkNames: make(map[int]string),
+		openMarks:     make(map[string]bool),
+		bookmarks:     make(map[string]*strings.Builder),
+	}
+	s.scanContent(content)
+	// Drop complex fields that never end.
+	complete := s.fields[:0]
+	for _, f := range s.fields {
+		if f.simple != nil || f.end != nil {
+			complete = append(complete, f)
+		}
+	}
+	s.fields = complete
+	return s
+}
+
+func (s *fieldScan) scanContent(content *[]interface{}) {
+	for _, elem := range *content {
+		switch v := elem.(type) {
+		case *wml.P:
+			s.scanContent(&v.Content)
+			s.addBookmarkText("\n")
+		case *wml.Tbl:
+			for _, tr := range v.Tr {
+				for _, tc := range tr.Tc {
+					s.scanContent(&tc.Content)
+				}
+			}
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				s.scanContent(&v.SdtContent.Content)
+			}
+		case *wml.Ins:
+			s.scanContent(&v.Content)
+		case *wml.Hyperlink:
+			s.scanContent(&v.Content)
+		case *wml.R:
+			s.scanRun(v, content)
+		case *wml.FldSimple:
+			f := &Field{doc: s.doc, simple: v, container: content}
+			f.parse()
+			s.fields = append(s.fields, f)
+			s.done = append(s.done, f)
+			s.addBookmarkText(f.Display)
+		case *wml.BookmarkStart:
+			name := strings.ToLower(v.Name)
+			s.bookmarkNames[v.ID] = name
+			s.openMarks[name] = true
+			if _, ok := s.bookmarks[name]; !ok {
+				s.bookmarks[name] = &strings.Builder{}
+			}
+		case *wml.BookmarkEnd:
+			delete(s.openMarks, s.bookmarkNames[v.ID])
+		}
+	}
+}
+
+func (s *fieldScan) top() *Field {
+	if len(s.open) == 0 {
+		return nil
+	}
+	return s.open[len(s.open)-1]
+}
+
+func (s *fieldScan) scanRun(r *wml.R, content *[]interface{}) {
+	for _, elem := range r.Content {
+		switch v := elem.(type) {
+		case *wml.FldChar:
+			switch v.FldCharType {
+			case wml.FldCharBegin:
+				f := &Field{doc: s.doc, begin: &fieldRun{r: r, content: content}}
+				if parent := s.top(); parent != nil && parent.sep == nil {
+					parent.instr = append(parent.instr, fieldPiece{field: f})
+				}
+				s.fields = append(s.fields, f)
+				s.open = append(s.open, f)
+			case wml.FldCharSeparate:
+				if f := s.top(); f != nil && f.sep == nil {
+					f.sep = &fieldRun{r: r, content: content}
+				}
+			case wml.FldCharEnd:
+				if f := s.top(); f != nil {
+					f.end = &fieldRun{r: r, content: content}
+					s.open = s.open[:len(s.open)-1]
+					f.parse()
+					s.done = append(s.done, f)
+				}
+			}
+		case *wml.InstrText:
+			if f := s.top(); f != nil && f.sep == nil {
+				if n := len(f.instr); n > 0 && f.instr[n-1].field == nil {
+					f.instr[n-1].text += v.Text
+				} else {
+					f.instr = append(f.instr, fieldPiece{text: v.Text})
+				}
+			}
+		}
+	}
+	// The run is part of the result of every open field past its separator.
+	for _, f := range s.open {
+		if f.sep != nil && f.sep.r != r {
+			f.result = append(f.result, &fieldRun{r: r, content: content})
+		}
+	}
+	s.addBookmarkText(textFromRun(r))
+}
+
+func (s *fieldScan) addBookmarkText(text string) {
+	for name := range s.openMarks {
+		s.bookmarks[name].WriteString(text)
+	}
+}
+
+// bookmarkText returns the text of the named bookmark, without a trailing
+// paragraph mark.
+func (s *fieldScan) bookmarkText(name string) (string, bool) {
+	sb, ok := s.bookmarks[strings.ToLower(name)]
+	if !ok {
+		return "", false
+	}
+	return strings.TrimRight(sb.String(), "\n"), true
 }
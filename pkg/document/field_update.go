@@ -0,0 +1,799 @@
+package document
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// Field results Word shows for references that cannot be resolved.
+const (
+	fieldErrorBookmark = "Error! Reference source not found."
+	fieldErrorProperty = "Error! Unknown document property name."
+)
+
+// FieldUpdateOptions configures UpdateFieldsWithOptions.
+type FieldUpdateOptions struct {
+	// Now is the time DATE and TIME fields show. Zero means time.Now().
+	Now time.Time
+	// MergeData holds MERGEFIELD values by field name; names are matched
+	// without regard to case. Fields without a value show «Name».
+	MergeData map[string]string
+}
+
+// UpdateFields recalculates the fields that do not depend on page layout;
+// see UpdateFieldsWithOptions.
+func (d *documentImpl) UpdateFields() error {
+	return d.UpdateFieldsWithOptions(nil)
+}
+
+// UpdateFieldsWithOptions recalculates the cached results of the fields in
+// the body, headers and footers that can be evaluated without page layout:
+// DATE and TIME, document information fields (TITLE, AUTHOR, CREATEDATE,
+// DOCPROPERTY, ...), REF and bookmark references, SEQ, IF and MERGEFIELD,
+// with their \@, \# and \* format switches. Nested fields are updated
+// before the fields that contain them. Locked fields and fields such as
+// PAGE, NUMPAGES, PAGEREF and TOC keep their cached results. opts may be nil.
+func (d *documentImpl) UpdateFieldsWithOptions(opts *FieldUpdateOptions) error {
+	e, err := d.newFieldEvaluator(opts)
+	if err != nil {
+		return err
+	}
+	for _, scan := range d.scanFields() {
+		if err := e.updateStory(scan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newFieldEvaluator returns an evaluator for the document's fields. opts
+// may be nil.
+func (d *documentImpl) newFieldEvaluator(opts *FieldUpdateOptions) (*fieldEvaluator, error) {
+	e := &fieldEvaluator{doc: d, seq: make(map[string]int)}
+	if opts != nil {
+		e.now = opts.Now
+		e.mergeData = opts.MergeData
+	}
+	if e.now.IsZero() {
+		e.now = time.Now()
+	}
+	var err error
+	if e.core, err = d.CoreProperties(); errors.Is(err, utils.ErrDocumentClosed) {
+		return nil, err
+	}
+	e.custom, _ = d.CustomProperties()
+	e.ext, _ = d.ExtendedProperties()
+	return e, nil
+}
+
+// updateStory updates the fields of one story, nested fields before the
+// fields that contain them.
+func (e *fieldEvaluator) updateStory(scan *fieldScan) error {
+	e.marks, e.stale = scan, false
+	for _, f := range scan.done {
+		if e.merge != nil && e.merge.skip {
+			return nil
+		}
+		if f.Locked() {
+			continue
+		}
+		f.parse() // nested results may have changed
+		if f.Type == "INCLUDEPICTURE" && e.merge != nil {
+			if err := e.includePicture(f); err != nil {
+				return err
+			}
+			continue
+		}
+		if text, ok := e.evaluate(f); ok {
+			f.setResult(text)
+			e.stale = true
+		}
+	}
+	return nil
+}
+
+// setResult replaces the cached result with text, keeping the formatting of
+// the first result run.
+func (f *Field) setResult(text string) {
+	defer f.parse()
+	if f.simple != nil {
+		run := &wml.R{}
+		if runs := f.resultRuns(); len(runs) > 0 {
+			run.RPr = copyRPr(runs[0].RPr)
+		}
+		run.Content = fieldResultContent(text)
+		f.simple.Content = []interface{}{run}
+		f.simple.Dirty = nil
+		return
+	}
+	for _, elem := range f.begin.r.Content {
+		if fc, ok := elem.(*wml.FldChar); ok && fc.FldCharType == wml.FldCharBegin {
+			fc.Dirty = nil
+		}
+	}
+	if len(f.result) > 0 {
+		first := f.result[0]
+		first.r.Content = fieldResultContent(text)
+		for _, fr := range f.result[1:] {
+			removeRun(fr.content, fr.r)
+		}
+		f.result = f.result[:1]
+		return
+	}
+	run := &wml.R{RPr: copyRPr(f.begin.r.RPr), Content: fieldResultContent(text)}
+	if f.sep == nil {
+		sep := &wml.R{RPr: copyRPr(f.begin.r.RPr), Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharSeparate}}}
+		insertRunBefore(f.end.content, f.end.r, sep)
+		f.sep = &fieldRun{r: sep, content: f.end.content}
+	}
+	insertRunBefore(f.end.content, f.end.r, run)
+	f.result = []*fieldRun{{r: run, content: f.end.content}}
+}
+
+// fieldResultContent returns run content for text, with breaks for newlines.
+func fieldResultContent(text string) []interface{} {
+	var content []interface{}
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			content = append(content, &wml.Br{})
+		}
+		if line != "" {
+			content = append(content, wml.NewT(line))
+		}
+	}
+	return content
+}
+
+func copyRPr(rpr *wml.RPr) *wml.RPr {
+	if rpr == nil {
+		return nil
+	}
+	c := *rpr
+	return &c
+}
+
+func removeRun(content *[]interface{}, r *wml.R) {
+	for i, elem := range *content {
+		if elem == interface{}(r) {
+			*content = append((*content)[:i], (*content)[i+1:]...)
+			return
+		}
+	}
+}
+
+func insertRunBefore(content *[]interface{}, before, r *wml.R) {
+	for i, elem := range *content {
+		if elem == interface{}(before) {
+			*content = append((*content)[:i], append([]interface{}{r}, (*content)[i:]...)...)
+			return
+		}
+	}
+	*content = append(*content, r)
+}
+
+// fieldEvaluator computes field results.
+type fieldEvaluator struct {
+	doc       *documentImpl
+	now       time.Time
+	mergeData map[string]string
+	core      *common.CoreProperties
+	custom    *common.CustomProperties
+	ext       *common.ExtendedProperties
+	seq       map[string]int
+
+	marks *fieldScan // bookmarks of the story being updated
+	stale bool       // whether results changed since marks was scanned
+	merge *mailMerge // the mail merge in progress, if any
+}
+
+// bookmarkText returns the current text of a bookmark in the story being
+// updated.
+func (e *fieldEvaluator) bookmarkText(name string) (string, bool) {
+	if e.stale {
+		e.marks = e.doc.scanStory(e.marks.content)
+		e.stale = false
+	}
+	return e.marks.bookmarkText(name)
+}
+
+// evaluate returns the new result of f, or false when f cannot be updated.
+func (e *fieldEvaluator) evaluate(f *Field) (string, bool) {
+	var (
+		text string
+		ok   bool
+	)
+	switch f.Type {
+	case "DATE":
+		text, ok = formatFieldDate(e.now, f, "M/d/yyyy"), true
+	case "TIME":
+		text, ok = formatFieldDate(e.now, f, "h:mm AM/PM"), true
+	case "CREATEDATE", "SAVEDATE", "PRINTDATE":
+		text, ok = e.propertyDate(f)
+	case "TITLE", "SUBJECT", "AUTHOR", "KEYWORDS", "COMMENTS", "LASTSAVEDBY", "REVNUM", "TEMPLATE":
+		text, ok = e.builtinProperty(f.Type)
+	case "FILENAME":
+		text, ok = e.fileName(f)
+	case "DOCPROPERTY":
+		if len(f.Arguments) == 0 {
+			return "", false
+		}
+		text, ok = e.docProperty(f.Arguments[0])
+		if !ok {
+			text, ok = fieldErrorProperty, true
+		}
+	case "REF":
+		if len(f.Arguments) == 0 {
+			return "", false
+		}
+		text, ok = e.ref(f, f.Arguments[0])
+	case "SEQ":
+		text, ok = e.sequence(f)
+	case "IF":
+		text, ok = evaluateIf(f.Arguments)
+	case "MERGEFIELD":
+		text, ok = e.mergeField(f)
+	case "NEXT", "NEXTIF", "SKIPIF", "MERGEREC", "MERGESEQ":
+		if e.merge == nil {
+			return "", false
+		}
+		text, ok = e.merge.control(f), true
+	default:
+		// A bare bookmark name is a reference to the bookmark.
+		if _, found := e.bookmarkText(f.Type); found && len(f.Arguments) == 0 {
+			text, ok = e.ref(f, f.Type)
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	return applyFormatSwitches(text, f), true
+}
+
+func (e *fieldEvaluator) propertyDate(f *Field) (string, bool) {
+	if e.core == nil {
+		return "", false
+	}
+	var date *common.DCDate
+	switch f.Type {
+	case "CREATEDATE":
+		date = e.core.Created
+	case "SAVEDATE":
+		date = e.core.Modified
+	case "PRINTDATE":
+		date = e.core.LastPrinted
+	}
+	if date == nil {
+		return "", false
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(date.Value))
+	if err != nil {
+		return "", false
+	}
+	return formatFieldDate(t.Local(), f, "M/d/yyyy h:mm:ss AM/PM"), true
+}
+
+func (e *fieldEvaluator) builtinProperty(name string) (string, bool) {
+	switch name {
+	case "TEMPLATE":
+		if e.ext == nil {
+			return "", false
+		}
+		return e.ext.Template, true
+	}
+	if e.core == nil {
+		return "", false
+	}
+	switch name {
+	case "TITLE":
+		return e.core.Title, true
+	case "SUBJECT":
+		return e.core.Subject, true
+	case "AUTHOR":
+		return e.core.Creator, true
+	case "KEYWORDS":
+		return e.core.Keywords, true
+	case "COMMENTS":
+		return e.core.Description, true
+	case "LASTSAVEDBY":
+		return e.core.LastModifiedBy, true
+	case "REVNUM":
+		return e.core.Revision, true
+	}
+	return "", false
+}
+
+func (e *fieldEvaluator) fileName(f *Field) (string, bool) {
+	path := e.doc.pkg.Path()
+	if path == "" {
+		return "", false
+	}
+	if _, full := f.Switch(`\p`); full {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs, true
+		}
+		return path, true
+	}
+	return filepath.Base(path), true
+}
+
+// docProperty returns a custom property, or a built-in property by the
+// name DOCPROPERTY uses for it.
+func (e *fieldEvaluator) docProperty(name string) (string, bool) {
+	if e.custom != nil {
+		if prop := e.custom.Get(name); prop != nil {
+			return prop.String(), true
+		}
+	}
+	builtin := map[string]string{
+		"title": "TITLE", "subject": "SUBJECT", "author": "AUTHOR", "keywords": "KEYWORDS",
+		"comments": "COMMENTS", "lastsavedby": "LASTSAVEDBY", "revisionnumber": "REVNUM",
+		"template": "TEMPLATE",
+	}
+	if field, ok := builtin[strings.ToLower(name)]; ok {
+		return e.builtinProperty(field)
+	}
+	if e.core != nil {
+		switch strings.ToLower(name) {
+		case "category":
+			return e.core.Category, true
+		case "contentstatus":
+			return e.core.ContentStatus, true
+		}
+	}
+	if e.ext != nil {
+		switch strings.ToLower(name) {
+		case "company":
+			return e.ext.Company, true
+		case "manager":
+			return e.ext.Manager, true
+		}
+	}
+	return "", false
+}
+
+func (e *fieldEvaluator) ref(f *Field, bookmark string) (string, bool) {
+	for _, unsupported := range []string{`\n`, `\r`, `\w`, `\p`} {
+		if _, ok := f.Switch(unsupported); ok {
+			return "", false // paragraph numbers and positions need layout
+		}
+	}
+	text, ok := e.bookmarkText(bookmark)
+	if !ok {
+		return fieldErrorBookmark, true
+	}
+	return text, true
+}
+
+func (e *fieldEvaluator) sequence(f *Field) (string, bool) {
+	if len(f.Arguments) == 0 {
+		return "", false
+	}
+	id := strings.ToLower(f.Arguments[0])
+	switch reset, hasReset := f.Switch(`\r`); {
+	case hasReset:
+		n, err := strconv.Atoi(reset)
+		if err != nil {
+			return "", false
+		}
+		e.seq[id] = n
+	case hasSwitch(f, `\c`):
+	default:
+		e.seq[id]++
+	}
+	if hasSwitch(f, `\h`) {
+		return "", true
+	}
+	return strconv.Itoa(e.seq[id]), true
+}
+
+func (e *fieldEvaluator) mergeField(f *Field) (string, bool) {
+	if len(f.Arguments) == 0 {
+		return "", false
+	}
+	name := f.Arguments[0]
+	data := e.mergeData
+	if e.merge != nil {
+		data = e.merge.record
+	}
+	value, found := data[name]
+	if !found {
+		for key, v := range data {
+			if strings.EqualFold(key, name) {
+				value, found = v, true
+				break
+			}
+		}
+	}
+	if !found {
+		if e.merge != nil {
+			return "", true
+		}
+		return "«" + name + "»", true
+	}
+	if value == "" {
+		return "", true
+	}
+	if picture, ok := f.Switch(`\@`); ok {
+		if t, ok := parseMergeDate(value); ok {
+			value = formatWordDate(t, picture)
+		}
+	}
+	if before, ok := f.Switch(`\b`); ok {
+		value = before + value
+	}
+	if after, ok := f.Switch(`\f`); ok {
+		value += after
+	}
+	return value, true
+}
+
+func hasSwitch(f *Field, name string) bool {
+	_, ok := f.Switch(name)
+	return ok
+}
+
+// parseMergeDate parses the date formats merge data commonly uses.
+func parseMergeDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "1/2/2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// evaluateIf evaluates IF expr1 operator expr2 trueText [falseText].
+func evaluateIf(args []string) (string, bool) {
+	if len(args) < 4 {
+		return "", false
+	}
+	whenFalse := ""
+	if len(args) > 4 {
+		whenFalse = args[4]
+	}
+	result, ok := compareFieldValues(args[0], args[1], args[2])
+	if !ok {
+		return "", false
+	}
+	if result {
+		return args[3], true
+	}
+	return whenFalse, true
+}
+
+// compareFieldValues evaluates the comparison of IF, NEXTIF and SKIPIF.
+// Values compare as numbers when both are numeric, and = and <> match
+// text against * and ? wildcards in right.
+func compareFieldValues(left, op, right string) (result, ok bool) {
+	cmp, numeric := 0, false
+	if l, err := strconv.ParseFloat(left, 64); err == nil {
+		if r, err := strconv.ParseFloat(right, 64); err == nil {
+			numeric = true
+			switch {
+			case l < r:
+				cmp = -1
+			case l > r:
+				cmp = 1
+			}
+		}
+	}
+	if !numeric {
+		cmp = strings.Compare(left, right)
+	}
+	switch op {
+	case "=":
+		result = cmp == 0 || (!numeric && matchWildcard(right, left))
+	case "<>":
+		result = cmp != 0 && (numeric || !matchWildcard(right, left))
+	case "<":
+		result = cmp < 0
+	case "<=":
+		result = cmp <= 0
+	case ">":
+		result = cmp > 0
+	case ">=":
+		result = cmp >= 0
+	default:
+		return false, false
+	}
+	return result, true
+}
+
+// matchWildcard matches s against a pattern with * and ? wildcards.
+func matchWildcard(pattern, s string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return false
+	}
+	p, t := []rune(pattern), []rune(s)
+	var match func(i, j int) bool
+	match = func(i, j int) bool {
+		for i < len(p) {
+			switch p[i] {
+			case '*':
+				for k := j; k <= len(t); k++ {
+					if match(i+1, k) {
+						return true
+					}
+				}
+				return false
+			case '?':
+				if j >= len(t) {
+					return false
+				}
+			default:
+				if j >= len(t) || p[i] != t[j] {
+					return false
+				}
+			}
+			i++
+			j++
+		}
+		return j == len(t)
+	}
+	return match(0, 0)
+}
+
+// formatFieldDate formats t with the field's \@ picture, or def.
+func formatFieldDate(t time.Time, f *Field, def string) string {
+	if picture, ok := f.Switch(`\@`); ok {
+		return formatWordDate(t, picture)
+	}
+	return formatWordDate(t, def)
+}
+
+// formatWordDate formats t with a Word date-time picture such as
+// "dddd, MMMM d, yyyy" or "HH:mm". Text in single quotes is literal.
+func formatWordDate(t time.Time, picture string) string {
+	var sb strings.Builder
+	p := []rune(picture)
+	for i := 0; i < len(p); {
+		c := p[i]
+		if c == '\'' {
+			end := i + 1
+			for end < len(p) && p[end] != '\'' {
+				end++
+			}
+			sb.WriteString(string(p[i+1 : end]))
+			i = end + 1
+			continue
+		}
+		if rest := string(p[i:]); len(p)-i >= 5 && strings.EqualFold(rest[:5], "am/pm") {
+			ampm := "AM"
+			if t.Hour() >= 12 {
+				ampm = "PM"
+			}
+			if rest[0] == 'a' {
+				ampm = strings.ToLower(ampm)
+			}
+			sb.WriteString(ampm)
+			i += 5
+			continue
+		}
+		n := 1
+		for i+n < len(p) && p[i+n] == c {
+			n++
+		}
+		switch c {
+		case 'd', 'D':
+			switch {
+			case n == 1:
+				sb.WriteString(strconv.Itoa(t.Day()))
+			case n == 2:
+				sb.WriteString(pad2(t.Day()))
+			case n == 3:
+				sb.WriteString(t.Weekday().String()[:3])
+			default:
+				sb.WriteString(t.Weekday().String())
+			}
+		case 'M':
+			switch {
+			case n == 1:
+				sb.WriteString(strconv.Itoa(int(t.Month())))
+			case n == 2:
+				sb.WriteString(pad2(int(t.Month())))
+			case n == 3:
+				sb.WriteString(t.Month().String()[:3])
+			default:
+				sb.WriteString(t.Month().String())
+			}
+		case 'y', 'Y':
+			if n <= 2 {
+				sb.WriteString(pad2(t.Year() % 100))
+			} else {
+				sb.WriteString(strconv.Itoa(t.Year()))
+			}
+		case 'h':
+			hour := t.Hour() % 12
+			if hour == 0 {
+				hour = 12
+			}
+			sb.WriteString(padN(hour, n))
+		case 'H':
+			sb.WriteString(padN(t.Hour(), n))
+		case 'm':
+			sb.WriteString(padN(t.Minute(), n))
+		case 's', 'S':
+			sb.WriteString(padN(t.Second(), n))
+		default:
+			sb.WriteString(strings.Repeat(string(c), n))
+		}
+		i += n
+	}
+	return sb.String()
+}
+
+func pad2(v int) string {
+	return padN(v, 2)
+}
+
+func padN(v, n int) string {
+	s := strconv.Itoa(v)
+	if n > 1 && len(s) < 2 {
+		s = "0" + s
+	}
+	return s
+}
+
+// applyFormatSwitches applies the \# numeric picture and \* format switches
+// to a field result.
+func applyFormatSwitches(text string, f *Field) string {
+	if picture, ok := f.Switch(`\#`); ok {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			text = formatNumberPicture(v, picture)
+		}
+	}
+	for _, sw := range f.Switches {
+		if sw.Name == `\*` {
+			text = applyGeneralFormat(text, sw.Value)
+		}
+	}
+	return text
+}
+
+// applyGeneralFormat applies one \* format such as Upper, roman or Ordinal.
+func applyGeneralFormat(text, format string) string {
+	switch strings.ToLower(format) {
+	case "upper":
+		return strings.ToUpper(text)
+	case "lower":
+		return strings.ToLower(text)
+	case "firstcap":
+		for i, r := range text {
+			if unicode.IsLetter(r) {
+				return text[:i] + string(unicode.ToUpper(r)) + text[i+len(string(r)):]
+			}
+		}
+		return text
+	case "caps":
+		runes := []rune(text)
+		for i := range runes {
+			if i == 0 || unicode.IsSpace(runes[i-1]) {
+				runes[i] = unicode.ToUpper(runes[i])
+			}
+		}
+		return string(runes)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return text
+	}
+	upper := format == strings.ToUpper(format)
+	switch strings.ToLower(format) {
+	case "arabic":
+		return strconv.Itoa(n)
+	case "roman":
+		if upper {
+			return romanNumeral(n)
+		}
+		return strings.ToLower(romanNumeral(n))
+	case "alphabetic":
+		if n <= 0 {
+			return text
+		}
+		letter := string(rune('a' + (n-1)%26))
+		if upper {
+			letter = strings.ToUpper(letter)
+		}
+		return strings.Repeat(letter, (n-1)/26+1)
+	case "ordinal":
+		return ordinal(n)
+	}
+	return text
+}
+
+// romanNumeral returns n in upper-case roman numerals.
+func romanNumeral(n int) string {
+	if n <= 0 || n >= 4000 {
+		return strconv.Itoa(n)
+	}
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	var sb strings.Builder
+	for i, v := range values {
+		for n >= v {
+			sb.WriteString(symbols[i])
+			n -= v
+		}
+	}
+	return sb.String()
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 100 {
+	case 11, 12, 13:
+	default:
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// formatNumberPicture formats v with a Word numeric picture such as
+// "#,##0.00" or "$#,##0". Only the first (positive) section is used.
+func formatNumberPicture(v float64, picture string) string {
+	if i := strings.IndexByte(picture, ';'); i >= 0 {
+		picture = picture[:i]
+	}
+	first := strings.IndexAny(picture, "0#")
+	if first < 0 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	last := strings.LastIndexAny(picture, "0#")
+	prefix := strings.ReplaceAll(picture[:first], "'", "")
+	suffix := strings.ReplaceAll(picture[last+1:], "'", "")
+	core := picture[first : last+1]
+
+	decimals, minInt := 0, 0
+	intPart := core
+	if dot := strings.IndexByte(core, '.'); dot >= 0 {
+		intPart = core[:dot]
+		decimals = strings.Count(core[dot+1:], "0") + strings.Count(core[dot+1:], "#")
+	}
+	minInt = strings.Count(intPart, "0")
+	grouped := strings.Contains(intPart, ",")
+
+	if strings.Contains(suffix, "%") {
+		v *= 100
+	}
+	negative := v < 0
+	s := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	digits, frac := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		digits, frac = s[:dot], s[dot:]
+	}
+	digits = strings.TrimLeft(digits, "0")
+	for len(digits) < minInt {
+		digits = "0" + digits
+	}
+	if grouped {
+		var sb strings.Builder
+		for i, r := range digits {
+			if i > 0 && (len(digits)-i)%3 == 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteRune(r)
+		}
+		digits = sb.String()
+	}
+	result := prefix + digits + frac + suffix
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
@@ -0,0 +1,587 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// StoryType identifies the part of a document that text belongs to.
+type StoryType string
+
+const (
+	// StoryBody identifies the main document body.
+	StoryBody StoryType = "body"
+	// StoryHeader identifies a header.
+	StoryHeader StoryType = "header"
+	// StoryFooter identifies a footer.
+	StoryFooter StoryType = "footer"
+	// StoryFootnote identifies a footnote.
+	StoryFootnote StoryType = "footnote"
+	// StoryEndnote identifies an endnote.
+	StoryEndnote StoryType = "endnote"
+)
+
+// textBoxPattern matches the content of a text box in the XML of a drawing.
+var textBoxPattern = regexp.MustCompile(`(?s)<(?:\w+:)?txbxContent(?:\s[^>]*[^/>])?>(.*?)</(?:\w+:)?txbxContent>`)
+
+// textBoxStart opens text box content for decoding, declaring the prefixes
+// the XML of a drawing may use.
+const textBoxStart = `<w:txbxContent xmlns:w="` + wml.NS + `" xmlns:r="` + wml.NSR + `"` +
+	` xmlns:w14="` + wml.NSW14 + `"` +
+	` xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"` +
+	` xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"` +
+	` xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"` +
+	` xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"` +
+	` xmlns:wps="http://schemas.microsoft.com/office/word/2010/wordprocessingShape"` +
+	` xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office">`
+
+// Match is an occurrence of a pattern found by FindAll.
+type Match struct {
+	// Text is the matched text and Groups the text of its capture groups;
+	// groups that did not take part in the match are empty.
+	Text   string
+	Groups []string
+	// Story is the part of the document the match is in.
+	Story StoryType
+	// Paragraph is the paragraph containing the match. For matches in text
+	// boxes it is a copy, and changes to it are not saved.
+	Paragraph Paragraph
+	// Offset is the byte offset of the match in the paragraph text, not
+	// counting deleted text.
+	Offset int
+	// Range is the range of the matched text. Replacements made after
+	// FindAll returns can move the text it refers to.
+	Range *Range
+}
+
+// ReplaceOptions configures ReplaceAllWithOptions.
+type ReplaceOptions struct {
+	// Track records each replacement as a tracked deletion and insertion.
+	// Replacements are always tracked while track changes is enabled.
+	Track bool
+	// Author is the author of tracked replacements. Empty means the track
+	// changes author.
+	Author string
+}
+
+// textPiece is a run element that makes up part of the text of a paragraph.
+type textPiece struct {
+	start, end int            // byte offsets in the paragraph text
+	run        *wml.R         // the run holding elem
+	content    *[]interface{} // the content holding run
+	owner      interface{}    // the element content belongs to
+	elem       interface{}    // *wml.T, *wml.Tab, *wml.Br or *wml.Sym
+}
+
+// paragraphPieces returns the pieces of the text of p, leaving out deleted
+// text and field instructions, and the text itself.
+func paragraphPieces(p *wml.P) ([]*textPiece, string) {
+	var (
+		pieces []*textPiece
+		sb     strings.Builder
+	)
+	walkParagraphRuns(p, func(r *wml.R, content *[]interface{}, owner interface{}) {
+		for _, elem := range r.Content {
+			text := pieceText(elem)
+			if text == "" {
+				continue
+			}
+			pieces = append(pieces, &textPiece{
+				start: sb.Len(), end: sb.Len() + len(text),
+				run: r, content: content, owner: owner, elem: elem,
+			})
+			sb.WriteString(text)
+		}
+	})
+	return pieces, sb.String()
+}
+
+// pieceText returns the text a run element contributes to its paragraph.
+func pieceText(elem interface{}) string {
+	switch e := elem.(type) {
+	case *wml.T:
+		return e.Text
+	case *wml.Tab:
+		return "\t"
+	case *wml.Br:
+		return "\n"
+	case *wml.Sym:
+		return string(symToRune(e.Char))
+	}
+	return ""
+}
+
+// walkParagraphRuns calls fn for the runs of p in order, including the runs
+// of insertions, hyperlinks, simple fields and content controls but not of
+// deletions, with the content holding each run and the element it belongs
+// to.
+func walkParagraphRuns(p *wml.P, fn func(r *wml.R, content *[]interface{}, owner interface{})) {
+	var walk func(content *[]interface{}, owner interface{})
+	walk = func(content *[]interface{}, owner interface{}) {
+		for _, elem := range *content {
+			switch v := elem.(type) {
+			case *wml.R:
+				fn(v, content, owner)
+			case *wml.Ins:
+				walk(&v.Content, v)
+			case *wml.Hyperlink:
+				walk(&v.Content, v)
+			case *wml.FldSimple:
+				walk(&v.Content, v)
+			case *wml.Sdt:
+				if v.SdtContent != nil {
+					walk(&v.SdtContent.Content, v)
+				}
+			}
+		}
+	}
+	walk(&p.Content, p)
+}
+
+// storyRef identifies a story and the block content it holds.
+type storyRef struct {
+	typ    StoryType
+	blocks *[]interface{}
+}
+
+// paragraphVisitor is called for each paragraph of a story, with the index
+// of the paragraph in the content holding it. It reports whether it changed
+// the paragraph.
+type paragraphVisitor func(story storyRef, p *wml.P, index int) bool
+
+// walkParagraphs calls visit for the paragraphs of the body, headers,
+// footers, footnotes and endnotes, including paragraphs in tables, content
+// controls and text boxes.
+func (d *documentImpl) walkParagraphs(visit paragraphVisitor) error {
+	if d.document == nil || d.document.Body == nil {
+		return utils.ErrDocumentClosed
+	}
+	stories := []storyRef{{StoryBody, &d.document.Body.Content}}
+	headerIDs := make([]string, 0, len(d.headers))
+	for id := range d.headers {
+		headerIDs = append(headerIDs, id)
+	}
+	sort.Strings(headerIDs)
+	for _, id := range headerIDs {
+		stories = append(stories, storyRef{StoryHeader, &d.headers[id].header.Content})
+	}
+	footerIDs := make([]string, 0, len(d.footers))
+	for id := range d.footers {
+		footerIDs = append(footerIDs, id)
+	}
+	sort.Strings(footerIDs)
+	for _, id := range footerIDs {
+		stories = append(stories, storyRef{StoryFooter, &d.footers[id].footer.Content})
+	}
+	if d.footnotes != nil {
+		for _, note := range d.footnotes.Footnote {
+			if isContentNote(note) {
+				stories = append(stories, storyRef{StoryFootnote, &note.Content})
+			}
+		}
+	}
+	if d.endnotes != nil {
+		for _, note := range d.endnotes.Endnote {
+			if isContentNote(note) {
+				stories = append(stories, storyRef{StoryEndnote, &note.Content})
+			}
+		}
+	}
+	for _, story := range stories {
+		if _, err := d.walkBlocks(*story.blocks, story, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isContentNote reports whether note is a footnote or endnote rather than a
+// separator.
+func isContentNote(note *wml.Note) bool {
+	return note.Type == "" || note.Type == wml.NoteTypeNormal
+}
+
+func (d *documentImpl) walkBlocks(content []interface{}, story storyRef, visit paragraphVisitor) (bool, error) {
+	changed := false
+	for i, elem := range content {
+		switch v := elem.(type) {
+		case *wml.P:
+			if visit(story, v, i) {
+				changed = true
+			}
+			boxChanged, err := d.walkTextBoxes(v.Content, story, visit)
+			if err != nil {
+				return changed, err
+			}
+			changed = changed || boxChanged
+		case *wml.Tbl:
+			for _, tr := range v.Tr {
+				for _, tc := range tr.Tc {
+					cellChanged, err := d.walkBlocks(tc.Content, story, visit)
+					if err != nil {
+						return changed, err
+					}
+					changed = changed || cellChanged
+				}
+			}
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				sdtChanged, err := d.walkBlocks(v.SdtContent.Content, story, visit)
+				if err != nil {
+					return changed, err
+				}
+				changed = changed || sdtChanged
+			}
+		}
+	}
+	return changed, nil
+}
+
+// walkTextBoxes visits the paragraphs of the text boxes in the drawings of
+// inline content.
+func (d *documentImpl) walkTextBoxes(content []interface{}, story storyRef, visit paragraphVisitor) (bool, error) {
+	changed := false
+	for _, elem := range content {
+		var inner []interface{}
+		switch v := elem.(type) {
+		case *wml.R:
+			for _, runElem := range v.Content {
+				if drawing, ok := runElem.(*wml.Drawing); ok {
+					drawingChanged, err := d.walkTextBox(drawing, story, visit)
+					if err != nil {
+						return changed, err
+					}
+					changed = changed || drawingChanged
+				}
+			}
+		case *wml.Ins:
+			inner = v.Content
+		case *wml.Hyperlink:
+			inner = v.Content
+		case *wml.FldSimple:
+			inner = v.Content
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				inner = v.SdtContent.Content
+			}
+		}
+		if len(inner) > 0 {
+			innerChanged, err := d.walkTextBoxes(inner, story, visit)
+			if err != nil {
+				return changed, err
+			}
+			changed = changed || innerChanged
+		}
+	}
+	return changed, nil
+}
+
+// walkTextBox visits the paragraphs of the text boxes of a drawing, writing
+// back the text boxes whose paragraphs changed. Text boxes that cannot be
+// decoded are left alone.
+func (d *documentImpl) walkTextBox(drawing *wml.Drawing, story storyRef, visit paragraphVisitor) (bool, error) {
+	locs := textBoxPattern.FindAllStringSubmatchIndex(drawing.Inner, -1)
+	if len(locs) == 0 {
+		return false, nil
+	}
+	var out strings.Builder
+	last, changed := 0, false
+	for _, loc := range locs {
+		box := &wml.TxbxContent{}
+		data := textBoxStart + drawing.Inner[loc[2]:loc[3]] + "</w:txbxContent>"
+		if err := d.pkg.DecodeXML([]byte(data), box); err != nil {
+			continue
+		}
+		boxChanged, err := d.walkBlocks(box.Content, storyRef{story.typ, &box.Content}, visit)
+		if err != nil {
+			return changed, err
+		}
+		if !boxChanged {
+			continue
+		}
+		var buf bytes.Buffer
+		enc := xml.NewEncoder(&buf)
+		for _, elem := range box.Content {
+			if err := enc.Encode(elem); err != nil {
+				return changed, err
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			return changed, err
+		}
+		out.WriteString(drawing.Inner[last:loc[2]])
+		out.Write(buf.Bytes())
+		last, changed = loc[3], true
+	}
+	if changed {
+		out.WriteString(drawing.Inner[last:])
+		drawing.Inner = out.String()
+	}
+	return changed, nil
+}
+
+// compilePattern compiles a FindAll or ReplaceAll pattern.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, utils.NewValidationError("pattern", "cannot be empty", pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, utils.NewValidationError("pattern", err.Error(), pattern)
+	}
+	return re, nil
+}
+
+// FindAll returns the matches of the regular expression pattern in the
+// text of the body, headers, footers, footnotes, endnotes, tables, text
+// boxes and content controls, in document order. Matches are found across
+// run boundaries but not across paragraphs; deleted text and field
+// instructions are not searched, and empty matches are ignored.
+func (d *documentImpl) FindAll(pattern string) ([]*Match, error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Match
+	err = d.walkParagraphs(func(story storyRef, p *wml.P, index int) bool {
+		_, text := paragraphPieces(p)
+		for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			m := &Match{
+				Text:      text[loc[0]:loc[1]],
+				Story:     story.typ,
+				Paragraph: &paragraphImpl{doc: d, p: p, index: index},
+				Offset:    loc[0],
+				Range: &Range{
+					doc:   d,
+					story: story,
+					start: rangePoint{p, loc[0]},
+					end:   rangePoint{p, loc[1]},
+				},
+			}
+			for g := 2; g < len(loc); g += 2 {
+				group := ""
+				if loc[g] >= 0 {
+					group = text[loc[g]:loc[g+1]]
+				}
+				m.Groups = append(m.Groups, group)
+			}
+			matches = append(matches, m)
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// ReplaceAll replaces the matches of a regular expression; see
+// ReplaceAllWithOptions.
+func (d *documentImpl) ReplaceAll(pattern, replacement string) (int, error) {
+	return d.ReplaceAllWithOptions(pattern, replacement, nil)
+}
+
+// ReplaceAllWithOptions replaces the matches FindAll finds with
+// replacement, in which $1 or ${name} stands for the text of a capture
+// group as in regexp.Regexp.Expand. Each replacement takes the formatting
+// of the run where its match begins, and other runs keep their formatting.
+// It returns the number of replacements. opts may be nil.
+func (d *documentImpl) ReplaceAllWithOptions(pattern, replacement string, opts *ReplaceOptions) (int, error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
+	track, author := d.trackChanges, d.trackAuthor
+	if opts != nil {
+		track = track || opts.Track
+		if opts.Author != "" {
+			author = opts.Author
+		}
+	}
+	count := 0
+	err = d.walkParagraphs(func(story storyRef, p *wml.P, index int) bool {
+		_, text := paragraphPieces(p)
+		locs := re.FindAllStringSubmatchIndex(text, -1)
+		replaced := false
+		// Later matches first, so that the offsets of earlier ones hold.
+		for i := len(locs) - 1; i >= 0; i-- {
+			loc := locs[i]
+			if loc[0] == loc[1] {
+				continue
+			}
+			repl := string(re.ExpandString(nil, replacement, text, loc))
+			if track {
+				d.replaceTracked(p, loc[0], loc[1], repl, author)
+			} else {
+				replaceText(p, loc[0], loc[1], repl)
+			}
+			replaced = true
+			count++
+		}
+		return replaced
+	})
+	return count, err
+}
+
+// overlapping returns the pieces holding text between start and end.
+func overlapping(pieces []*textPiece, start, end int) []*textPiece {
+	var result []*textPiece
+	for _, pc := range pieces {
+		if pc.end > start && pc.start < end {
+			result = append(result, pc)
+		}
+	}
+	return result
+}
+
+// replaceText replaces the text of p between byte offsets start and end,
+// putting the replacement in the run where the replaced text begins.
+func replaceText(p *wml.P, start, end int, repl string) {
+	pieces, _ := paragraphPieces(p)
+	for i, pc := range overlapping(pieces, start, end) {
+		lo, hi := maxInt(start, pc.start)-pc.start, minInt(end, pc.end)-pc.start
+		var text string
+		if t, ok := pc.elem.(*wml.T); ok {
+			text = t.Text[:lo] + t.Text[hi:]
+			if i == 0 {
+				text = t.Text[:lo] + repl + t.Text[hi:]
+			}
+		} else if i == 0 {
+			text = repl
+		}
+		pos := indexOfElement(pc.run.Content, pc.elem)
+		content := append([]interface{}{}, pc.run.Content[:pos]...)
+		content = append(content, runTextContent(text)...)
+		pc.run.Content = append(content, pc.run.Content[pos+1:]...)
+		if len(pc.run.Content) == 0 {
+			removeRun(pc.content, pc.run)
+		}
+	}
+}
+
+// replaceTracked replaces the text of p between byte offsets start and end
+// with a tracked deletion of the runs holding it followed by a tracked
+// insertion of repl.
+func (d *documentImpl) replaceTracked(p *wml.P, start, end int, repl, author string) {
+	// Split the runs at the ends of the match so that whole runs are deleted.
+	pieces, _ := paragraphPieces(p)
+	if found := overlapping(pieces, start, end); len(found) > 0 {
+		last := found[len(found)-1]
+		splitRun(last, end-last.start)
+	}
+	pieces, _ = paragraphPieces(p)
+	if found := overlapping(pieces, start, end); len(found) > 0 {
+		splitRun(found[0], start-found[0].start)
+	}
+	pieces, _ = paragraphPieces(p)
+	found := overlapping(pieces, start, end)
+	if len(found) == 0 {
+		return
+	}
+	date := time.Now().Format(time.RFC3339)
+	var (
+		first    = found[0]
+		rpr      = copyRPr(first.run.RPr)
+		del      *wml.Del
+		firstDel *wml.Del
+		seen     = make(map[*wml.R]bool)
+	)
+	for _, pc := range found {
+		r := pc.run
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		for i, elem := range r.Content {
+			if t, ok := elem.(*wml.T); ok {
+				r.Content[i] = &wml.DelText{Space: t.Space, Text: t.Text}
+			}
+		}
+		pos := indexOfElement(*pc.content, r)
+		if del != nil && pos > 0 && (*pc.content)[pos-1] == interface{}(del) {
+			del.Content = append(del.Content, r)
+			*pc.content = append((*pc.content)[:pos], (*pc.content)[pos+1:]...)
+			continue
+		}
+		del = &wml.Del{ID: d.nextRevID(), Author: author, Date: date, Content: []interface{}{r}}
+		(*pc.content)[pos] = del
+		if firstDel == nil {
+			firstDel = del
+		}
+	}
+	if repl == "" {
+		return
+	}
+	run := &wml.R{RPr: rpr, Content: runTextContent(repl)}
+	var inserted interface{} = &wml.Ins{ID: d.nextRevID(), Author: author, Date: date, Content: []interface{}{run}}
+	if _, ok := first.owner.(*wml.Ins); ok {
+		inserted = run // already part of an insertion
+	}
+	pos := indexOfElement(*first.content, firstDel)
+	*first.content = append((*first.content)[:pos+1], append([]interface{}{inserted}, (*first.content)[pos+1:]...)...)
+}
+
+// splitRun splits the run of pc at byte offset off of the piece, moving the
+// rest of the run into a new run with the same properties.
+func splitRun(pc *textPiece, off int) {
+	r := pc.run
+	pos := indexOfElement(r.Content, pc.elem)
+	var head, tail []interface{}
+	switch {
+	case off <= 0:
+		head, tail = r.Content[:pos], r.Content[pos:]
+	case off >= pc.end-pc.start:
+		head, tail = r.Content[:pos+1], r.Content[pos+1:]
+	default:
+		t := pc.elem.(*wml.T)
+		head = append(append([]interface{}{}, r.Content[:pos]...), wml.NewT(t.Text[:off]))
+		tail = append([]interface{}{wml.NewT(t.Text[off:])}, r.Content[pos+1:]...)
+	}
+	if len(head) == 0 || len(tail) == 0 {
+		return
+	}
+	r.Content = append([]interface{}{}, head...)
+	split := &wml.R{RPr: copyRPr(r.RPr), Content: append([]interface{}{}, tail...)}
+	pos = indexOfElement(*pc.content, r)
+	*pc.content = append((*pc.content)[:pos+1], append([]interface{}{split}, (*pc.content)[pos+1:]...)...)
+}
+
+// runTextContent returns run content for text, with tabs and breaks for
+// tab characters and newlines.
+func runTextContent(text string) []interface{} {
+	var content []interface{}
+	for len(text) > 0 {
+		i := strings.IndexAny(text, "\t\n")
+		if i < 0 {
+			content = append(content, wml.NewT(text))
+			break
+		}
+		if i > 0 {
+			content = append(content, wml.NewT(text[:i]))
+		}
+		if text[i] == '\t' {
+			content = append(content, &wml.Tab{})
+		} else {
+			content = append(content, &wml.Br{})
+		}
+		text = text[i+1:]
+	}
+	return content
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
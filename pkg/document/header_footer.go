@@ -2,6 +2,7 @@
 package document
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
@@ -355,11 +356,12 @@ func (d *documentImpl) saveFooters() error {
 	return nil
 }
 
-func (d *documentImpl) parseHeaders() error {
+func (d *documentImpl) parseHeaders(ctx context.Context) error {
 	if d.document == nil || d.document.Body == nil || d.document.Body.SectPr == nil {
 		return nil
 	}
 	rels := d.pkg.GetRelationships(packaging.WordDocumentPath)
+	dropped := make(map[string]bool)
 	for _, ref := range d.document.Body.SectPr.HeaderRefs {
 		rel := rels.ByID(ref.ID)
 		if rel == nil {
@@ -371,12 +373,17 @@ func (d *documentImpl) parseHeaders() error {
 			continue
 		}
 		content, err := part.Content()
-		if err != nil {
-			return err
-		}
 		header := &wml.Header{}
-		if err := utils.UnmarshalXML(content, header); err != nil {
-			return err
+		if err == nil {
+			err = d.pkg.DecodeXMLContext(ctx, content, header)
+		}
+		if err != nil {
+			// In recover mode drop the part and its reference instead.
+			if err := d.pkg.RecoverPart(path, err, packaging.SeverityWarning, "dropped header part"); err != nil {
+				return err
+			}
+			dropped[ref.ID] = true
+			continue
 		}
 		d.headers[ref.ID] = &headerImpl{
 			doc:    d,
@@ -385,14 +392,24 @@ func (d *documentImpl) parseHeaders() error {
 			hfType: HeaderFooterType(ref.Type),
 		}
 	}
+	if len(dropped) > 0 {
+		kept := d.document.Body.SectPr.HeaderRefs[:0]
+		for _, ref := range d.document.Body.SectPr.HeaderRefs {
+			if !dropped[ref.ID] {
+				kept = append(kept, ref)
+			}
+		}
+		d.document.Body.SectPr.HeaderRefs = kept
+	}
 	return nil
 }
 
-func (d *documentImpl) parseFooters() error {
+func (d *documentImpl) parseFooters(ctx context.Context) error {
 	if d.document == nil || d.document.Body == nil || d.document.Body.SectPr == nil {
 		return nil
 	}
 	rels := d.pkg.GetRelationships(packaging.WordDocumentPath)
+	dropped := make(map[string]bool)
 	for _, ref := range d.document.Body.SectPr.FooterRefs {
 		rel := rels.ByID(ref.ID)
 		if rel == nil {
@@ -404,12 +421,17 @@ func (d *documentImpl) parseFooters() error {
 			continue
 		}
 		content, err := part.Content()
-		if err != nil {
-			return err
-		}
 		footer := &wml.Footer{}
-		if err := utils.UnmarshalXML(content, footer); err != nil {
-			return err
+		if err == nil {
+			err = d.pkg.DecodeXMLContext(ctx, content, footer)
+		}
+		if err != nil {
+			// In recover mode drop the part and its reference instead.
+			if err := d.pkg.RecoverPart(path, err, packaging.SeverityWarning, "dropped footer part"); err != nil {
+				return err
+			}
+			dropped[ref.ID] = true
+			continue
 		}
 		d.footers[ref.ID] = &footerImpl{
 			doc:    d,
@@ -418,5 +440,14 @@ func (d *documentImpl) parseFooters() error {
 			hfType: HeaderFooterType(ref.Type),
 		}
 	}
+	if len(dropped) > 0 {
+		kept := d.document.Body.SectPr.FooterRefs[:0]
+		for _, ref := range d.document.Body.SectPr.FooterRefs {
+			if !dropped[ref.ID] {
+				kept = append(kept, ref)
+			}
+		}
+		d.document.Body.SectPr.FooterRefs = kept
+	}
 	return nil
 }
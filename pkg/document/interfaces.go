@@ -1,15 +1,53 @@
 package document
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
+	"github.com/rcarmo/go-ooxml/pkg/packaging"
 )
 
 // DocumentProperties maps to core properties.
 type DocumentProperties = common.CoreProperties
 
+// SaveOptions configures how the document package is written.
+type SaveOptions = packaging.SaveOptions
+
+// OpenOptions configures how the document package is opened.
+type OpenOptions = packaging.OpenOptions
+
+// Variant identifies the regular, template and macro-enabled flavours of the document format.
+type Variant = packaging.Variant
+
+// Document variants.
+const (
+	VariantDefault              = packaging.VariantDefault
+	VariantTemplate             = packaging.VariantTemplate
+	VariantMacroEnabled         = packaging.VariantMacroEnabled
+	VariantMacroEnabledTemplate = packaging.VariantMacroEnabledTemplate
+)
+
+// EmbeddedObject describes an object embedded in the document, such as a chart's
+// data workbook.
+type EmbeddedObject = packaging.EmbeddedObject
+
+// Diagnostic describes a part that could not be loaded when opening the
+// document with OpenOptions.Recover, and what was done about it.
+type Diagnostic = packaging.Diagnostic
+
+// Severity ranks a Diagnostic.
+type Severity = packaging.Severity
+
+// Diagnostic severities.
+const (
+	SeverityInfo    = packaging.SeverityInfo
+	SeverityWarning = packaging.SeverityWarning
+	SeverityError   = packaging.SeverityError
+)
+
 // ParagraphProperties represents paragraph properties.
 type ParagraphProperties = wml.PPr
 
@@ -27,9 +65,6 @@ type BodyElement interface {
 	Index() int
 }
 
-// RevisionLocation is a placeholder for revision location.
-type RevisionLocation struct{}
-
 // Revision represents a tracked change.
 type Revision interface {
 	ID() string
@@ -37,7 +72,7 @@ type Revision interface {
 	Author() string
 	Date() time.Time
 	Text() string
-	Location() RevisionLocation
+	Location() *Range
 	Accept() error
 	Reject() error
 }
@@ -155,6 +190,21 @@ type Footer interface {
 type Document interface {
 	Save() error
 	SaveAs(path string) error
+	SaveWithOptions(opts *SaveOptions) error
+	SaveAsWithOptions(path string, opts *SaveOptions) error
+	WriteTo(w io.Writer) (int64, error)
+	SaveContext(ctx context.Context, opts *SaveOptions) error
+	SaveAsContext(ctx context.Context, path string, opts *SaveOptions) error
+	WriteToContext(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error)
+	SaveAsTemplate(path string) error
+	Variant() Variant
+	SetVariant(v Variant) error
+	HasMacros() bool
+	IsStrict() bool
+	Diagnostics() []Diagnostic
+	EmbeddedObjects() []*EmbeddedObject
+	WriteDir(dir string, pretty bool) error
+	WriteFlatOPC(w io.Writer) error
 	Close() error
 	Body() Body
 	Paragraphs() []Paragraph
@@ -185,6 +235,16 @@ type Document interface {
 	DeleteComment(id string) error
 	CoreProperties() (*common.CoreProperties, error)
 	SetCoreProperties(props *common.CoreProperties) error
+	CustomProperties() (*common.CustomProperties, error)
+	SetCustomProperties(props *common.CustomProperties) error
+	CustomProperty(name string) (*common.CustomProperty, error)
+	SetCustomProperty(name string, value interface{}) error
+	DeleteCustomProperty(name string) error
+	ExtendedProperties() (*common.ExtendedProperties, error)
+	SetExtendedProperties(props *common.ExtendedProperties) error
+	UpdateExtendedProperties() error
+	Thumbnail() (contentType string, data []byte, err error)
+	SetThumbnail(contentType string, data []byte) error
 	Headers() []Header
 	AddHeader(hfType HeaderFooterType) Header
 	Footers() []Footer
@@ -202,6 +262,15 @@ type Document interface {
 	AddContentControl(tag, alias, text string) *ContentControl
 	AddBlockContentControl(tag, alias, text string) *ContentControl
 	ContentControlByTag(tag string) *ContentControl
+	Fields() []*Field
+	UpdateFields() error
+	UpdateFieldsWithOptions(opts *FieldUpdateOptions) error
+	MailMerge(source MergeSource, opts *MailMergeOptions) ([]Document, error)
+	MailMergeToDocument(source MergeSource, opts *MailMergeOptions) (Document, error)
+	FindAll(pattern string) ([]*Match, error)
+	ReplaceAll(pattern, replacement string) (int, error)
+	ReplaceAllWithOptions(pattern, replacement string, opts *ReplaceOptions) (int, error)
+	Range(start, end Position) (*Range, error)
 	BackgroundColor() string
 	SetBackgroundColor(hex string)
 }
@@ -221,6 +290,7 @@ type Body interface {
 	InsertParagraphAt(index int) Paragraph
 	InsertParagraphBefore(target BodyElement) Paragraph
 	InsertParagraphAfter(target BodyElement) Paragraph
+	InsertTableOfContents(opts *TOCOptions) (*Field, error)
 	ElementCount() int
 }
 
@@ -1,8 +1,10 @@
 package document
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
 	"github.com/rcarmo/go-ooxml/pkg/packaging"
@@ -49,10 +51,11 @@ func (d *documentImpl) initPackage() error {
 	return nil
 }
 
-// updatePackage updates the OPC package with current document state.
-func (d *documentImpl) updatePackage() error {
+// updatePackage updates the OPC package with current document state,
+// stopping while document.xml is written once ctx is done.
+func (d *documentImpl) updatePackage(ctx context.Context) error {
 	// Update document.xml
-	docData, err := utils.MarshalXMLWithHeader(d.document)
+	docData, err := utils.MarshalXMLContext(ctx, d.document)
 	if err != nil {
 		return err
 	}
@@ -78,7 +81,7 @@ func (d *documentImpl) updatePackage() error {
 		}
 	}
 
-	// Update settings.xml if exists
+	// Update settings.xml, adding it if the document had none
 	if d.settings != nil {
 		settingsData, err := utils.MarshalXMLWithHeader(d.settings)
 		if err != nil {
@@ -89,6 +92,11 @@ func (d *documentImpl) updatePackage() error {
 			if err := settingsPart.SetContent(settingsData); err != nil {
 				return err
 			}
+		} else if len(d.pkg.GetRelationshipsByType(packaging.WordDocumentPath, packaging.RelTypeSettings)) == 0 {
+			if _, err := d.pkg.AddPart(packaging.WordSettingsPath, packaging.ContentTypeSettings, settingsData); err != nil {
+				return err
+			}
+			d.pkg.AddRelationship(packaging.WordDocumentPath, "settings.xml", packaging.RelTypeSettings)
 		}
 	}
 
@@ -151,6 +159,18 @@ func (d *documentImpl) updatePackage() error {
 		}
 	}
 
+	// Update footnotes.xml and endnotes.xml if the document has them
+	if d.footnotes != nil {
+		if err := d.saveNotes(packaging.RelTypeFootnotes, d.footnotes); err != nil {
+			return err
+		}
+	}
+	if d.endnotes != nil {
+		if err := d.saveNotes(packaging.RelTypeEndnotes, d.endnotes); err != nil {
+			return err
+		}
+	}
+
 	if err := d.saveHeaders(); err != nil {
 		return err
 	}
@@ -161,8 +181,27 @@ func (d *documentImpl) updatePackage() error {
 	return nil
 }
 
+// parseOptional runs parse for the optional part that document.xml relates
+// to by relType. Errors are ignored; in recover mode the malformed part is
+// dropped instead, reset discards whatever was partially parsed, and a
+// diagnostic is recorded.
+func (d *documentImpl) parseOptional(ctx context.Context, relType string, parse func(context.Context) error, reset func()) {
+	err := parse(ctx)
+	if err == nil || !d.pkg.CanRecover(err) {
+		return
+	}
+	rel := d.pkg.GetRelationships(packaging.WordDocumentPath).FirstByType(relType)
+	if rel == nil {
+		return
+	}
+	partPath := packaging.ResolveRelationshipTarget(packaging.WordDocumentPath, rel.Target)
+	reset()
+	name := strings.TrimSuffix(path.Base(partPath), path.Ext(partPath))
+	_ = d.pkg.RecoverPart(partPath, err, packaging.SeverityWarning, "dropped "+name+" part")
+}
+
 // parseDocument parses the document.xml part.
-func (d *documentImpl) parseDocument() error {
+func (d *documentImpl) parseDocument(ctx context.Context) error {
 	// Find document part via relationship
 	rels := d.pkg.GetRelationshipsByType("", packaging.RelTypeOfficeDocument)
 	if len(rels) == 0 {
@@ -181,11 +220,11 @@ func (d *documentImpl) parseDocument() error {
 	}
 
 	d.document = &wml.Document{}
-	return xml.Unmarshal(content, d.document)
+	return d.pkg.DecodeXMLContext(ctx, content, d.document)
 }
 
 // parseStyles parses the styles.xml part.
-func (d *documentImpl) parseStyles() error {
+func (d *documentImpl) parseStyles(ctx context.Context) error {
 	rels := d.pkg.GetRelationshipsByType(packaging.WordDocumentPath, packaging.RelTypeStyles)
 	if len(rels) == 0 {
 		return nil // optional
@@ -203,11 +242,11 @@ func (d *documentImpl) parseStyles() error {
 	}
 
 	d.styles = &wml.Styles{}
-	return xml.Unmarshal(content, d.styles)
+	return d.pkg.DecodeXMLContext(ctx, content, d.styles)
 }
 
 // parseSettings parses the settings.xml part.
-func (d *documentImpl) parseSettings() error {
+func (d *documentImpl) parseSettings(ctx context.Context) error {
 	rels := d.pkg.GetRelationshipsByType(packaging.WordDocumentPath, packaging.RelTypeSettings)
 	if len(rels) == 0 {
 		return nil // optional
@@ -225,7 +264,7 @@ func (d *documentImpl) parseSettings() error {
 	}
 
 	d.settings = &wml.Settings{}
-	if err := xml.Unmarshal(content, d.settings); err != nil {
+	if err := d.pkg.DecodeXMLContext(ctx, content, d.settings); err != nil {
 		return err
 	}
 
@@ -238,7 +277,7 @@ func (d *documentImpl) parseSettings() error {
 }
 
 // parseComments parses the comments.xml part.
-func (d *documentImpl) parseComments() error {
+func (d *documentImpl) parseComments(ctx context.Context) error {
 	rels := d.pkg.GetRelationshipsByType(packaging.WordDocumentPath, packaging.RelTypeComments)
 	if len(rels) == 0 {
 		return nil // optional
@@ -256,7 +295,7 @@ func (d *documentImpl) parseComments() error {
 	}
 
 	d.comments = &wml.Comments{}
-	if err := xml.Unmarshal(content, d.comments); err != nil {
+	if err := d.pkg.DecodeXMLContext(ctx, content, d.comments); err != nil {
 		return err
 	}
 
@@ -275,7 +314,7 @@ func (d *documentImpl) parseComments() error {
 }
 
 // parseCommentsExtended parses the commentsExtended.xml part.
-func (d *documentImpl) parseCommentsExtended() error {
+func (d *documentImpl) parseCommentsExtended(ctx context.Context) error {
 	rels := d.pkg.GetRelationshipsByType(packaging.WordDocumentPath, packaging.RelTypeCommentsExtended)
 	if len(rels) == 0 {
 		return nil
@@ -293,7 +332,7 @@ func (d *documentImpl) parseCommentsExtended() error {
 	}
 
 	d.commentsExtended = &wml.CommentsEx{}
-	if err := xml.Unmarshal(content, d.commentsExtended); err != nil {
+	if err := d.pkg.DecodeXMLContext(ctx, content, d.commentsExtended); err != nil {
 		return err
 	}
 
@@ -307,7 +346,7 @@ func (d *documentImpl) parseCommentsExtended() error {
 }
 
 // parseNumbering parses the numbering.xml part.
-func (d *documentImpl) parseNumbering() error {
+func (d *documentImpl) parseNumbering(ctx context.Context) error {
 	rels := d.pkg.GetRelationshipsByType(packaging.WordDocumentPath, packaging.RelTypeNumbering)
 	if len(rels) == 0 {
 		return nil // optional
@@ -325,7 +364,7 @@ func (d *documentImpl) parseNumbering() error {
 	}
 
 	d.numbering = &wml.Numbering{}
-	if err := xml.Unmarshal(content, d.numbering); err != nil {
+	if err := d.pkg.DecodeXMLContext(ctx, content, d.numbering); err != nil {
 		return err
 	}
 
@@ -343,6 +382,59 @@ func (d *documentImpl) parseNumbering() error {
 	return nil
 }
 
+// parseFootnotes parses the footnotes.xml part.
+func (d *documentImpl) parseFootnotes(ctx context.Context) error {
+	content, err := d.notesContent(packaging.RelTypeFootnotes)
+	if err != nil || content == nil {
+		return err
+	}
+	d.footnotes = &wml.Footnotes{}
+	return d.pkg.DecodeXMLContext(ctx, content, d.footnotes)
+}
+
+// parseEndnotes parses the endnotes.xml part.
+func (d *documentImpl) parseEndnotes(ctx context.Context) error {
+	content, err := d.notesContent(packaging.RelTypeEndnotes)
+	if err != nil || content == nil {
+		return err
+	}
+	d.endnotes = &wml.Endnotes{}
+	return d.pkg.DecodeXMLContext(ctx, content, d.endnotes)
+}
+
+// notesContent returns the content of the footnotes or endnotes part, or
+// nil when the document has none.
+func (d *documentImpl) notesContent(relType string) ([]byte, error) {
+	rels := d.pkg.GetRelationshipsByType(packaging.WordDocumentPath, relType)
+	if len(rels) == 0 {
+		return nil, nil // optional
+	}
+	notesPath := packaging.ResolveRelationshipTarget(packaging.WordDocumentPath, rels[0].Target)
+	part, err := d.pkg.GetPart(notesPath)
+	if err != nil {
+		return nil, nil
+	}
+	return part.Content()
+}
+
+// saveNotes writes notes back to the footnotes or endnotes part they were
+// read from.
+func (d *documentImpl) saveNotes(relType string, notes interface{}) error {
+	rels := d.pkg.GetRelationshipsByType(packaging.WordDocumentPath, relType)
+	if len(rels) == 0 {
+		return nil
+	}
+	part, err := d.pkg.GetPart(packaging.ResolveRelationshipTarget(packaging.WordDocumentPath, rels[0].Target))
+	if err != nil {
+		return nil
+	}
+	data, err := utils.MarshalXMLWithHeader(notes)
+	if err != nil {
+		return err
+	}
+	return part.SetContent(data)
+}
+
 // parseBookmarks scans the document and initializes nextBookmarkID.
 func (d *documentImpl) parseBookmarks() {
 	maxID := 0
@@ -0,0 +1,458 @@
+// Package document provides mail merge functionality.
+package document
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/gif" // decoders for the sizes of INCLUDEPICTURE images
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
+	"github.com/rcarmo/go-ooxml/pkg/packaging"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// mergeFieldTypes are the fields a mail merge replaces with their results.
+var mergeFieldTypes = map[string]bool{
+	"MERGEFIELD": true, "IF": true, "NEXT": true, "NEXTIF": true, "SKIPIF": true,
+	"MERGEREC": true, "MERGESEQ": true, "INCLUDEPICTURE": true,
+}
+
+// drawingExtentPattern finds the size of an inline drawing.
+var drawingExtentPattern = regexp.MustCompile(`extent cx="(\d+)" cy="(\d+)"`)
+
+// MergeSource supplies the records of a mail merge.
+type MergeSource interface {
+	// Next returns the next record, which maps field names to values, or
+	// io.EOF after the last record.
+	Next() (map[string]string, error)
+}
+
+// MergeFunc adapts a function to a MergeSource.
+type MergeFunc func() (map[string]string, error)
+
+// Next calls f.
+func (f MergeFunc) Next() (map[string]string, error) {
+	return f()
+}
+
+// MergeRecords returns a MergeSource over records.
+func MergeRecords(records []map[string]string) MergeSource {
+	next := 0
+	return MergeFunc(func() (map[string]string, error) {
+		if next >= len(records) {
+			return nil, io.EOF
+		}
+		next++
+		return records[next-1], nil
+	})
+}
+
+// MailMergeOptions configures MailMerge and MailMergeToDocument.
+type MailMergeOptions struct {
+	// Now is the time DATE and TIME fields show. Zero means time.Now().
+	Now time.Time
+	// ImageDir is the directory relative INCLUDEPICTURE paths are resolved
+	// against. Empty means the working directory.
+	ImageDir string
+	// KeepFields keeps the merge fields, with their new results, instead
+	// of replacing them with their results.
+	KeepFields bool
+}
+
+// mailMerge is the state of a mail merge.
+type mailMerge struct {
+	source MergeSource
+	opts   MailMergeOptions
+	record map[string]string
+	number int  // of the current record, from 1
+	merged int  // records merged so far
+	done   bool // whether the source has no more records
+	skip   bool // whether SKIPIF dropped the current record
+	inBody bool // whether the story being merged is the body
+	err    error
+}
+
+// MailMerge merges each record of source into a copy of the document and
+// returns the copies. MERGEFIELD fields show the record's values, matched
+// to field names without regard to case and empty when missing. IF fields
+// choose between texts, INCLUDEPICTURE fields show the image the path
+// names, NEXT and NEXTIF move on to the next record within the same copy,
+// SKIPIF drops the record, and MERGEREC and MERGESEQ number the records.
+// The fields are then replaced by their results, which keep the formatting
+// of the field result or, without one, of the field code. Other fields are
+// updated as by UpdateFieldsWithOptions. opts may be nil.
+func (d *documentImpl) MailMerge(source MergeSource, opts *MailMergeOptions) ([]Document, error) {
+	template, m, err := d.startMailMerge(source, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []Document
+	fail := func(err error) ([]Document, error) {
+		for _, doc := range docs {
+			doc.Close()
+		}
+		return nil, err
+	}
+	for m.next() {
+		doc, err := openMergeCopy(template)
+		if err != nil {
+			return fail(err)
+		}
+		if err := m.mergeInto(doc, &doc.document.Body.Content, true); err != nil {
+			doc.Close()
+			return fail(err)
+		}
+		if m.skip {
+			doc.Close()
+			continue
+		}
+		m.merged++
+		docs = append(docs, doc)
+	}
+	if m.err != nil {
+		return fail(m.err)
+	}
+	return docs, nil
+}
+
+// MailMergeToDocument merges the records of source like MailMerge, but
+// into one document with a section break after each record. Headers and
+// footers are merged with the first record, and only the first record's
+// copy keeps the bookmarks of the document. opts may be nil.
+func (d *documentImpl) MailMergeToDocument(source MergeSource, opts *MailMergeOptions) (Document, error) {
+	template, m, err := d.startMailMerge(source, opts)
+	if err != nil {
+		return nil, err
+	}
+	out, err := openMergeCopy(template)
+	if err != nil {
+		return nil, err
+	}
+	docXML, err := utils.MarshalXMLWithHeader(out.document)
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+
+	var (
+		content []interface{}
+		first   map[string]string
+	)
+	for m.next() {
+		dup := &wml.Document{}
+		if err := out.pkg.DecodeXML(docXML, dup); err != nil {
+			out.Close()
+			return nil, err
+		}
+		if dup.Body == nil {
+			dup.Body = &wml.Body{}
+		}
+		record := m.record
+		if err := m.mergeInto(out, &dup.Body.Content, false); err != nil {
+			out.Close()
+			return nil, err
+		}
+		if m.skip {
+			continue
+		}
+		if m.merged == 0 {
+			first = record
+		} else {
+			content = appendSectionBreak(content, out.document.Body.SectPr)
+			dup.Body.Content = removeBookmarks(dup.Body.Content)
+		}
+		m.merged++
+		content = append(content, dup.Body.Content...)
+	}
+	if m.err != nil {
+		out.Close()
+		return nil, m.err
+	}
+	out.document.Body.Content = content
+
+	m.record = first
+	if err := m.mergeInto(out, nil, true); err != nil {
+		out.Close()
+		return nil, err
+	}
+	return out, nil
+}
+
+// startMailMerge returns the saved document to copy for each record and
+// the merge state.
+func (d *documentImpl) startMailMerge(source MergeSource, opts *MailMergeOptions) ([]byte, *mailMerge, error) {
+	if source == nil {
+		return nil, nil, utils.NewValidationError("source", "cannot be nil", nil)
+	}
+	if d == nil || d.pkg == nil || d.document == nil {
+		return nil, nil, utils.ErrDocumentClosed
+	}
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, nil, err
+	}
+	m := &mailMerge{source: source}
+	if opts != nil {
+		m.opts = *opts
+	}
+	return buf.Bytes(), m, nil
+}
+
+// openMergeCopy opens a copy of the mail merge template.
+func openMergeCopy(template []byte) (*documentImpl, error) {
+	pkg, err := packaging.OpenBytes(template)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := openFromPackage(context.Background(), pkg)
+	if err != nil {
+		return nil, err
+	}
+	if doc.document.Body == nil {
+		doc.document.Body = &wml.Body{}
+	}
+	return doc, nil
+}
+
+// next moves to the next record and reports whether there is one.
+func (m *mailMerge) next() bool {
+	if m.done {
+		return false
+	}
+	record, err := m.source.Next()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			m.err = err
+		}
+		m.done = true
+		m.record = nil
+		return false
+	}
+	m.record = record
+	m.number++
+	return true
+}
+
+// mergeInto merges the current record into body, which may be nil, and,
+// if headers is set, into the headers and footers of doc.
+func (m *mailMerge) mergeInto(doc *documentImpl, body *[]interface{}, headers bool) error {
+	e, err := doc.newFieldEvaluator(&FieldUpdateOptions{Now: m.opts.Now})
+	if err != nil {
+		return err
+	}
+	e.merge = m
+	m.skip = false
+
+	var stories []*[]interface{}
+	if body != nil {
+		stories = append(stories, body)
+	}
+	if headers {
+		stories = append(stories, doc.stories()[1:]...)
+	}
+	for _, content := range stories {
+		m.inBody = content == body
+		if err := e.updateStory(doc.scanStory(content)); err != nil {
+			return err
+		}
+		if m.skip {
+			return m.err
+		}
+		if !m.opts.KeepFields {
+			for _, f := range doc.scanStory(content).fields {
+				if mergeFieldTypes[f.Type] {
+					f.unlink()
+				}
+			}
+		}
+	}
+	return m.err
+}
+
+// control evaluates the merge control fields NEXT, NEXTIF, SKIPIF,
+// MERGEREC and MERGESEQ.
+func (m *mailMerge) control(f *Field) string {
+	switch f.Type {
+	case "NEXT":
+		m.next()
+	case "NEXTIF", "SKIPIF":
+		if len(f.Arguments) < 3 {
+			return ""
+		}
+		if result, _ := compareFieldValues(f.Arguments[0], f.Arguments[1], f.Arguments[2]); result {
+			if f.Type == "NEXTIF" {
+				m.next()
+			} else {
+				m.skip = true
+			}
+		}
+	case "MERGEREC":
+		return strconv.Itoa(m.number)
+	case "MERGESEQ":
+		return strconv.Itoa(m.merged + 1)
+	}
+	return ""
+}
+
+// includePicture replaces the result of an INCLUDEPICTURE field with the
+// image its path names, sized like the picture it replaces or at 96 DPI.
+// Fields outside the body keep their results.
+func (e *fieldEvaluator) includePicture(f *Field) error {
+	if !e.merge.inBody || len(f.Arguments) == 0 {
+		return nil
+	}
+	path := f.Arguments[0]
+	if path == "" {
+		f.setResult("")
+		e.stale = true
+		return nil
+	}
+	if !filepath.IsAbs(path) && e.merge.opts.ImageDir != "" {
+		path = filepath.Join(e.merge.opts.ImageDir, path)
+	}
+	width, height := drawingExtent(f.resultRuns())
+	if width == 0 || height == 0 {
+		var err error
+		if width, height, err = imageExtent(path); err != nil {
+			return err
+		}
+	}
+	picture, err := e.doc.newPictureRun(path, width, height)
+	if err != nil {
+		return err
+	}
+	f.setResult("")
+	f.resultRuns()[0].Content = picture.Content
+	e.stale = true
+	return nil
+}
+
+// drawingExtent returns the size of the first inline drawing in runs.
+func drawingExtent(runs []*wml.R) (width, height int64) {
+	for _, r := range runs {
+		for _, elem := range r.Content {
+			drawing, ok := elem.(*wml.Drawing)
+			if !ok {
+				continue
+			}
+			if m := drawingExtentPattern.FindStringSubmatch(drawing.Inner); m != nil {
+				width, _ = strconv.ParseInt(m[1], 10, 64)
+				height, _ = strconv.ParseInt(m[2], 10, 64)
+				return width, height
+			}
+		}
+	}
+	return 0, 0
+}
+
+// imageExtent returns the size of an image at 96 DPI, or one inch square
+// for formats that cannot be decoded.
+func imageExtent(path string) (width, height int64, err error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil || config.Width == 0 || config.Height == 0 {
+		return utils.EMUsPerInch, utils.EMUsPerInch, nil
+	}
+	return utils.PixelsToEMU(config.Width), utils.PixelsToEMU(config.Height), nil
+}
+
+// unlink replaces the field with its result runs.
+func (f *Field) unlink() {
+	if f.simple != nil {
+		if f.container == nil {
+			return
+		}
+		for i, elem := range *f.container {
+			if elem == interface{}(f.simple) {
+				rest := append(append([]interface{}(nil), f.simple.Content...), (*f.container)[i+1:]...)
+				*f.container = append((*f.container)[:i], rest...)
+				return
+			}
+		}
+		return
+	}
+	last := f.sep
+	if last == nil {
+		last = f.end
+	}
+	if last.content != f.begin.content {
+		return // the field code spans paragraphs
+	}
+	from, to := indexOfElement(*f.begin.content, f.begin.r), indexOfElement(*f.begin.content, last.r)
+	if from < 0 || to < from {
+		return
+	}
+	*f.begin.content = append((*f.begin.content)[:from], (*f.begin.content)[to+1:]...)
+	removeRun(f.end.content, f.end.r)
+}
+
+func indexOfElement(content []interface{}, elem interface{}) int {
+	for i, e := range content {
+		if e == elem {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendSectionBreak ends the section at the end of content with the
+// properties of sect.
+func appendSectionBreak(content []interface{}, sect *wml.SectPr) []interface{} {
+	props := &wml.SectPr{}
+	if sect != nil {
+		copied := *sect
+		props = &copied
+	}
+	if n := len(content); n > 0 {
+		if p, ok := content[n-1].(*wml.P); ok {
+			if p.PPr == nil {
+				p.PPr = &wml.PPr{}
+			}
+			p.PPr.SectPr = props
+			return content
+		}
+	}
+	return append(content, &wml.P{PPr: &wml.PPr{SectPr: props}})
+}
+
+// removeBookmarks drops the bookmarks in content, which repeated copies of
+// a document would duplicate.
+func removeBookmarks(content []interface{}) []interface{} {
+	kept := content[:0]
+	for _, elem := range content {
+		switch v := elem.(type) {
+		case *wml.BookmarkStart, *wml.BookmarkEnd:
+			continue
+		case *wml.P:
+			v.Content = removeBookmarks(v.Content)
+		case *wml.Hyperlink:
+			v.Content = removeBookmarks(v.Content)
+		case *wml.Tbl:
+			for _, tr := range v.Tr {
+				for _, tc := range tr.Tc {
+					tc.Content = removeBookmarks(tc.Content)
+				}
+			}
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				v.SdtContent.Content = removeBookmarks(v.SdtContent.Content)
+			}
+		}
+		kept = append(kept, elem)
+	}
+	return kept
+}
@@ -264,16 +264,27 @@ func (p *paragraphImpl) AddPicture(imagePath string, widthEMU, heightEMU int64)
 	if p == nil || p.doc == nil || p.doc.pkg == nil {
 		return utils.ErrDocumentClosed
 	}
+	run, err := p.doc.newPictureRun(imagePath, widthEMU, heightEMU)
+	if err != nil {
+		return err
+	}
+	p.p.Content = append(p.p.Content, run)
+	return nil
+}
+
+// newPictureRun adds an image to the package and returns a run that shows
+// it in the document body.
+func (d *documentImpl) newPictureRun(imagePath string, widthEMU, heightEMU int64) (*wml.R, error) {
 	if imagePath == "" {
-		return utils.ErrPathNotSet
+		return nil, utils.ErrPathNotSet
 	}
 	if widthEMU <= 0 || heightEMU <= 0 {
-		return utils.NewValidationError("size", "width and height must be positive", fmt.Sprintf("%d x %d", widthEMU, heightEMU))
+		return nil, utils.NewValidationError("size", "width and height must be positive", fmt.Sprintf("%d x %d", widthEMU, heightEMU))
 	}
 	cleanPath := filepath.Clean(imagePath)
 	data, err := os.ReadFile(cleanPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	ext := strings.TrimPrefix(strings.ToLower(path.Ext(cleanPath)), ".")
 	contentType := packaging.ContentTypePNG
@@ -287,18 +298,20 @@ func (p *paragraphImpl) AddPicture(imagePath string, widthEMU, heightEMU int64)
 	case "tif", "tiff":
 		contentType = packaging.ContentTypeTIFF
 	}
-	imageName := fmt.Sprintf("word/media/image%d.%s", p.doc.nextImageID, ext)
-	p.doc.nextImageID++
-	if _, err := p.doc.pkg.AddPart(imageName, contentType, data); err != nil {
-		return err
+	imageName := fmt.Sprintf("word/media/image%d.%s", d.nextImageID, ext)
+	d.nextImageID++
+	// Identical images share one media part.
+	imageName, err = d.pkg.AddMedia(imageName, contentType, data)
+	if err != nil {
+		return nil, err
 	}
 	sourcePath := packaging.WordDocumentPath
-	rels := p.doc.pkg.GetRelationships(sourcePath)
+	rels := d.pkg.GetRelationships(sourcePath)
 	relID := rels.NextID()
 	rels.AddWithID(relID, packaging.RelTypeImage, relativeTarget(sourcePath, imageName), packaging.TargetModeInternal)
 
-	drawingID := p.doc.nextDrawingID
-	p.doc.nextDrawingID++
+	drawingID := d.nextDrawingID
+	d.nextDrawingID++
 	name := fmt.Sprintf("Picture %d", drawingID)
 	inline := &dml.WPInline{
 		Ext:   &dml.WPSize{Cx: widthEMU, Cy: heightEMU},
@@ -310,31 +323,39 @@ func (p *paragraphImpl) AddPicture(imagePath string, widthEMU, heightEMU int64)
 			},
 		},
 	}
-	if err := p.addDrawingInline(inline); err != nil {
+	return newDrawingRun(inline)
+}
+
+func (p *paragraphImpl) addDrawingInline(inline *dml.WPInline) error {
+	run, err := newDrawingRun(inline)
+	if err != nil {
 		return err
 	}
+	p.p.Content = append(p.p.Content, run)
 	return nil
 }
 
-func (p *paragraphImpl) addDrawingInline(inline *dml.WPInline) error {
+// newDrawingRun returns a run holding an inline drawing.
+func newDrawingRun(inline *dml.WPInline) (*wml.R, error) {
 	if inline == nil {
-		return utils.NewValidationError("drawing", "inline cannot be nil", nil)
+		return nil, utils.NewValidationError("drawing", "inline cannot be nil", nil)
 	}
 	data, err := utils.MarshalXMLWithHeader(inline)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	inlineXML := string(stripXMLHeader(data))
 	inlineXML = strings.Replace(inlineXML, "<graphic>", "<a:graphic>", 1)
+	// The graphic may carry a default namespace declaration instead, which
+	// would not match the prefixed end tag below.
+	inlineXML = strings.Replace(inlineXML, `<graphic xmlns="`+packaging.NSDrawingML+`">`, "<a:graphic>", 1)
 	inlineXML = strings.Replace(inlineXML, "</graphic>", "</a:graphic>", 1)
 	inlineXML = strings.Replace(inlineXML, "<graphicData", "<a:graphicData", 1)
 	inlineXML = strings.Replace(inlineXML, "</graphicData>", "</a:graphicData>", 1)
 	inlineXML = ensureXMLNamespace(inlineXML, "a", packaging.NSDrawingML)
 	inlineXML = ensureXMLNamespace(inlineXML, "r", packaging.NSOfficeDocRels)
 	drawing := &wml.Drawing{Inner: inlineXML}
-	run := &wml.R{Content: []interface{}{drawing}}
-	p.p.Content = append(p.p.Content, run)
-	return nil
+	return &wml.R{Content: []interface{}{drawing}}, nil
 }
 
 func pictureXML(relID string) *dml.PictureRef {
@@ -2,10 +2,19 @@
 package document
 
 import (
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
 	"github.com/rcarmo/go-ooxml/pkg/packaging"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
 )
 
 // =============================================================================
@@ -627,6 +636,852 @@ func TestAddField(t *testing.T) {
 	}
 }
 
+// appendTestField appends a complex field with one nested field to para:
+// IF {MERGEFIELD Title} = "Dr" "Doctor" "Friend".
+func appendTestField(para Paragraph, innerResult, result string) {
+	p := para.(*paragraphImpl).p
+	fldChar := func(typ string) *wml.R {
+		return &wml.R{Content: []interface{}{&wml.FldChar{FldCharType: typ}}}
+	}
+	instr := func(text string) *wml.R {
+		return &wml.R{Content: []interface{}{wml.NewInstrText(text)}}
+	}
+	p.Content = append(p.Content,
+		fldChar(wml.FldCharBegin), instr("IF "),
+		fldChar(wml.FldCharBegin), instr(" MERGEFIELD Title "), fldChar(wml.FldCharSeparate),
+		&wml.R{Content: []interface{}{wml.NewT(innerResult)}}, fldChar(wml.FldCharEnd),
+		instr(` = "Dr" "Doctor" "Friend" `), fldChar(wml.FldCharSeparate),
+		&wml.R{RPr: &wml.RPr{B: wml.NewOnOffEnabled()}, Content: []interface{}{wml.NewT(result)}},
+		fldChar(wml.FldCharEnd))
+}
+
+// appendSimpleField appends a w:fldSimple to para.
+func appendSimpleField(para Paragraph, instr, result string) {
+	p := para.(*paragraphImpl).p
+	p.Content = append(p.Content, &wml.FldSimple{Instr: instr, Content: []interface{}{
+		&wml.R{Content: []interface{}{wml.NewT(result)}},
+	}})
+}
+
+func TestDocument_Fields(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(func(d Document) {
+		if _, err := d.AddParagraph().AddField(`DATE \@ "d MMMM yyyy" \* MERGEFORMAT`, "1 January 2024"); err != nil {
+			t.Fatal(err)
+		}
+		appendSimpleField(d.AddParagraph(), " AUTHOR ", "Jane")
+		appendTestField(d.AddParagraph(), "Mr", "Friend")
+	})
+	path := h.SaveDocument(doc, "fields.docx")
+	doc.Close()
+
+	doc2 := h.OpenDocument(path)
+	defer doc2.Close()
+	fields := doc2.Fields()
+	if len(fields) != 4 {
+		t.Fatalf("Fields() returned %d fields, want 4", len(fields))
+	}
+
+	date := fields[0]
+	if date.Type != "DATE" || date.Display != "1 January 2024" || date.IsSimple() {
+		t.Errorf("DATE field = %+v", date)
+	}
+	if v, ok := date.Switch("@"); !ok || v != "d MMMM yyyy" {
+		t.Errorf(`Switch("@") = %q, %v`, v, ok)
+	}
+	if v, ok := date.Switch(`\*`); !ok || v != "MERGEFORMAT" {
+		t.Errorf(`Switch("\\*") = %q, %v`, v, ok)
+	}
+	if runs := date.ResultRuns(); len(runs) != 1 || runs[0].Text() != "1 January 2024" {
+		t.Errorf("ResultRuns() = %v", runs)
+	}
+
+	if author := fields[1]; author.Type != "AUTHOR" || !author.IsSimple() || author.Display != "Jane" {
+		t.Errorf("AUTHOR field = %+v", author)
+	}
+	if got := doc2.Paragraphs()[1].Text(); got != "Jane" {
+		t.Errorf("simple field paragraph text = %q, want %q", got, "Jane")
+	}
+
+	ifField, merge := fields[2], fields[3]
+	if ifField.Instruction != `IF { MERGEFIELD Title } = "Dr" "Doctor" "Friend"` {
+		t.Errorf("IF Instruction = %q", ifField.Instruction)
+	}
+	if strings.Join(ifField.Arguments, "|") != "Mr|=|Dr|Doctor|Friend" || ifField.Display != "Friend" {
+		t.Errorf("IF Arguments = %q, Display = %q", ifField.Arguments, ifField.Display)
+	}
+	if merge.Type != "MERGEFIELD" || len(merge.Arguments) != 1 || merge.Arguments[0] != "Title" || merge.Display != "Mr" {
+		t.Errorf("MERGEFIELD field = %+v", merge)
+	}
+}
+
+func TestDocument_UpdateFields(t *testing.T) {
+	doc, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+	if err := doc.SetCoreProperties(&common.CoreProperties{Title: "Quarterly report", Creator: "Jane"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = doc.SetCustomProperty("Client", "Acme")
+	_ = doc.SetCustomProperty("Amount", 1234.5)
+
+	add := func(instruction, display string) Paragraph {
+		p := doc.AddParagraph()
+		if _, err := p.AddField(instruction, display); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	date := add(`DATE \@ "dddd, d MMMM yyyy"`, "")
+	clock := add(`TIME \@ "HH:mm"`, "old")
+	client := add(`DOCPROPERTY Client \* Upper`, "")
+	amount := add(`DOCPROPERTY Amount \# "$#,##0.00"`, "")
+	caption := doc.AddParagraph()
+	caption.AddRun().SetText("Revenue table")
+	if err := caption.AddBookmark("RevenueTable", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	seq1 := add("SEQ Figure", "9")
+	seq2 := add("SEQ Figure", "9")
+	seq3 := add(`SEQ Figure \* ROMAN`, "9")
+	ref := add(`REF RevenueTable \h`, "")
+	missing := add("REF Nowhere", "")
+	greeting := add(`MERGEFIELD Name \b "Dear "`, "")
+	unknown := add("MERGEFIELD Missing", "")
+	page := add("PAGE", "7")
+	locked := add("DATE", "frozen")
+	title := doc.AddParagraph()
+	appendSimpleField(title, " TITLE ", "")
+	nested := doc.AddParagraph()
+	appendTestField(nested, "", "")
+
+	lock := true
+	for _, f := range doc.Fields() {
+		if f.Display == "frozen" {
+			f.begin.r.Content[0].(*wml.FldChar).Lock = &lock
+		}
+	}
+
+	opts := &FieldUpdateOptions{
+		Now:       time.Date(2024, time.March, 15, 9, 5, 0, 0, time.UTC),
+		MergeData: map[string]string{"name": "Ann", "Title": "Dr"},
+	}
+	if err := doc.UpdateFieldsWithOptions(opts); err != nil {
+		t.Fatalf("UpdateFieldsWithOptions() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		para Paragraph
+		want string
+	}{
+		{"DATE", date, "Friday, 15 March 2024"},
+		{"TIME", clock, "09:05"},
+		{"DOCPROPERTY", client, "ACME"},
+		{"numeric picture", amount, "$1,234.50"},
+		{"SEQ", seq1, "1"},
+		{"SEQ again", seq2, "2"},
+		{"SEQ roman", seq3, "III"},
+		{"REF", ref, "Revenue table"},
+		{"REF missing", missing, "Error! Reference source not found."},
+		{"MERGEFIELD", greeting, "Dear Ann"},
+		{"MERGEFIELD missing", unknown, "«Missing»"},
+		{"PAGE", page, "7"},
+		{"locked", locked, "frozen"},
+		{"TITLE", title, "Quarterly report"},
+		{"IF", nested, "DrDoctor"},
+	}
+	for _, tt := range tests {
+		if got := tt.para.Text(); got != tt.want {
+			t.Errorf("%s: paragraph text = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// The IF result keeps the formatting of its cached result run.
+	fields := doc.Fields()
+	ifField := fields[len(fields)-2]
+	if runs := ifField.ResultRuns(); len(runs) != 1 || !runs[0].Bold() || ifField.Display != "Doctor" {
+		t.Errorf("IF result runs = %v, Display = %q", runs, ifField.Display)
+	}
+}
+
+// appendMergeField appends a MERGEFIELD whose instruction is split across
+// runs and whose result is italic.
+func appendMergeField(para Paragraph, name string) {
+	p := para.(*paragraphImpl).p
+	p.Content = append(p.Content,
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharBegin}}},
+		&wml.R{Content: []interface{}{wml.NewInstrText(" MERGE")}},
+		&wml.R{Content: []interface{}{wml.NewInstrText("FIELD " + name + " ")}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharSeparate}}},
+		&wml.R{RPr: &wml.RPr{I: wml.NewOnOffEnabled()}, Content: []interface{}{wml.NewT("«" + name + "»")}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharEnd}}})
+}
+
+func TestDocument_MailMerge(t *testing.T) {
+	doc, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+	greeting := doc.AddParagraph()
+	greeting.AddRun().SetText("Dear ")
+	appendMergeField(greeting, "Name")
+	greeting.AddRun().SetText(",")
+	appendTestField(doc.AddParagraph(), "«Title»", "Friend")
+	appendSimpleField(doc.AddParagraph(), " MERGEREC ", "1")
+	// SKIPIF {MERGEFIELD Name} = "Skip"
+	skip := doc.AddParagraph().(*paragraphImpl).p
+	skip.Content = append(skip.Content,
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharBegin}}},
+		&wml.R{Content: []interface{}{wml.NewInstrText("SKIPIF ")}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharBegin}}},
+		&wml.R{Content: []interface{}{wml.NewInstrText(" MERGEFIELD name ")}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharEnd}}},
+		&wml.R{Content: []interface{}{wml.NewInstrText(` = "Skip" `)}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharEnd}}})
+
+	docs, err := doc.MailMerge(MergeRecords([]map[string]string{
+		{"Name": "Ada", "Title": "Dr"},
+		{"Name": "Skip"},
+		{"name": "Bob"},
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("MailMerge() returned %d documents, want 2", len(docs))
+	}
+	want := [][]string{
+		{"Dear Ada,", "Doctor", "1", ""},
+		{"Dear Bob,", "Friend", "3", ""},
+	}
+	for i, merged := range docs {
+		defer merged.Close()
+		for j, para := range merged.Paragraphs() {
+			if got := para.Text(); got != want[i][j] {
+				t.Errorf("document %d paragraph %d = %q, want %q", i, j, got, want[i][j])
+			}
+		}
+		if fields := merged.Fields(); len(fields) != 0 {
+			t.Errorf("document %d kept %d fields", i, len(fields))
+		}
+		runs := merged.Paragraphs()[0].Runs()
+		if len(runs) != 3 || !runs[1].Italic() || runs[1].Text() != want[i][0][5:8] {
+			t.Errorf("document %d merged name run lost its formatting: %v", i, runs)
+		}
+	}
+	if got := doc.Paragraphs()[0].Text(); got != "Dear «Name»," {
+		t.Errorf("template changed to %q", got)
+	}
+
+	failing := MergeFunc(func() (map[string]string, error) {
+		return nil, errors.New("source failed")
+	})
+	if _, err := doc.MailMerge(failing, nil); err == nil || err.Error() != "source failed" {
+		t.Errorf("MailMerge() error = %v, want the source error", err)
+	}
+}
+
+func TestDocument_MailMergeToDocument(t *testing.T) {
+	h := NewTestHelper(t)
+	doc, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+	photo := doc.AddParagraph().(*paragraphImpl).p
+	photo.Content = append(photo.Content,
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharBegin}}},
+		&wml.R{Content: []interface{}{wml.NewInstrText(`INCLUDEPICTURE "`)}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharBegin}}},
+		&wml.R{Content: []interface{}{wml.NewInstrText(" MERGEFIELD Photo ")}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharEnd}}},
+		&wml.R{Content: []interface{}{wml.NewInstrText(`" \d`)}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharEnd}}})
+
+	label := doc.AddParagraph()
+	appendMergeField(label, "Name")
+	appendSimpleField(label, " NEXT ", "")
+	label.AddRun().SetText(" & ")
+	appendMergeField(label, "Name")
+
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "photo.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 10, 20))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	merged, err := doc.MailMergeToDocument(MergeRecords([]map[string]string{
+		{"Name": "Ada", "Photo": "photo.png"},
+		{"Name": "Bob"},
+		{"Name": "Cy", "Photo": "photo.png"},
+	}), &MailMergeOptions{ImageDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := h.SaveDocument(merged, "merged.docx")
+	merged.Close()
+
+	merged = h.OpenDocument(path)
+	defer merged.Close()
+	paras := merged.Paragraphs()
+	if len(paras) != 4 {
+		t.Fatalf("merged document has %d paragraphs, want 4", len(paras))
+	}
+	if got := paras[1].Text(); got != "Ada & Bob" {
+		t.Errorf("first label = %q, want %q", got, "Ada & Bob")
+	}
+	if got := paras[3].Text(); got != "Cy & " {
+		t.Errorf("second label = %q, want %q", got, "Cy & ")
+	}
+	if ppr := paras[1].(*paragraphImpl).p.PPr; ppr == nil || ppr.SectPr == nil {
+		t.Error("no section break after the first record")
+	}
+	for _, i := range []int{0, 2} {
+		runs := paras[i].(*paragraphImpl).p.Content
+		if len(runs) != 1 {
+			t.Fatalf("photo paragraph %d content = %v", i, runs)
+		}
+		drawing, ok := runs[0].(*wml.R).Content[0].(*wml.Drawing)
+		if !ok || !strings.Contains(drawing.Inner, `cx="95250" cy="190500"`) {
+			t.Errorf("photo paragraph %d does not show the 10x20 pixel image", i)
+		}
+	}
+}
+
+func TestFormatWordDate(t *testing.T) {
+	ts := time.Date(2024, time.January, 5, 14, 3, 9, 0, time.UTC)
+	tests := []struct {
+		picture string
+		want    string
+	}{
+		{"M/d/yyyy", "1/5/2024"},
+		{"dd.MM.yy", "05.01.24"},
+		{"dddd, MMMM d", "Friday, January 5"},
+		{"ddd d MMM", "Fri 5 Jan"},
+		{"h:mm am/pm", "2:03 pm"},
+		{"HH:mm:ss", "14:03:09"},
+		{"'Week of' d MMM", "Week of 5 Jan"},
+	}
+	for _, tt := range tests {
+		if got := formatWordDate(ts, tt.picture); got != tt.want {
+			t.Errorf("formatWordDate(%q) = %q, want %q", tt.picture, got, tt.want)
+		}
+	}
+}
+
+func TestFieldFormatSwitches(t *testing.T) {
+	tests := []struct {
+		text, format, want string
+	}{
+		{"hello world", "Caps", "Hello World"},
+		{"hello world", "FirstCap", "Hello world"},
+		{"14", "roman", "xiv"},
+		{"28", "ALPHABETIC", "BB"},
+		{"22", "Ordinal", "22nd"},
+		{"13", "Ordinal", "13th"},
+		{"text", "MERGEFORMAT", "text"},
+	}
+	for _, tt := range tests {
+		if got := applyGeneralFormat(tt.text, tt.format); got != tt.want {
+			t.Errorf("applyGeneralFormat(%q, %q) = %q, want %q", tt.text, tt.format, got, tt.want)
+		}
+	}
+	numbers := []struct {
+		v       float64
+		picture string
+		want    string
+	}{
+		{1234567.891, "#,##0.00", "1,234,567.89"},
+		{5, "00", "05"},
+		{-42.5, "0.0", "-42.5"},
+		{0.256, "0%", "26%"},
+	}
+	for _, tt := range numbers {
+		if got := formatNumberPicture(tt.v, tt.picture); got != tt.want {
+			t.Errorf("formatNumberPicture(%v, %q) = %q, want %q", tt.v, tt.picture, got, tt.want)
+		}
+	}
+}
+
+func TestBody_InsertTableOfContents(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(func(d Document) {
+		d.AddParagraph().SetText("Quarterly Report")
+		for _, heading := range []struct{ style, text string }{
+			{"Heading1", "Introduction"}, {"Heading2", "Scope"}, {"Heading4", "Too Deep"}, {"Heading1", "Results"},
+		} {
+			if heading.text == "Results" {
+				d.AddParagraph().AddRun().AddPageBreak()
+			}
+			p := d.AddParagraph()
+			p.SetStyle(heading.style)
+			p.SetText(heading.text)
+			d.AddParagraph().SetText(strings.Repeat("Body text. ", 20))
+		}
+		field, err := d.Body().InsertTableOfContents(&TOCOptions{
+			Index: 1, Title: "Contents", EstimatePageNumbers: true, UpdateFieldsOnOpen: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if field == nil || field.Type != "TOC" {
+			t.Fatalf("InsertTableOfContents() field = %+v", field)
+		}
+		if v, ok := field.Switch("o"); !ok || v != "1-3" {
+			t.Errorf(`Switch("o") = %q, %v`, v, ok)
+		}
+	})
+	path := h.SaveDocument(doc, "toc.docx")
+	doc.Close()
+
+	doc2 := h.OpenDocument(path)
+	defer doc2.Close()
+	paras := doc2.Paragraphs()
+	want := []struct{ style, text string }{
+		{"TOCHeading", "Contents"},
+		{"TOC1", "Introduction\t1"},
+		{"TOC2", "Scope\t1"},
+		{"TOC1", "Results\t2"},
+	}
+	for i, w := range want {
+		if got := paras[i+1]; got.Style() != w.style || got.Text() != w.text {
+			t.Errorf("paragraph %d = %q (%s), want %q (%s)", i+1, got.Text(), got.Style(), w.text, w.style)
+		}
+	}
+	for _, id := range []string{"TOCHeading", "TOC1", "TOC2"} {
+		if doc2.Styles().ByID(id) == nil {
+			t.Errorf("style %s was not added", id)
+		}
+	}
+	if doc2.Styles().ByID("TOC3") != nil {
+		t.Error("style TOC3 added without level 3 entries")
+	}
+
+	// Each entry links to a bookmark around its heading.
+	heading := paras[5]
+	if heading.Text() != "Introduction" {
+		t.Fatalf("paragraph 5 = %q, want the Introduction heading", heading.Text())
+	}
+	start, ok := heading.(*paragraphImpl).p.Content[0].(*wml.BookmarkStart)
+	if !ok || !strings.HasPrefix(start.Name, "_Toc") {
+		t.Fatalf("heading does not start with a _Toc bookmark: %v", heading.(*paragraphImpl).p.Content)
+	}
+	if links := paras[2].Hyperlinks(); len(links) != 1 || links[0].Anchor() != start.Name {
+		t.Errorf("entry hyperlinks = %v, want one to %s", links, start.Name)
+	}
+	if settings := doc2.(*documentImpl).settings; settings == nil || settings.UpdateFields == nil {
+		t.Error("updateFields was not set in the settings")
+	}
+
+	var types []string
+	for _, f := range doc2.Fields() {
+		types = append(types, f.Type)
+	}
+	if got := strings.Join(types, " "); got != "TOC PAGEREF PAGEREF PAGEREF" {
+		t.Errorf("Fields() types = %s", got)
+	}
+}
+
+func TestBody_InsertTableOfContentsPlaceholders(t *testing.T) {
+	doc, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	if _, err := doc.Body().InsertTableOfContents(&TOCOptions{MinLevel: 4, MaxLevel: 2}); err == nil {
+		t.Error("InsertTableOfContents() accepted MinLevel above MaxLevel")
+	}
+	field, err := doc.Body().InsertTableOfContents(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field.Display != tocNoEntries {
+		t.Errorf("Display without headings = %q, want %q", field.Display, tocNoEntries)
+	}
+
+	p := doc.AddParagraph()
+	p.SetStyle("Heading1")
+	p.SetText("Only Heading")
+	if _, err := doc.Body().InsertTableOfContents(&TOCOptions{Index: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Paragraphs()[1].Text(); got != "Only Heading\t" {
+		t.Errorf("entry text = %q, want the heading without a page number", got)
+	}
+	for _, f := range doc.Fields() {
+		if f.Type == "PAGEREF" && (f.Display != "" || f.begin.r.Content[0].(*wml.FldChar).Dirty == nil) {
+			t.Errorf("PAGEREF placeholder = %+v, want an empty result marked dirty", f)
+		}
+	}
+}
+
+// addSplitRuns adds text to para as one run per piece, the way Word splits
+// text for revision IDs and proofing marks.
+func addSplitRuns(para Paragraph, pieces ...string) []Run {
+	var runs []Run
+	for _, piece := range pieces {
+		run := para.AddRun()
+		run.SetText(piece)
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+func TestDocument_FindReplace(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(func(d Document) {
+		runs := addSplitRuns(d.AddParagraph(), "Dear ", "{{cus", "tomer}}", ", welcome.")
+		runs[1].SetBold(true)
+		runs[2].SetItalic(true)
+		d.AddTable(1, 1).Cell(0, 0).SetText("Account of {{customer}}")
+		d.AddHeader(HeaderFooterDefault).SetText("Prepared for {{customer}}")
+		d.AddParagraph().AddContentControl("name", "Name", "{{customer}}")
+		box := d.AddParagraph().AddRun().(*runImpl)
+		box.r.Content = append(box.r.Content, &wml.Drawing{Inner: `<wp:anchor><a:graphic><a:graphicData><wps:wsp><wps:txbx>` +
+			`<w:txbxContent><w:p><w:r><w:t>Hi {{</w:t></w:r><w:r><w:t>customer}}</w:t></w:r></w:p></w:txbxContent>` +
+			`</wps:txbx></wps:wsp></a:graphicData></a:graphic></wp:anchor>`})
+	})
+	path := h.SaveDocument(doc, "find.docx")
+	doc.Close()
+
+	// Add a footnote part, which documents made here do not have.
+	pkg, err := packaging.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := `<w:footnotes xmlns:w="` + wml.NS + `">` +
+		`<w:footnote w:type="separator" w:id="-1"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>` +
+		`<w:footnote w:id="1"><w:p><w:r><w:footnoteRef/></w:r><w:r><w:t xml:space="preserve"> Sent to {{cust</w:t></w:r><w:r><w:t>omer}}</w:t></w:r></w:p></w:footnote>` +
+		`</w:footnotes>`
+	if _, err := pkg.AddPart(packaging.WordFootnotesPath, packaging.ContentTypeFootnotes, []byte(notes)); err != nil {
+		t.Fatal(err)
+	}
+	pkg.AddRelationship(packaging.WordDocumentPath, "footnotes.xml", packaging.RelTypeFootnotes)
+	if err := pkg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	pkg.Close()
+
+	doc2 := h.OpenDocument(path)
+	matches, err := doc2.FindAll(`\{\{(\w+)\}\}`)
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	var stories []string
+	for _, m := range matches {
+		if m.Text != "{{customer}}" || len(m.Groups) != 1 || m.Groups[0] != "customer" {
+			t.Errorf("match = %+v", m)
+		}
+		stories = append(stories, string(m.Story))
+	}
+	if got, want := strings.Join(stories, ","), "body,body,body,body,header,footnote"; got != want {
+		t.Errorf("match stories = %s, want %s", got, want)
+	}
+	if m := matches[0]; m.Offset != 5 || m.Paragraph.Text() != "Dear {{customer}}, welcome." {
+		t.Errorf("first match at %d in %q", m.Offset, m.Paragraph.Text())
+	}
+	if _, err := doc2.FindAll(`(`); err == nil {
+		t.Error("FindAll() accepted an invalid pattern")
+	}
+
+	n, err := doc2.ReplaceAll(`\{\{(\w+)\}\}`, "<$1>")
+	if err != nil || n != 6 {
+		t.Fatalf("ReplaceAll() = %d, %v, want 6", n, err)
+	}
+	runs := doc2.Paragraphs()[0].Runs()
+	if len(runs) != 3 || runs[1].Text() != "<customer>" || !runs[1].Bold() || runs[1].Italic() {
+		t.Errorf("runs after replacing = %d, replacement %q", len(runs), runs[1].Text())
+	}
+	path = h.SaveDocument(doc2, "replaced.docx")
+	doc2.Close()
+
+	doc3 := h.OpenDocument(path)
+	defer doc3.Close()
+	if got := doc3.Paragraphs()[0].Text(); got != "Dear <customer>, welcome." {
+		t.Errorf("paragraph text = %q", got)
+	}
+	if got := doc3.Tables()[0].Cell(0, 0).Text(); got != "Account of <customer>" {
+		t.Errorf("cell text = %q", got)
+	}
+	if got := doc3.Header(HeaderFooterDefault).Text(); got != "Prepared for <customer>" {
+		t.Errorf("header text = %q", got)
+	}
+	if got := doc3.ContentControlByTag("name").Text(); got != "<customer>" {
+		t.Errorf("content control text = %q", got)
+	}
+	matches, err = doc3.FindAll(`(Hi|Sent to) <customer>`)
+	if err != nil || len(matches) != 2 || matches[0].Story != StoryBody || matches[1].Story != StoryFootnote {
+		t.Fatalf("FindAll() after saving = %+v, %v", matches, err)
+	}
+	if got := matches[1].Paragraph.Text(); got != " Sent to <customer>" {
+		t.Errorf("footnote text = %q", got)
+	}
+	if n, _ := doc3.ReplaceAll(`\{\{`, ""); n != 0 {
+		t.Errorf("ReplaceAll() replaced %d leftovers", n)
+	}
+}
+
+func TestDocument_ReplaceAllTracked(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(func(d Document) {
+		runs := addSplitRuns(d.AddParagraph(), "Total: ", "12", "0 EUR")
+		runs[1].SetBold(true)
+	})
+	n, err := doc.ReplaceAllWithOptions(`(\d+) EUR`, "€$1", &ReplaceOptions{Track: true, Author: "Editor"})
+	if err != nil || n != 1 {
+		t.Fatalf("ReplaceAllWithOptions() = %d, %v", n, err)
+	}
+	if doc.TrackChangesEnabled() {
+		t.Error("ReplaceAllWithOptions() enabled track changes")
+	}
+	path := h.SaveDocument(doc, "tracked.docx")
+	doc.Close()
+
+	doc2 := h.OpenDocument(path)
+	defer doc2.Close()
+	revisions := doc2.AllRevisions()
+	if len(revisions) != 2 {
+		t.Fatalf("AllRevisions() = %d, want 2", len(revisions))
+	}
+	if r := revisions[0]; r.Type() != RevisionDelete || r.Text() != "120 EUR" || r.Author() != "Editor" {
+		t.Errorf("deletion = %s %q by %s", r.Type(), r.Text(), r.Author())
+	}
+	if r := revisions[1]; r.Type() != RevisionInsert || r.Text() != "€120" || r.Author() != "Editor" {
+		t.Errorf("insertion = %s %q by %s", r.Type(), r.Text(), r.Author())
+	}
+	matches, err := doc2.FindAll(`EUR`)
+	if err != nil || len(matches) != 0 {
+		t.Errorf("FindAll() found deleted text: %+v, %v", matches, err)
+	}
+	doc2.AcceptAllRevisions()
+	para := doc2.Paragraphs()[0]
+	if got := para.Text(); got != "Total: €120" {
+		t.Errorf("text after accepting = %q", got)
+	}
+	if runs := para.Runs(); !runs[len(runs)-1].Bold() {
+		t.Error("insertion did not keep the formatting of the first replaced run")
+	}
+}
+
+
+func TestRange_EditAndAnnotate(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(func(d Document) {
+		addSplitRuns(d.AddParagraph(), "The quick ", "brown fox", " jumps.")
+		d.AddParagraph().SetText("Second paragraph.")
+		d.AddParagraph().SetText("Third.")
+	})
+
+	rg, err := doc.Range(Position{Run: 0, Offset: 4}, Position{Run: 1, Offset: 5})
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if got := rg.Text(); got != "quick brown" {
+		t.Errorf("Text() = %q, want %q", got, "quick brown")
+	}
+	if got := rg.Start(); got != (Position{Run: 0, Offset: 4}) {
+		t.Errorf("Start() = %+v", got)
+	}
+	if err := rg.Format(func(r Run) { r.SetBold(true) }); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	for _, r := range doc.Paragraphs()[0].Runs() {
+		want := r.Text() == "quick " || r.Text() == "brown"
+		if r.Bold() != want {
+			t.Errorf("run %q bold = %v, want %v", r.Text(), r.Bold(), want)
+		}
+	}
+	if _, err := rg.AddComment("Which fox?", "Reviewer"); err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+
+	matches, err := doc.FindAll(`fox`)
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("FindAll() = %d, %v", len(matches), err)
+	}
+	if err := matches[0].Range.AddBookmark("animal"); err != nil {
+		t.Fatalf("AddBookmark() error = %v", err)
+	}
+
+	matches, _ = doc.FindAll(`Second`)
+	second := matches[0].Range
+	if _, err := second.InsertBefore("A "); err != nil {
+		t.Fatalf("InsertBefore() error = %v", err)
+	}
+	inserted, err := second.InsertAfter("!")
+	if err != nil {
+		t.Fatalf("InsertAfter() error = %v", err)
+	}
+	if second.Text() != "Second" || inserted.Text() != "!" {
+		t.Errorf("ranges after inserting = %q, %q", second.Text(), inserted.Text())
+	}
+
+	matches, _ = doc.FindAll(`paragraph\.|Third`)
+	span, err := doc.Range(matches[0].Range.Start(), matches[1].Range.Start())
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if got := span.Text(); got != "paragraph.\n" {
+		t.Errorf("Text() = %q", got)
+	}
+	if err := span.Delete(); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if !span.IsCollapsed() {
+		t.Error("Delete() did not collapse the range")
+	}
+
+	if _, err := doc.Range(Position{Element: 1}, Position{Element: 0}); !errors.Is(err, utils.ErrInvalidRange) {
+		t.Errorf("Range() with end before start error = %v", err)
+	}
+	if _, err := doc.Range(Position{Element: 5}, Position{Element: 5}); !errors.Is(err, utils.ErrInvalidIndex) {
+		t.Errorf("Range() out of bounds error = %v", err)
+	}
+
+	path := h.SaveDocument(doc, "ranges.docx")
+	doc.Close()
+
+	doc2 := h.OpenDocument(path)
+	defer doc2.Close()
+	paras := doc2.Paragraphs()
+	if len(paras) != 2 || paras[1].Text() != "A Second! Third." {
+		t.Fatalf("paragraphs after delete = %d, second %q", len(paras), paras[len(paras)-1].Text())
+	}
+	comments := doc2.Comments().All()
+	if len(comments) != 1 || comments[0].AnchoredText() != "quick brown" {
+		t.Errorf("comments = %d, anchored %q", len(comments), comments[0].AnchoredText())
+	}
+	scan := doc2.(*documentImpl).scanStory(&doc2.(*documentImpl).document.Body.Content)
+	if text, ok := scan.bookmarkText("animal"); !ok || text != "fox" {
+		t.Errorf("bookmark text = %q, %v", text, ok)
+	}
+}
+
+func TestRange_ContentControlsAndRevisions(t *testing.T) {
+	h := NewTestHelper(t)
+	doc := h.CreateDocument(func(d Document) {
+		d.AddParagraph().SetText("Invoice for ACME Ltd, due today.")
+		d.AddParagraph().SetText("Terms apply.")
+		d.AddParagraph().SetText("Payment within 30 days.")
+	})
+	defer doc.Close()
+
+	matches, _ := doc.FindAll(`ACME Ltd`)
+	cc, err := matches[0].Range.WrapContentControl("Customer", "Customer")
+	if err != nil {
+		t.Fatalf("WrapContentControl() error = %v", err)
+	}
+	if cc.Text() != "ACME Ltd" || doc.ContentControlByTag("Customer") == nil {
+		t.Errorf("inline content control text = %q", cc.Text())
+	}
+	if matches, _ := doc.FindAll(`Invoice for ACME Ltd, due today\.`); len(matches) != 1 {
+		t.Error("wrapping changed the paragraph text")
+	}
+
+	partial, _ := doc.Range(Position{Element: 1, Offset: 2}, Position{Element: 2, Run: 1})
+	if _, err := partial.WrapContentControl("Terms", ""); err == nil {
+		t.Error("WrapContentControl() wrapped part of a paragraph with a block content control")
+	}
+	whole, _ := doc.Range(Position{Element: 1}, Position{Element: 2, Run: 1})
+	block, err := whole.WrapContentControl("Terms", "")
+	if err != nil {
+		t.Fatalf("WrapContentControl() error = %v", err)
+	}
+	body := doc.(*documentImpl).document.Body
+	if len(block.Paragraphs()) != 2 || len(body.Content) != 2 || body.Content[1] != interface{}(block.sdt) {
+		t.Errorf("block content control paragraphs = %d, body elements = %d",
+			len(block.Paragraphs()), len(body.Content))
+	}
+
+	doc.EnableTrackChanges("Editor")
+	matches, _ = doc.FindAll(`due today`)
+	if _, err := matches[0].Range.InsertAfter(" at noon"); err != nil {
+		t.Fatalf("InsertAfter() error = %v", err)
+	}
+	matches, _ = doc.FindAll(`Invoice `)
+	if err := matches[0].Range.Delete(); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	revisions := doc.AllRevisions()
+	if len(revisions) != 2 {
+		t.Fatalf("AllRevisions() = %d, want 2", len(revisions))
+	}
+	for _, rev := range revisions {
+		loc := rev.Location()
+		if loc == nil {
+			t.Fatalf("%s Location() = nil", rev.Type())
+		}
+		switch rev.Type() {
+		case RevisionInsert:
+			if loc.Text() != " at noon" {
+				t.Errorf("insertion Location().Text() = %q", loc.Text())
+			}
+		case RevisionDelete:
+			if !loc.IsCollapsed() || loc.Start() != (Position{}) {
+				t.Errorf("deletion Location() = %+v to %+v", loc.Start(), loc.End())
+			}
+		}
+	}
+}
+
+func TestRevision_LocationInHyperlink(t *testing.T) {
+	doc := NewTestHelper(t).CreateDocument(func(d Document) {
+		d.AddParagraph().SetText("See ")
+	})
+	defer doc.Close()
+
+	para := doc.Paragraphs()[0].(*paragraphImpl).p
+	link := &wml.Hyperlink{Anchor: "docs", Content: []interface{}{
+		&wml.R{Content: []interface{}{wml.NewT("the ")}},
+		&wml.Ins{ID: 1, Author: "Editor", Content: []interface{}{&wml.R{Content: []interface{}{wml.NewT("new ")}}}},
+		&wml.Del{ID: 2, Author: "Editor", Content: []interface{}{&wml.R{Content: []interface{}{&wml.DelText{Text: "old "}}}}},
+		&wml.R{Content: []interface{}{wml.NewT("docs")}},
+	}}
+	para.Content = append(para.Content, link)
+
+	revisions := doc.AllRevisions()
+	if len(revisions) != 2 {
+		t.Fatalf("AllRevisions() = %d, want 2", len(revisions))
+	}
+	for _, rev := range revisions {
+		loc := rev.Location()
+		if loc == nil {
+			t.Fatalf("%s Location() = nil", rev.Type())
+		}
+		switch rev.Type() {
+		case RevisionInsert:
+			if loc.Text() != "new " {
+				t.Errorf("insertion Location().Text() = %q, want %q", loc.Text(), "new ")
+			}
+		case RevisionDelete:
+			before, err := doc.Range(Position{}, loc.Start())
+			if err != nil {
+				t.Fatalf("Range() error = %v", err)
+			}
+			if !loc.IsCollapsed() || before.Text() != "See the new " {
+				t.Errorf("deletion Location() follows %q, collapsed %v", before.Text(), loc.IsCollapsed())
+			}
+		}
+	}
+
+	doc.AcceptAllRevisions()
+	if got := doc.Paragraphs()[0].Text(); got != "See the new docs" {
+		t.Errorf("text after AcceptAllRevisions() = %q", got)
+	}
+	if len(doc.AllRevisions()) != 0 || len(link.Content) != 3 {
+		t.Errorf("revisions left = %d, hyperlink content = %d", len(doc.AllRevisions()), len(link.Content))
+	}
+}
+
 func TestHyperlinkRoundTrip(t *testing.T) {
 	h := NewTestHelper(t)
 doc := h.CreateDocument(func(d Document) {
@@ -0,0 +1,139 @@
+package document
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
+)
+
+// ExtendedProperties returns the document extended properties (docProps/app.xml).
+func (d *documentImpl) ExtendedProperties() (*common.ExtendedProperties, error) {
+	return d.pkg.ExtendedProperties()
+}
+
+// SetExtendedProperties sets the document extended properties.
+func (d *documentImpl) SetExtendedProperties(props *common.ExtendedProperties) error {
+	return d.pkg.SetExtendedProperties(props)
+}
+
+// UpdateExtendedProperties recomputes the document statistics and the
+// title part in docProps/app.xml. Without a layout engine, Pages and Lines
+// are estimates based on explicit page and line breaks. Save and SaveAs do
+// not call it; use it or SaveOptions.UpdateExtendedProperties.
+func (d *documentImpl) UpdateExtendedProperties() error {
+	props, err := d.pkg.ExtendedProperties()
+	if err != nil {
+		return err
+	}
+
+	var stats documentStats
+	if d.document != nil && d.document.Body != nil {
+		stats.addBlocks(d.document.Body.Content)
+	}
+	props.Pages = stats.pageBreaks + 1
+	props.Words = stats.words
+	props.Characters = stats.characters
+	props.CharactersWithSpaces = stats.charactersWithSpaces
+	props.Paragraphs = stats.paragraphs
+	props.Lines = stats.lines
+
+	var titles []string
+	if core, err := d.pkg.CoreProperties(); err == nil && core.Title != "" {
+		titles = []string{core.Title}
+	}
+	props.SetPartTitles("Title", titles)
+
+	return d.pkg.SetExtendedProperties(props)
+}
+
+// documentStats accumulates Word-style document statistics.
+type documentStats struct {
+	words                int
+	characters           int
+	charactersWithSpaces int
+	paragraphs           int
+	lines                int
+	pageBreaks           int
+}
+
+func (s *documentStats) addBlocks(content []interface{}) {
+	for _, elem := range content {
+		switch v := elem.(type) {
+		case *wml.P:
+			s.addParagraph(v)
+		case *wml.Tbl:
+			for _, tr := range v.Tr {
+				for _, tc := range tr.Tc {
+					s.addBlocks(tc.Content)
+				}
+			}
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				s.addBlocks(v.SdtContent.Content)
+			}
+		}
+	}
+}
+
+func (s *documentStats) addParagraph(p *wml.P) {
+	var sb strings.Builder
+	lineBreaks := 0
+	s.collectInline(p.Content, &sb, &lineBreaks)
+	text := sb.String()
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	s.paragraphs++
+	s.lines += 1 + lineBreaks
+	s.words += len(strings.Fields(text))
+	for _, r := range text {
+		if r == '\n' {
+			continue
+		}
+		s.charactersWithSpaces++
+		if !unicode.IsSpace(r) {
+			s.characters++
+		}
+	}
+}
+
+// collectInline gathers the visible (non-deleted) text of inline content.
+func (s *documentStats) collectInline(content []interface{}, sb *strings.Builder, lineBreaks *int) {
+	for _, elem := range content {
+		switch v := elem.(type) {
+		case *wml.R:
+			for _, rc := range v.Content {
+				switch t := rc.(type) {
+				case *wml.T:
+					sb.WriteString(t.Text)
+				case *wml.Tab:
+					sb.WriteString("\t")
+				case *wml.Sym:
+					if r := symToRune(t.Char); r != 0 && utf8.ValidRune(r) {
+						sb.WriteRune(r)
+					}
+				case *wml.Br:
+					switch t.Type {
+					case "page":
+						s.pageBreaks++
+					case "column":
+					default:
+						*lineBreaks++
+					}
+					sb.WriteString("\n")
+				}
+			}
+		case *wml.Ins:
+			s.collectInline(v.Content, sb, lineBreaks)
+		case *wml.Hyperlink:
+			s.collectInline(v.Content, sb, lineBreaks)
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				s.collectInline(v.SdtContent.Content, sb, lineBreaks)
+			}
+		}
+	}
+}
@@ -0,0 +1,567 @@
+// Package document provides text range functionality.
+package document
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// Position is a point in the text of a story.
+type Position struct {
+	// Element is the index of a block element (paragraph, table or content
+	// control) of the story.
+	Element int
+	// Paragraph is the index of a paragraph within the element: 0 for a
+	// paragraph, and in document order for the paragraphs of a table or a
+	// content control.
+	Paragraph int
+	// Run is the index of a run of the paragraph, counting the runs of
+	// insertions, hyperlinks, simple fields and content controls but not
+	// deleted runs. The run count itself, with Offset 0, is the end of the
+	// paragraph.
+	Run int
+	// Offset is a character offset in the text of the run, up to its length.
+	Offset int
+}
+
+// Range is a span of text in a story, from a start position to an end
+// position that may be in a later paragraph. A range holds offsets into
+// the text of its paragraphs, so edits made other than through the range
+// can move it; a range whose paragraphs have been removed is invalid.
+// Ranges in text boxes refer to a copy of the text box, and changes made
+// through them are not saved.
+type Range struct {
+	doc   *documentImpl
+	story storyRef
+	start rangePoint
+	end   rangePoint
+}
+
+// rangePoint is a byte offset in the text of a paragraph, not counting
+// deleted text.
+type rangePoint struct {
+	p      *wml.P
+	offset int
+}
+
+// rangeSegment is the part of a range in one paragraph.
+type rangeSegment struct {
+	p          *wml.P
+	start, end int
+}
+
+// runSpan is the text of a run within the text of its paragraph.
+type runSpan struct {
+	r          *wml.R
+	start, end int
+}
+
+// Range returns the range of the body from start to end.
+func (d *documentImpl) Range(start, end Position) (*Range, error) {
+	if d.document == nil || d.document.Body == nil {
+		return nil, utils.ErrDocumentClosed
+	}
+	rg := &Range{doc: d, story: storyRef{StoryBody, &d.document.Body.Content}}
+	var err error
+	if rg.start, err = rg.resolve(start); err != nil {
+		return nil, err
+	}
+	if rg.end, err = rg.resolve(end); err != nil {
+		return nil, err
+	}
+	paras := storyParagraphs(*rg.story.blocks)
+	first, last := indexOfParagraph(paras, rg.start.p), indexOfParagraph(paras, rg.end.p)
+	if last < first || (last == first && rg.end.offset < rg.start.offset) {
+		return nil, utils.ErrInvalidRange
+	}
+	return rg, nil
+}
+
+// Story returns the part of the document the range is in.
+func (rg *Range) Story() StoryType {
+	return rg.story.typ
+}
+
+// Start returns the position of the start of the range.
+func (rg *Range) Start() Position {
+	return rg.position(rg.start)
+}
+
+// End returns the position of the end of the range.
+func (rg *Range) End() Position {
+	return rg.position(rg.end)
+}
+
+// IsCollapsed reports whether the range holds no text.
+func (rg *Range) IsCollapsed() bool {
+	return rg.start == rg.end
+}
+
+// Text returns the text of the range, with paragraphs separated by
+// newlines. Deleted text and field instructions are left out.
+func (rg *Range) Text() string {
+	var parts []string
+	for _, seg := range rg.segments() {
+		_, text := paragraphPieces(seg.p)
+		parts = append(parts, text[seg.start:seg.end])
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Paragraphs returns the paragraphs the range spans.
+func (rg *Range) Paragraphs() []Paragraph {
+	var result []Paragraph
+	for _, seg := range rg.segments() {
+		index := 0
+		if content, i := findParagraph(rg.story.blocks, seg.p); content != nil {
+			index = i
+		}
+		result = append(result, &paragraphImpl{doc: rg.doc, p: seg.p, index: index})
+	}
+	return result
+}
+
+// Runs returns the runs holding the text of the range, splitting runs at
+// the ends of the range so that every run returned is entirely inside it.
+func (rg *Range) Runs() []Run {
+	var runs []Run
+	for _, seg := range rg.segments() {
+		splitAt(seg.p, seg.end)
+		splitAt(seg.p, seg.start)
+		for _, span := range paragraphRunSpans(seg.p) {
+			if span.end > span.start && span.start >= seg.start && span.end <= seg.end {
+				runs = append(runs, &runImpl{doc: rg.doc, r: span.r})
+			}
+		}
+	}
+	return runs
+}
+
+// Format calls fn for each run of the range, e.g. to make the text bold
+// with func(r Run) { r.SetBold(true) }.
+func (rg *Range) Format(fn func(Run)) error {
+	if rg.segments() == nil {
+		return utils.ErrInvalidRange
+	}
+	for _, r := range rg.Runs() {
+		fn(r)
+	}
+	return nil
+}
+
+// Delete removes the text of the range and collapses the range to its
+// start. Without track changes, paragraphs the range spans are merged into
+// the first, which requires them to share a table cell or other container.
+// With track changes, the text is marked deleted and the paragraphs stay.
+func (rg *Range) Delete() error {
+	segs := rg.segments()
+	if segs == nil {
+		return utils.ErrInvalidRange
+	}
+	d := rg.doc
+	if d.trackChanges {
+		for i := len(segs) - 1; i >= 0; i-- {
+			if seg := segs[i]; seg.end > seg.start {
+				d.replaceTracked(seg.p, seg.start, seg.end, "", d.trackAuthor)
+			}
+		}
+		rg.end = rg.start
+		return nil
+	}
+	if len(segs) == 1 {
+		replaceText(segs[0].p, segs[0].start, segs[0].end, "")
+		rg.end = rg.start
+		return nil
+	}
+	first, last := segs[0], segs[len(segs)-1]
+	content, i := findParagraph(rg.story.blocks, first.p)
+	lastContent, j := findParagraph(rg.story.blocks, last.p)
+	if content == nil || content != lastContent {
+		return utils.NewValidationError("range", "cannot delete across tables or content controls", rg.Text())
+	}
+	replaceText(last.p, last.start, last.end, "")
+	replaceText(first.p, first.start, first.end, "")
+	first.p.Content = append(first.p.Content, last.p.Content...)
+	*content = append((*content)[:i+1], (*content)[j+1:]...)
+	rg.end = rg.start
+	return nil
+}
+
+// InsertBefore inserts text before the range, formatted like the text that
+// follows, and returns the range of the inserted text. The range moves to
+// stay after the inserted text. Tabs and newlines become tabs and breaks.
+func (rg *Range) InsertBefore(text string) (*Range, error) {
+	inserted, err := rg.insert(rg.start, text, false)
+	if err != nil {
+		return nil, err
+	}
+	if rg.end.p == rg.start.p {
+		rg.end.offset += len(text)
+	}
+	rg.start.offset += len(text)
+	return inserted, nil
+}
+
+// InsertAfter inserts text after the range, formatted like the text that
+// precedes it, and returns the range of the inserted text.
+func (rg *Range) InsertAfter(text string) (*Range, error) {
+	return rg.insert(rg.end, text, true)
+}
+
+func (rg *Range) insert(at rangePoint, text string, after bool) (*Range, error) {
+	if text == "" {
+		return nil, utils.NewValidationError("text", "cannot be empty", text)
+	}
+	if rg.segments() == nil {
+		return nil, utils.ErrInvalidRange
+	}
+	d := rg.doc
+	content, index, ref := boundary(at.p, at.offset, after)
+	run := &wml.R{Content: runTextContent(text)}
+	if ref != nil {
+		run.RPr = copyRPr(ref.run.RPr)
+	}
+	var elem interface{} = run
+	if d.trackChanges {
+		if ref == nil || !isInsertion(ref.owner) {
+			elem = &wml.Ins{
+				ID:      d.nextRevID(),
+				Author:  d.trackAuthor,
+				Date:    time.Now().Format(time.RFC3339),
+				Content: []interface{}{run},
+			}
+		}
+	}
+	insertElements(content, index, elem)
+	return &Range{
+		doc:   d,
+		story: rg.story,
+		start: at,
+		end:   rangePoint{at.p, at.offset + len(text)},
+	}, nil
+}
+
+// AddComment adds a comment anchored to the text of the range. A range
+// that starts or ends inside a hyperlink, insertion or content control
+// grows to take all of it.
+func (rg *Range) AddComment(text, author string) (Comment, error) {
+	if rg.segments() == nil {
+		return nil, utils.ErrInvalidRange
+	}
+	comment := rg.doc.AddComment(text, author)
+	id := comment.IDInt()
+	ref := &wml.R{Content: []interface{}{&wml.CommentReference{ID: id}}}
+	rg.mark(&wml.CommentRangeStart{ID: id}, []interface{}{&wml.CommentRangeEnd{ID: id}, ref})
+	return comment, nil
+}
+
+// AddBookmark adds a bookmark around the text of the range. A range that
+// starts or ends inside a hyperlink, insertion or content control grows to
+// take all of it.
+func (rg *Range) AddBookmark(name string) error {
+	if name == "" {
+		return utils.NewValidationError("bookmark", "name cannot be empty", name)
+	}
+	if rg.segments() == nil {
+		return utils.ErrInvalidRange
+	}
+	id := rg.doc.nextBookmarkID
+	rg.doc.nextBookmarkID++
+	rg.mark(&wml.BookmarkStart{ID: id, Name: name}, []interface{}{&wml.BookmarkEnd{ID: id}})
+	return nil
+}
+
+// mark puts start before the text of the range and end after it.
+func (rg *Range) mark(start interface{}, end []interface{}) {
+	splitAt(rg.end.p, rg.end.offset)
+	splitAt(rg.start.p, rg.start.offset)
+	i := markerIndex(rg.start.p, rg.start.offset, false)
+	j := markerIndex(rg.end.p, rg.end.offset, true)
+	if rg.start.p == rg.end.p && j < i {
+		j = i // nothing but markers between the ends
+	}
+	insertElements(&rg.end.p.Content, j, end...)
+	insertElements(&rg.start.p.Content, i, start)
+}
+
+// WrapContentControl wraps the range in a content control. A range within
+// a paragraph gets an inline content control, and grows to take all of a
+// hyperlink, insertion or content control it starts or ends inside. A range
+// of whole paragraphs that share a container gets a block content control.
+func (rg *Range) WrapContentControl(tag, alias string) (*ContentControl, error) {
+	segs := rg.segments()
+	if segs == nil {
+		return nil, utils.ErrInvalidRange
+	}
+	if len(segs) == 1 {
+		p := rg.start.p
+		splitAt(p, rg.end.offset)
+		splitAt(p, rg.start.offset)
+		i := markerIndex(p, rg.start.offset, false)
+		j := markerIndex(p, rg.end.offset, true)
+		if rg.IsCollapsed() || j <= i {
+			return nil, utils.NewValidationError("range", "cannot wrap empty range", "")
+		}
+		sdt := newContentControl(tag, alias, append([]interface{}{}, p.Content[i:j]...))
+		p.Content = append(p.Content[:i], append([]interface{}{sdt}, p.Content[j:]...)...)
+		return &ContentControl{doc: rg.doc, sdt: sdt}, nil
+	}
+	first, last := segs[0], segs[len(segs)-1]
+	_, lastText := paragraphPieces(last.p)
+	content, i := findParagraph(rg.story.blocks, first.p)
+	lastContent, j := findParagraph(rg.story.blocks, last.p)
+	if first.start != 0 || last.end != len(lastText) || content == nil || content != lastContent {
+		return nil, utils.NewValidationError("range", "must be within a paragraph or span whole paragraphs of one container", rg.Text())
+	}
+	sdt := newContentControl(tag, alias, append([]interface{}{}, (*content)[i:j+1]...))
+	*content = append((*content)[:i], append([]interface{}{sdt}, (*content)[j+1:]...)...)
+	return &ContentControl{doc: rg.doc, sdt: sdt}, nil
+}
+
+// segments returns the parts of the range in each of its paragraphs, or
+// nil when the range is no longer in its story.
+func (rg *Range) segments() []rangeSegment {
+	paras := storyParagraphs(*rg.story.blocks)
+	first, last := indexOfParagraph(paras, rg.start.p), indexOfParagraph(paras, rg.end.p)
+	if first < 0 || last < first {
+		return nil
+	}
+	var segs []rangeSegment
+	for i := first; i <= last; i++ {
+		_, text := paragraphPieces(paras[i])
+		seg := rangeSegment{p: paras[i], end: len(text)}
+		if i == first {
+			seg.start = minInt(rg.start.offset, len(text))
+		}
+		if i == last {
+			seg.end = minInt(rg.end.offset, len(text))
+		}
+		seg.start = minInt(seg.start, seg.end)
+		segs = append(segs, seg)
+	}
+	return segs
+}
+
+// resolve returns the point at pos in the story of the range.
+func (rg *Range) resolve(pos Position) (rangePoint, error) {
+	blocks := *rg.story.blocks
+	if pos.Element < 0 || pos.Element >= len(blocks) {
+		return rangePoint{}, utils.ErrInvalidIndex
+	}
+	paras := storyParagraphs(blocks[pos.Element : pos.Element+1])
+	if pos.Paragraph < 0 || pos.Paragraph >= len(paras) {
+		return rangePoint{}, utils.ErrInvalidIndex
+	}
+	p := paras[pos.Paragraph]
+	spans := paragraphRunSpans(p)
+	if pos.Run == len(spans) && pos.Offset == 0 {
+		_, text := paragraphPieces(p)
+		return rangePoint{p, len(text)}, nil
+	}
+	if pos.Run < 0 || pos.Run >= len(spans) || pos.Offset < 0 {
+		return rangePoint{}, utils.ErrInvalidIndex
+	}
+	span := spans[pos.Run]
+	n := 0
+	for i := range textFromRun(span.r) {
+		if n == pos.Offset {
+			return rangePoint{p, span.start + i}, nil
+		}
+		n++
+	}
+	if n == pos.Offset {
+		return rangePoint{p, span.end}, nil
+	}
+	return rangePoint{}, utils.ErrInvalidIndex
+}
+
+// position returns the position of pt in the story of the range.
+func (rg *Range) position(pt rangePoint) Position {
+	for i, elem := range *rg.story.blocks {
+		for j, p := range storyParagraphs([]interface{}{elem}) {
+			if p != pt.p {
+				continue
+			}
+			spans := paragraphRunSpans(p)
+			for k, span := range spans {
+				if pt.offset >= span.start && pt.offset < span.end {
+					text := textFromRun(span.r)[:pt.offset-span.start]
+					return Position{Element: i, Paragraph: j, Run: k, Offset: utf8.RuneCountInString(text)}
+				}
+			}
+			return Position{Element: i, Paragraph: j, Run: len(spans)}
+		}
+	}
+	return Position{}
+}
+
+// storyParagraphs returns the paragraphs of block content in document
+// order, including those of tables and content controls.
+func storyParagraphs(content []interface{}) []*wml.P {
+	var paras []*wml.P
+	for _, elem := range content {
+		switch v := elem.(type) {
+		case *wml.P:
+			paras = append(paras, v)
+		case *wml.Tbl:
+			for _, tr := range v.Tr {
+				for _, tc := range tr.Tc {
+					paras = append(paras, storyParagraphs(tc.Content)...)
+				}
+			}
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				paras = append(paras, storyParagraphs(v.SdtContent.Content)...)
+			}
+		}
+	}
+	return paras
+}
+
+func indexOfParagraph(paras []*wml.P, p *wml.P) int {
+	for i, para := range paras {
+		if para == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// findParagraph returns the block content holding p and the index of p in
+// it, looking in tables and content controls.
+func findParagraph(content *[]interface{}, p *wml.P) (*[]interface{}, int) {
+	for i, elem := range *content {
+		switch v := elem.(type) {
+		case *wml.P:
+			if v == p {
+				return content, i
+			}
+		case *wml.Tbl:
+			for _, tr := range v.Tr {
+				for _, tc := range tr.Tc {
+					if found, j := findParagraph(&tc.Content, p); found != nil {
+						return found, j
+					}
+				}
+			}
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				if found, j := findParagraph(&v.SdtContent.Content, p); found != nil {
+					return found, j
+				}
+			}
+		}
+	}
+	return nil, -1
+}
+
+// paragraphRunSpans returns the runs of p in the order of walkParagraphRuns
+// with the offsets of their text.
+func paragraphRunSpans(p *wml.P) []runSpan {
+	var (
+		spans  []runSpan
+		offset int
+	)
+	walkParagraphRuns(p, func(r *wml.R, _ *[]interface{}, _ interface{}) {
+		start := offset
+		for _, elem := range r.Content {
+			offset += len(pieceText(elem))
+		}
+		spans = append(spans, runSpan{r: r, start: start, end: offset})
+	})
+	return spans
+}
+
+// splitAt splits the run of p holding byte offset offset of its text, so
+// that the offset falls between runs.
+func splitAt(p *wml.P, offset int) {
+	pieces, _ := paragraphPieces(p)
+	for _, pc := range pieces {
+		if pc.end > offset {
+			splitRun(pc, offset-pc.start)
+			return
+		}
+	}
+}
+
+// boundary returns where content inserted at byte offset offset of the
+// text of p goes, splitting the run there: before the run that follows, or
+// with after set, after the run that precedes. ref is the piece next to
+// that place, or nil when p has no text.
+func boundary(p *wml.P, offset int, after bool) (content *[]interface{}, index int, ref *textPiece) {
+	splitAt(p, offset)
+	pieces, _ := paragraphPieces(p)
+	var prev, next *textPiece
+	for _, pc := range pieces {
+		if pc.end <= offset {
+			prev = pc
+		} else if next == nil {
+			next = pc
+		}
+	}
+	if prev != nil && (after || next == nil) {
+		return prev.content, indexOfElement(*prev.content, prev.run) + 1, prev
+	}
+	if next != nil {
+		return next.content, indexOfElement(*next.content, next.run), next
+	}
+	return &p.Content, len(p.Content), nil
+}
+
+// markerIndex is boundary for elements that belong directly in the
+// paragraph, such as bookmarks: a place inside a hyperlink, insertion or
+// content control moves to the edge of that element.
+func markerIndex(p *wml.P, offset int, after bool) int {
+	content, index, ref := boundary(p, offset, after)
+	if content == &p.Content || ref == nil {
+		return index
+	}
+	past := index > indexOfElement(*content, ref.run)
+	for i, elem := range p.Content {
+		if containsRun(elem, ref.run) {
+			if past {
+				return i + 1
+			}
+			return i
+		}
+	}
+	return index
+}
+
+// containsRun reports whether elem is r or holds it.
+func containsRun(elem interface{}, r *wml.R) bool {
+	var content []interface{}
+	switch v := elem.(type) {
+	case *wml.R:
+		return v == r
+	case *wml.Ins:
+		content = v.Content
+	case *wml.Hyperlink:
+		content = v.Content
+	case *wml.FldSimple:
+		content = v.Content
+	case *wml.Sdt:
+		if v.SdtContent != nil {
+			content = v.SdtContent.Content
+		}
+	}
+	for _, c := range content {
+		if containsRun(c, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func isInsertion(owner interface{}) bool {
+	_, ok := owner.(*wml.Ins)
+	return ok
+}
+
+func insertElements(content *[]interface{}, index int, elems ...interface{}) {
+	*content = append((*content)[:index], append(elems, (*content)[index:]...)...)
+}
@@ -63,6 +63,8 @@ func textFromInlineContent(content []interface{}) string {
 			}
 		case *wml.Hyperlink:
 			sb.WriteString(textFromInlineContent(v.Content))
+		case *wml.FldSimple:
+			sb.WriteString(textFromInlineContent(v.Content))
 		case *wml.Sdt:
 			sb.WriteString(textFromSdt(v))
 		}
@@ -0,0 +1,347 @@
+// Package document provides table of contents generation.
+package document
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/wml"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// tocNoEntries is the result Word shows for a TOC field without headings.
+const tocNoEntries = "No table of contents entries found."
+
+// Layout figures for estimating page numbers, in twips.
+const (
+	tocLineHeight     = 276 // one line of 11pt text
+	tocCharWidth      = 110 // an average 11pt character
+	tocParaSpacing    = 160 // space after a paragraph
+	tocHeadingSpacing = 240 // extra space before a heading
+	tocRowPadding     = 60  // cell margins of a table row
+)
+
+// TOCOptions configures Body.InsertTableOfContents.
+type TOCOptions struct {
+	// Index is the body element index the table of contents is inserted
+	// at, from 0 (the start of the body) to ElementCount() (the end).
+	Index int
+	// MinLevel and MaxLevel bound the heading levels listed. Zero means
+	// levels 1 to 3.
+	MinLevel int
+	MaxLevel int
+	// Title, if set, is added above the entries in a TOCHeading paragraph.
+	Title string
+	// EstimatePageNumbers fills in page numbers estimated from the length
+	// of the text and explicit page breaks. Otherwise the page numbers are
+	// left empty and marked for update.
+	EstimatePageNumbers bool
+	// UpdateFieldsOnOpen sets updateFields in the document settings, so
+	// that Word offers to update the fields, and with them the page
+	// numbers, when the document is opened.
+	UpdateFieldsOnOpen bool
+}
+
+// tocEntry is a heading listed in a table of contents.
+type tocEntry struct {
+	heading  *wml.P
+	level    int
+	text     string
+	bookmark string
+	link     *wml.Hyperlink
+}
+
+// InsertTableOfContents inserts a TOC field listing the body headings
+// (see Paragraph.HeadingLevel) and fills in its cached result, so that the
+// table of contents shows without updating fields. Each heading gets a
+// _Toc bookmark, unless it already has one, and each entry is a TOCn
+// paragraph that links to it. Missing TOCn and TOCHeading styles are
+// added. opts may be nil.
+func (b *bodyImpl) InsertTableOfContents(opts *TOCOptions) (*Field, error) {
+	if opts == nil {
+		opts = &TOCOptions{}
+	}
+	minLevel, maxLevel := opts.MinLevel, opts.MaxLevel
+	if minLevel == 0 {
+		minLevel = 1
+	}
+	if maxLevel == 0 {
+		maxLevel = 3
+	}
+	if minLevel < 1 || maxLevel > 9 || minLevel > maxLevel {
+		return nil, utils.NewValidationError("levels", "must be between 1 and 9, with MinLevel not above MaxLevel", fmt.Sprintf("%d-%d", minLevel, maxLevel))
+	}
+	if b.doc == nil {
+		return nil, utils.ErrDocumentClosed
+	}
+	body := b.body()
+	if opts.Index < 0 || opts.Index > len(body.Content) {
+		return nil, utils.ErrInvalidIndex
+	}
+
+	names := make(map[string]bool)
+	for name := range b.doc.scanStory(&body.Content).bookmarks {
+		names[name] = true
+	}
+	var entries []*tocEntry
+	for _, elem := range body.Content {
+		p, ok := elem.(*wml.P)
+		if !ok {
+			continue
+		}
+		para := &paragraphImpl{doc: b.doc, p: p}
+		level := para.HeadingLevel()
+		text := strings.TrimSpace(para.Text())
+		if level < minLevel || level > maxLevel || text == "" {
+			continue
+		}
+		entries = append(entries, &tocEntry{heading: p, level: level, text: text, bookmark: b.doc.tocBookmark(p, names)})
+	}
+
+	begin := &wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharBegin}}}
+	instr := &wml.R{Content: []interface{}{wml.NewInstrText(fmt.Sprintf(`TOC \o "%d-%d" \h \z \u`, minLevel, maxLevel))}}
+	sep := &wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharSeparate}}}
+	end := &wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharEnd}}}
+
+	var block []interface{}
+	if opts.Title != "" {
+		b.doc.ensureTOCStyle(0)
+		block = append(block, &wml.P{
+			PPr:     &wml.PPr{PStyle: &wml.PStyle{Val: "TOCHeading"}},
+			Content: []interface{}{&wml.R{Content: []interface{}{wml.NewT(opts.Title)}}},
+		})
+	}
+	if len(entries) == 0 {
+		block = append(block, &wml.P{Content: []interface{}{
+			begin, instr, sep, &wml.R{Content: []interface{}{wml.NewT(tocNoEntries)}}, end,
+		}})
+	}
+	tabPos := b.textWidth()
+	history := true
+	for i, entry := range entries {
+		b.doc.ensureTOCStyle(entry.level)
+		p := &wml.P{PPr: &wml.PPr{
+			PStyle: &wml.PStyle{Val: "TOC" + strconv.Itoa(entry.level)},
+			Tabs:   &wml.Tabs{Tab: []*wml.TabStop{{Val: "right", Leader: "dot", Pos: tabPos}}},
+		}}
+		if i == 0 {
+			p.Content = append(p.Content, begin, instr, sep)
+		}
+		entry.link = &wml.Hyperlink{Anchor: entry.bookmark, History: &history, Content: []interface{}{
+			&wml.R{Content: []interface{}{wml.NewT(entry.text)}},
+			&wml.R{Content: []interface{}{&wml.Tab{}}},
+		}}
+		p.Content = append(p.Content, entry.link)
+		if i == len(entries)-1 {
+			p.Content = append(p.Content, end)
+		}
+		block = append(block, p)
+	}
+	body.Content = append(body.Content[:opts.Index], append(block, body.Content[opts.Index:]...)...)
+
+	// Page numbers are estimated with the entries in place, as they take
+	// room ahead of the headings they list.
+	var pages map[*wml.P]int
+	if opts.EstimatePageNumbers {
+		pages = b.estimatePages()
+	}
+	for _, entry := range entries {
+		entry.link.Content = append(entry.link.Content, tocPageRef(entry.bookmark, pages[entry.heading])...)
+	}
+
+	if opts.UpdateFieldsOnOpen {
+		if b.doc.settings == nil {
+			b.doc.settings = &wml.Settings{}
+		}
+		b.doc.settings.UpdateFields = wml.NewOnOffEnabled()
+	}
+
+	var field *Field
+	for _, f := range b.doc.scanStory(&body.Content).fields {
+		if f.begin != nil && f.begin.r == begin {
+			field = f
+			break
+		}
+	}
+	return field, nil
+}
+
+// tocBookmark returns the name of the _Toc bookmark of a heading, adding
+// one around the whole paragraph if it has none. names holds the
+// lower-cased bookmark names in use.
+func (d *documentImpl) tocBookmark(p *wml.P, names map[string]bool) string {
+	for _, elem := range p.Content {
+		if start, ok := elem.(*wml.BookmarkStart); ok && strings.HasPrefix(start.Name, "_Toc") {
+			return start.Name
+		}
+	}
+	var name string
+	for n := len(names) + 1; ; n++ {
+		name = fmt.Sprintf("_Toc%09d", n)
+		if !names[strings.ToLower(name)] {
+			break
+		}
+	}
+	names[strings.ToLower(name)] = true
+
+	id := d.nextBookmarkID
+	d.nextBookmarkID++
+	content := make([]interface{}, 0, len(p.Content)+2)
+	content = append(content, &wml.BookmarkStart{ID: id, Name: name})
+	content = append(content, p.Content...)
+	p.Content = append(content, &wml.BookmarkEnd{ID: id})
+	return name
+}
+
+// tocPageRef returns the runs of the PAGEREF field of an entry. A page of
+// zero leaves the result empty and marks the field for update.
+func tocPageRef(bookmark string, page int) []interface{} {
+	begin := &wml.FldChar{FldCharType: wml.FldCharBegin}
+	runs := []interface{}{
+		&wml.R{Content: []interface{}{begin}},
+		&wml.R{Content: []interface{}{wml.NewInstrText(` PAGEREF ` + bookmark + ` \h `)}},
+		&wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharSeparate}}},
+	}
+	if page > 0 {
+		runs = append(runs, &wml.R{Content: []interface{}{wml.NewT(strconv.Itoa(page))}})
+	} else {
+		dirty := true
+		begin.Dirty = &dirty
+	}
+	return append(runs, &wml.R{Content: []interface{}{&wml.FldChar{FldCharType: wml.FldCharEnd}}})
+}
+
+// ensureTOCStyle adds the TOCn paragraph style for a level, or the
+// TOCHeading style for level 0, unless the document already has it.
+func (d *documentImpl) ensureTOCStyle(level int) {
+	styles := d.Styles()
+	id, name := "TOCHeading", "TOC Heading"
+	if level > 0 {
+		id, name = "TOC"+strconv.Itoa(level), "toc "+strconv.Itoa(level)
+	}
+	if styles.ByID(id) != nil {
+		return
+	}
+	style := styles.AddParagraphStyle(id, name)
+	s, ok := style.(*styleImpl)
+	if !ok {
+		return
+	}
+	s.SetUIPriority(39)
+	s.style.UnhideWhenUsed = wml.NewOnOffEnabled()
+	if styles.ByID("Normal") != nil {
+		s.SetNext("Normal")
+	}
+	if level == 0 {
+		if styles.ByID("Heading1") != nil {
+			s.SetBasedOn("Heading1")
+		} else {
+			s.SetBold(true)
+			s.SetFontSize(16)
+		}
+		s.SetQFormat(true)
+		// Keep the title itself out of tables of contents.
+		s.style.PPr = &wml.PPr{OutlineLvl: &wml.OutlineLvl{Val: 9}}
+		return
+	}
+	if styles.ByID("Normal") != nil {
+		s.SetBasedOn("Normal")
+	}
+	s.SetSpacingAfter(100)
+	if level > 1 {
+		indent := int64(level-1) * 220
+		s.style.PPr.Ind = &wml.Ind{Left: &indent}
+	}
+}
+
+// textWidth returns the width between the page margins of the last
+// section, in twips.
+func (b *bodyImpl) textWidth() int64 {
+	if s := b.body().SectPr; s != nil && s.PgSz != nil && s.PgMar != nil {
+		if w := s.PgSz.W - s.PgMar.Left - s.PgMar.Right; w > 0 {
+			return w
+		}
+	}
+	return 9360 // Letter with 1" margins
+}
+
+// estimatePages returns the page each body paragraph is estimated to start
+// on, from the length of its text and the explicit page breaks.
+func (b *bodyImpl) estimatePages() map[*wml.P]int {
+	body := b.body()
+	pageHeight := int64(12960) // Letter with 1" margins
+	if s := body.SectPr; s != nil && s.PgSz != nil && s.PgMar != nil {
+		if h := s.PgSz.H - s.PgMar.Top - s.PgMar.Bottom; h > 0 {
+			pageHeight = h
+		}
+	}
+	charsPerLine := b.textWidth() / tocCharWidth
+	if charsPerLine < 1 {
+		charsPerLine = 1
+	}
+
+	pages := make(map[*wml.P]int)
+	page, used := 1, int64(0)
+	place := func(height int64) {
+		if used > 0 && used+height > pageHeight {
+			page++
+			used = 0
+		}
+		used += height
+	}
+	for _, elem := range body.Content {
+		switch v := elem.(type) {
+		case *wml.P:
+			para := &paragraphImpl{doc: b.doc, p: v}
+			if para.PageBreakBefore() && used > 0 {
+				page++
+				used = 0
+			}
+			var lines int64
+			for _, line := range strings.Split(para.Text(), "\n") {
+				n := int64(len([]rune(line)))
+				lines += (n + charsPerLine - 1) / charsPerLine
+				if n == 0 {
+					lines++
+				}
+			}
+			height := lines*tocLineHeight + tocParaSpacing
+			if para.HeadingLevel() > 0 {
+				height += tocHeadingSpacing
+			}
+			place(height)
+			pages[v] = page
+			if breaks := countPageBreaks(v.Content); breaks > 0 {
+				page += breaks
+				used = 0
+			}
+		case *wml.Tbl:
+			for range v.Tr {
+				place(tocLineHeight + tocRowPadding)
+			}
+			place(tocParaSpacing)
+		}
+	}
+	return pages
+}
+
+// countPageBreaks returns the number of page breaks in paragraph content.
+func countPageBreaks(content []interface{}) int {
+	count := 0
+	for _, elem := range content {
+		switch v := elem.(type) {
+		case *wml.R:
+			for _, rc := range v.Content {
+				if br, ok := rc.(*wml.Br); ok && br.Type == "page" {
+					count++
+				}
+			}
+		case *wml.Hyperlink:
+			count += countPageBreaks(v.Content)
+		case *wml.Ins:
+			count += countPageBreaks(v.Content)
+		}
+	}
+	return count
+}
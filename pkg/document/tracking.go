@@ -96,9 +96,30 @@ func (r *revisionImpl) Text() string {
 	return ""
 }
 
-// Location returns the revision location placeholder.
-func (r *revisionImpl) Location() RevisionLocation {
-	return RevisionLocation{}
+// Location returns the range of the revision in the body: the inserted
+// text of an insertion, or the collapsed range where deleted text was. It
+// returns nil when the revision is no longer in the document.
+func (r *revisionImpl) Location() *Range {
+	if r.paragraph == nil || r.doc.document == nil || r.doc.document.Body == nil {
+		return nil
+	}
+	p := r.paragraph.p
+	var elem interface{} = r.del
+	if r.ins != nil {
+		elem = r.ins
+	}
+	content, ok := contentBefore(p.Content, elem)
+	if !ok {
+		return nil
+	}
+	_, before := paragraphPieces(&wml.P{Content: content})
+	start := rangePoint{p, len(before)}
+	end := start
+	if r.ins != nil {
+		_, inserted := paragraphPieces(&wml.P{Content: []interface{}{r.ins}})
+		end.offset += len(inserted)
+	}
+	return &Range{doc: r.doc, story: storyRef{StoryBody, &r.doc.document.Body.Content}, start: start, end: end}
 }
 
 // Accept accepts this revision, making the change permanent.
@@ -235,9 +256,15 @@ func (p *paragraphImpl) DeleteTrackedText(runIndex int) error {
 // =============================================================================
 
 func (p *paragraphImpl) revisions() []Revision {
+	return p.inlineRevisions(p.p.Content)
+}
+
+// inlineRevisions returns the revisions in inline content, including those
+// inside hyperlinks, simple fields and content controls.
+func (p *paragraphImpl) inlineRevisions(content []interface{}) []Revision {
 	var revisions []Revision
 	
-	for _, elem := range p.p.Content {
+	for _, elem := range content {
 		switch v := elem.(type) {
 		case *wml.Ins:
 			rev := &revisionImpl{
@@ -266,12 +293,81 @@ func (p *paragraphImpl) revisions() []Revision {
 				rev.date, _ = time.Parse(time.RFC3339, v.Date)
 			}
 			revisions = append(revisions, rev)
+
+		case *wml.Hyperlink:
+			revisions = append(revisions, p.inlineRevisions(v.Content)...)
+		case *wml.FldSimple:
+			revisions = append(revisions, p.inlineRevisions(v.Content)...)
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				revisions = append(revisions, p.inlineRevisions(v.SdtContent.Content)...)
+			}
 		}
 	}
 	
 	return revisions
 }
 
+// findInline returns the inline content holding elem and the index of elem
+// in it, looking in the containers walkParagraphRuns descends into.
+func findInline(content *[]interface{}, elem interface{}) (*[]interface{}, int) {
+	for i, e := range *content {
+		if e == elem {
+			return content, i
+		}
+		var inner *[]interface{}
+		switch v := e.(type) {
+		case *wml.Ins:
+			inner = &v.Content
+		case *wml.Hyperlink:
+			inner = &v.Content
+		case *wml.FldSimple:
+			inner = &v.Content
+		case *wml.Sdt:
+			if v.SdtContent != nil {
+				inner = &v.SdtContent.Content
+			}
+		}
+		if inner != nil {
+			if found, j := findInline(inner, elem); found != nil {
+				return found, j
+			}
+		}
+	}
+	return nil, -1
+}
+
+// contentBefore returns the inline elements that precede elem in document
+// order, flattened across the containers findInline looks in, and whether
+// elem was found.
+func contentBefore(content []interface{}, elem interface{}) ([]interface{}, bool) {
+	for i, e := range content {
+		if e == elem {
+			return content[:i:i], true
+		}
+		var inner []interface{}
+		switch v := e.(type) {
+		case *wml.Ins:
+			inner = v.Content
+		case *wml.Hyperlink:
+			inner = v.Content
+		case *wml.FldSimple:
+			inner = v.Content
+		case *wml.Sdt:
+			if v.SdtContent == nil {
+				continue
+			}
+			inner = v.SdtContent.Content
+		default:
+			continue
+		}
+		if before, ok := contentBefore(inner, elem); ok {
+			return append(content[:i:i], before...), true
+		}
+	}
+	return nil, false
+}
+
 func revisionsFromTable(doc *documentImpl, tbl *wml.Tbl) []Revision {
 	var revisions []Revision
 	for _, row := range tbl.Tr {
@@ -289,67 +385,57 @@ func revisionsFromTable(doc *documentImpl, tbl *wml.Tbl) []Revision {
 
 func (p *paragraphImpl) acceptInsertion(ins *wml.Ins) error {
 	// Find and replace the ins with its content
-	for i, elem := range p.p.Content {
-		if elem == ins {
-			// Remove the ins and insert the runs in its place
-			newContent := make([]interface{}, 0, len(p.p.Content)-1+len(ins.Content))
-			newContent = append(newContent, p.p.Content[:i]...)
-			for _, c := range ins.Content {
-				newContent = append(newContent, c)
-			}
-			newContent = append(newContent, p.p.Content[i+1:]...)
-			p.p.Content = newContent
-			return nil
-		}
+	content, i := findInline(&p.p.Content, ins)
+	if content == nil {
+		return nil
 	}
+	// Remove the ins and insert the runs in its place
+	newContent := make([]interface{}, 0, len(*content)-1+len(ins.Content))
+	newContent = append(newContent, (*content)[:i]...)
+	newContent = append(newContent, ins.Content...)
+	newContent = append(newContent, (*content)[i+1:]...)
+	*content = newContent
 	return nil
 }
 
 func (p *paragraphImpl) acceptDeletion(del *wml.Del) error {
 	// Remove the del element entirely
-	for i, elem := range p.p.Content {
-		if elem == del {
-			p.p.Content = append(p.p.Content[:i], p.p.Content[i+1:]...)
-			return nil
-		}
+	if content, i := findInline(&p.p.Content, del); content != nil {
+		*content = append((*content)[:i], (*content)[i+1:]...)
 	}
 	return nil
 }
 
 func (p *paragraphImpl) rejectInsertion(ins *wml.Ins) error {
 	// Remove the ins element entirely
-	for i, elem := range p.p.Content {
-		if elem == ins {
-			p.p.Content = append(p.p.Content[:i], p.p.Content[i+1:]...)
-			return nil
-		}
+	if content, i := findInline(&p.p.Content, ins); content != nil {
+		*content = append((*content)[:i], (*content)[i+1:]...)
 	}
 	return nil
 }
 
 func (p *paragraphImpl) rejectDeletion(del *wml.Del) error {
 	// Convert del back to normal runs with T instead of DelText
-	for i, elem := range p.p.Content {
-		if elem == del {
-			newContent := make([]interface{}, 0, len(p.p.Content)-1+len(del.Content))
-			newContent = append(newContent, p.p.Content[:i]...)
-			
-			for _, c := range del.Content {
-				if r, ok := c.(*wml.R); ok {
-					newRun := &wml.R{RPr: r.RPr}
-					for _, runElem := range r.Content {
-						if dt, ok := runElem.(*wml.DelText); ok {
-							newRun.Content = append(newRun.Content, wml.NewT(dt.Text))
-						}
-					}
-					newContent = append(newContent, newRun)
+	content, i := findInline(&p.p.Content, del)
+	if content == nil {
+		return nil
+	}
+	newContent := make([]interface{}, 0, len(*content)-1+len(del.Content))
+	newContent = append(newContent, (*content)[:i]...)
+	
+	for _, c := range del.Content {
+		if r, ok := c.(*wml.R); ok {
+			newRun := &wml.R{RPr: r.RPr}
+			for _, runElem := range r.Content {
+				if dt, ok := runElem.(*wml.DelText); ok {
+					newRun.Content = append(newRun.Content, wml.NewT(dt.Text))
 				}
 			}
-			
-			newContent = append(newContent, p.p.Content[i+1:]...)
-			p.p.Content = newContent
-			return nil
+			newContent = append(newContent, newRun)
 		}
 	}
+	
+	newContent = append(newContent, (*content)[i+1:]...)
+	*content = newContent
 	return nil
 }
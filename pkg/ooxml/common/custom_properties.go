@@ -0,0 +1,228 @@
+package common
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// Namespaces for custom properties.
+const (
+	NSCustomProperties = "http://schemas.openxmlformats.org/officeDocument/2006/custom-properties"
+	NSDocPropsVTypes   = "http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"
+)
+
+// CustomPropertyFmtID is the format ID Office uses for user-defined properties.
+const CustomPropertyFmtID = "{D5CDD505-2E9C-101B-9397-08002B2CF9AE}"
+
+// Custom property value types (vt: element names).
+const (
+	VTString   = "lpwstr"
+	VTInt      = "i4"
+	VTFloat    = "r8"
+	VTBool     = "bool"
+	VTFiletime = "filetime"
+)
+
+// CustomProperties represents custom document properties (docProps/custom.xml).
+type CustomProperties struct {
+	XMLName    xml.Name          `xml:"http://schemas.openxmlformats.org/officeDocument/2006/custom-properties Properties"`
+	Properties []*CustomProperty `xml:"property"`
+}
+
+// CustomProperty represents a single named custom property.
+type CustomProperty struct {
+	FmtID      string  `xml:"fmtid,attr"`
+	PID        int     `xml:"pid,attr"`
+	Name       string  `xml:"name,attr"`
+	LinkTarget string  `xml:"linkTarget,attr,omitempty"`
+	Value      VTValue `xml:",any"`
+}
+
+// VTValue is a vt: typed value. Type is the element name (see VTString etc.).
+type VTValue struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+// NewCustomProperties creates an empty CustomProperties.
+func NewCustomProperties() *CustomProperties {
+	return &CustomProperties{}
+}
+
+// Get returns the property with the given name. Names are case-insensitive,
+// as in Office.
+func (c *CustomProperties) Get(name string) *CustomProperty {
+	for _, prop := range c.Properties {
+		if strings.EqualFold(prop.Name, name) {
+			return prop
+		}
+	}
+	return nil
+}
+
+// Names returns the property names in document order.
+func (c *CustomProperties) Names() []string {
+	names := make([]string, len(c.Properties))
+	for i, prop := range c.Properties {
+		names[i] = prop.Name
+	}
+	return names
+}
+
+// Set adds or replaces a property. value may be a string, bool, time.Time,
+// int, int32 or int64 (stored as vt:i4, so it must fit in 32 bits), or a float.
+func (c *CustomProperties) Set(name string, value interface{}) error {
+	if strings.TrimSpace(name) == "" {
+		return utils.NewValidationError("name", "cannot be empty", nil)
+	}
+	v, err := NewVTValue(value)
+	if err != nil {
+		return err
+	}
+	if prop := c.Get(name); prop != nil {
+		prop.Value = v
+		prop.LinkTarget = ""
+		return nil
+	}
+	c.Properties = append(c.Properties, &CustomProperty{
+		FmtID: CustomPropertyFmtID,
+		PID:   c.nextPID(),
+		Name:  name,
+		Value: v,
+	})
+	return nil
+}
+
+// Delete removes the named property and reports whether it existed.
+func (c *CustomProperties) Delete(name string) bool {
+	for i, prop := range c.Properties {
+		if strings.EqualFold(prop.Name, name) {
+			c.Properties = append(c.Properties[:i], c.Properties[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// nextPID returns the next free property ID; IDs start at 2.
+func (c *CustomProperties) nextPID() int {
+	pid := 1
+	for _, prop := range c.Properties {
+		if prop.PID > pid {
+			pid = prop.PID
+		}
+	}
+	return pid + 1
+}
+
+// NewVTValue converts a Go value to its vt: representation.
+func NewVTValue(value interface{}) (VTValue, error) {
+	typed := func(vt, text string) (VTValue, error) {
+		return VTValue{XMLName: xml.Name{Space: NSDocPropsVTypes, Local: vt}, Text: text}, nil
+	}
+	switch v := value.(type) {
+	case string:
+		return typed(VTString, v)
+	case bool:
+		return typed(VTBool, strconv.FormatBool(v))
+	case time.Time:
+		return typed(VTFiletime, v.UTC().Format(time.RFC3339))
+	case int:
+		return newVTInt(int64(v))
+	case int32:
+		return newVTInt(int64(v))
+	case int64:
+		return newVTInt(v)
+	case float32:
+		return typed(VTFloat, strconv.FormatFloat(float64(v), 'g', -1, 32))
+	case float64:
+		return typed(VTFloat, strconv.FormatFloat(v, 'g', -1, 64))
+	default:
+		return VTValue{}, utils.NewValidationError("value", "unsupported custom property type", fmt.Sprintf("%T", value))
+	}
+}
+
+func newVTInt(v int64) (VTValue, error) {
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		return VTValue{}, utils.NewValidationError("value", "integer does not fit in vt:i4", v)
+	}
+	return VTValue{XMLName: xml.Name{Space: NSDocPropsVTypes, Local: VTInt}, Text: strconv.FormatInt(v, 10)}, nil
+}
+
+// Type returns the vt: type name of the value (e.g. "lpwstr", "i4").
+func (p *CustomProperty) Type() string {
+	return p.Value.XMLName.Local
+}
+
+// String returns the value as text, regardless of its type.
+func (p *CustomProperty) String() string {
+	return p.Value.Text
+}
+
+// Int returns an integer value (vt:i1 .. vt:ui8, vt:int, vt:uint).
+func (p *CustomProperty) Int() (int64, error) {
+	switch p.Type() {
+	case "i1", "i2", "i4", "i8", "int", "ui1", "ui2", "ui4", "ui8", "uint":
+		return strconv.ParseInt(strings.TrimSpace(p.Value.Text), 10, 64)
+	}
+	return 0, p.typeError("integer")
+}
+
+// Float returns a floating-point value (vt:r4, vt:r8, vt:decimal).
+func (p *CustomProperty) Float() (float64, error) {
+	switch p.Type() {
+	case "r4", "r8", "decimal":
+		return strconv.ParseFloat(strings.TrimSpace(p.Value.Text), 64)
+	}
+	return 0, p.typeError("float")
+}
+
+// Bool returns a vt:bool value.
+func (p *CustomProperty) Bool() (bool, error) {
+	if p.Type() != VTBool {
+		return false, p.typeError("bool")
+	}
+	switch strings.TrimSpace(p.Value.Text) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("custom property %q: invalid bool %q", p.Name, p.Value.Text)
+}
+
+// Time returns a vt:filetime or vt:date value.
+func (p *CustomProperty) Time() (time.Time, error) {
+	if p.Type() != VTFiletime && p.Type() != "date" {
+		return time.Time{}, p.typeError("time")
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(p.Value.Text))
+}
+
+// Interface returns the value as string, int64, float64, bool or time.Time,
+// falling back to the raw text for other vt: types.
+func (p *CustomProperty) Interface() interface{} {
+	if v, err := p.Int(); err == nil {
+		return v
+	}
+	if v, err := p.Float(); err == nil {
+		return v
+	}
+	if v, err := p.Bool(); err == nil {
+		return v
+	}
+	if v, err := p.Time(); err == nil {
+		return v
+	}
+	return p.Value.Text
+}
+
+func (p *CustomProperty) typeError(want string) error {
+	return fmt.Errorf("custom property %q has type vt:%s, not %s", p.Name, p.Type(), want)
+}
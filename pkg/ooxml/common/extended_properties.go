@@ -0,0 +1,203 @@
+package common
+
+import "encoding/xml"
+
+// ExtendedProperties represents document extended properties (docProps/app.xml).
+type ExtendedProperties struct {
+	XMLName              xml.Name       `xml:"http://schemas.openxmlformats.org/officeDocument/2006/extended-properties Properties"`
+	XMLNSVT              string         `xml:"xmlns:vt,attr,omitempty"`
+	Template             string         `xml:"Template,omitempty"`
+	Manager              string         `xml:"Manager,omitempty"`
+	Company              string         `xml:"Company,omitempty"`
+	TotalTime            int            `xml:"TotalTime,omitempty"`
+	Pages                int            `xml:"Pages,omitempty"`
+	Words                int            `xml:"Words,omitempty"`
+	Characters           int            `xml:"Characters,omitempty"`
+	CharactersWithSpaces int            `xml:"CharactersWithSpaces,omitempty"`
+	Lines                int            `xml:"Lines,omitempty"`
+	Paragraphs           int            `xml:"Paragraphs,omitempty"`
+	Slides               int            `xml:"Slides,omitempty"`
+	Notes                int            `xml:"Notes,omitempty"`
+	HiddenSlides         int            `xml:"HiddenSlides,omitempty"`
+	MMClips              int            `xml:"MMClips,omitempty"`
+	PresentationFormat   string         `xml:"PresentationFormat,omitempty"`
+	Application          string         `xml:"Application,omitempty"`
+	AppVersion           string         `xml:"AppVersion,omitempty"`
+	DocSecurity          int            `xml:"DocSecurity,omitempty"`
+	ScaleCrop            bool           `xml:"ScaleCrop,omitempty"`
+	HeadingPairs         *HeadingPairs  `xml:"HeadingPairs,omitempty"`
+	TitlesOfParts        *TitlesOfParts `xml:"TitlesOfParts,omitempty"`
+	LinksUpToDate        bool           `xml:"LinksUpToDate,omitempty"`
+	SharedDoc            bool           `xml:"SharedDoc,omitempty"`
+	HyperlinkBase        string         `xml:"HyperlinkBase,omitempty"`
+	HyperlinksChanged    bool           `xml:"HyperlinksChanged,omitempty"`
+	// Other preserves elements without a typed field (HLinks, DigSig, ...).
+	Other []RawElement `xml:",any"`
+}
+
+// RawElement preserves an unmodelled XML element verbatim.
+type RawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// HeadingPairs represents heading pairs.
+type HeadingPairs struct {
+	Vector *Vector `xml:"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes vector"`
+}
+
+// TitlesOfParts represents titles of parts.
+type TitlesOfParts struct {
+	Vector *Vector `xml:"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes vector"`
+}
+
+// Vector represents a vector of values.
+type Vector struct {
+	Size     int        `xml:"size,attr"`
+	BaseType string     `xml:"baseType,attr"`
+	Variant  []*Variant `xml:"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes variant,omitempty"`
+	Lpstr    []string   `xml:"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes lpstr,omitempty"`
+}
+
+// Variant represents a variant value.
+type Variant struct {
+	Lpstr string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes lpstr,omitempty"`
+	I4    int    `xml:"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes i4,omitempty"`
+
+	held string // "lpstr" or "i4" when read from XML, so zero values survive
+}
+
+// variantXML is the wire form of Variant, telling absent values from zero ones.
+type variantXML struct {
+	Lpstr *string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes lpstr,omitempty"`
+	I4    *int    `xml:"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes i4,omitempty"`
+}
+
+// UnmarshalXML implements custom XML unmarshaling for Variant, recording
+// which value it holds.
+func (v *Variant) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw variantXML
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+	*v = Variant{}
+	switch {
+	case raw.Lpstr != nil:
+		v.Lpstr, v.held = *raw.Lpstr, "lpstr"
+	case raw.I4 != nil:
+		v.I4, v.held = *raw.I4, "i4"
+	}
+	return nil
+}
+
+// MarshalXML implements custom XML marshaling for Variant. It writes the
+// value read from XML, otherwise Lpstr when set and I4 (even zero) when not.
+func (v Variant) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	var raw variantXML
+	if v.isLpstr() {
+		raw.Lpstr = &v.Lpstr
+	} else {
+		raw.I4 = &v.I4
+	}
+	return e.EncodeElement(raw, start)
+}
+
+func (v *Variant) isLpstr() bool {
+	if v.held != "" {
+		return v.held == "lpstr"
+	}
+	return v.Lpstr != ""
+}
+
+// NewExtendedProperties creates an empty ExtendedProperties.
+func NewExtendedProperties() *ExtendedProperties {
+	return &ExtendedProperties{XMLNSVT: NSDocPropsVTypes}
+}
+
+// partGroup is one HeadingPairs entry with its slice of TitlesOfParts.
+type partGroup struct {
+	heading string
+	titles  []string
+}
+
+// PartTitles returns the TitlesOfParts entries counted under heading
+// (e.g. "Worksheets" or "Slide Titles").
+func (e *ExtendedProperties) PartTitles(heading string) []string {
+	for _, g := range e.partGroups() {
+		if g.heading == heading {
+			return g.titles
+		}
+	}
+	return nil
+}
+
+// SetPartTitles replaces the titles counted under heading, keeping the other
+// HeadingPairs groups. Passing no titles removes the group.
+func (e *ExtendedProperties) SetPartTitles(heading string, titles []string) {
+	groups := e.partGroups()
+	found := false
+	for i := range groups {
+		if groups[i].heading == heading {
+			groups[i].titles = titles
+			found = true
+		}
+	}
+	if !found {
+		groups = append(groups, partGroup{heading: heading, titles: titles})
+	}
+
+	pairs := &Vector{BaseType: "variant"}
+	parts := &Vector{BaseType: "lpstr"}
+	for _, g := range groups {
+		if len(g.titles) == 0 {
+			continue
+		}
+		pairs.Variant = append(pairs.Variant, &Variant{Lpstr: g.heading}, &Variant{I4: len(g.titles)})
+		parts.Lpstr = append(parts.Lpstr, g.titles...)
+	}
+	if len(pairs.Variant) == 0 {
+		e.HeadingPairs = nil
+		e.TitlesOfParts = nil
+		return
+	}
+	pairs.Size = len(pairs.Variant)
+	parts.Size = len(parts.Lpstr)
+	e.HeadingPairs = &HeadingPairs{Vector: pairs}
+	e.TitlesOfParts = &TitlesOfParts{Vector: parts}
+	if e.XMLNSVT == "" {
+		e.XMLNSVT = NSDocPropsVTypes
+	}
+}
+
+func (e *ExtendedProperties) partGroups() []partGroup {
+	if e.HeadingPairs == nil || e.HeadingPairs.Vector == nil {
+		return nil
+	}
+	var titles []string
+	if e.TitlesOfParts != nil && e.TitlesOfParts.Vector != nil {
+		titles = append(titles, e.TitlesOfParts.Vector.Lpstr...)
+		for _, v := range e.TitlesOfParts.Vector.Variant {
+			if v.isLpstr() {
+				titles = append(titles, v.Lpstr)
+			}
+		}
+	}
+	var groups []partGroup
+	variants := e.HeadingPairs.Vector.Variant
+	for i := 0; i+1 < len(variants); i += 2 {
+		if !variants[i].isLpstr() || variants[i+1].isLpstr() {
+			continue
+		}
+		count := variants[i+1].I4
+		if count > len(titles) {
+			count = len(titles)
+		}
+		if count < 0 {
+			count = 0
+		}
+		groups = append(groups, partGroup{heading: variants[i].Lpstr, titles: titles[:count:count]})
+		titles = titles[count:]
+	}
+	return groups
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestVariant_RoundTrip(t *testing.T) {
+	vector := &Vector{BaseType: "variant", Variant: []*Variant{
+		{Lpstr: "Worksheets"},
+		{I4: 0},
+		{I4: 3},
+	}}
+	data, err := xml.Marshal(vector)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, want := range []string{">Worksheets</lpstr>", ">0</i4>", ">3</i4>"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Marshal() = %s, missing %q", data, want)
+		}
+	}
+
+	var got Vector
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(got.Variant) != 3 || got.Variant[0].Lpstr != "Worksheets" || got.Variant[1].I4 != 0 || got.Variant[2].I4 != 3 {
+		t.Fatalf("Unmarshal() = %+v", got.Variant)
+	}
+	// A zero i4 read from XML stays an i4.
+	data, err = xml.Marshal(got.Variant[1])
+	if err != nil || !strings.Contains(string(data), ">0</i4>") {
+		t.Errorf("Marshal(i4 0) = %s, %v", data, err)
+	}
+	// So does an empty lpstr.
+	var empty Variant
+	if err := xml.Unmarshal([]byte(`<variant xmlns:vt="`+NSDocPropsVTypes+`"><vt:lpstr></vt:lpstr></variant>`), &empty); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := xml.Marshal(&empty); err != nil || !strings.Contains(string(data), "lpstr") {
+		t.Errorf("Marshal(empty lpstr) = %s, %v", data, err)
+	}
+}
@@ -7,3 +7,65 @@ type Drawing struct {
 	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main drawing"`
 	Inner   string   `xml:",innerxml"`
 }
+
+// TxbxContent represents the content of a text box. Drawings keep their
+// XML as is, so it is decoded on demand from the w:txbxContent element.
+type TxbxContent struct {
+	XMLName xml.Name      `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main txbxContent"`
+	Content []interface{} `xml:"-"` // Paragraphs, tables, etc.
+}
+
+// UnmarshalXML implements custom XML unmarshaling for TxbxContent.
+func (c *TxbxContent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				p := &P{}
+				if err := d.DecodeElement(p, &t); err != nil {
+					return err
+				}
+				c.Content = append(c.Content, p)
+			case "tbl":
+				tbl := &Tbl{}
+				if err := d.DecodeElement(tbl, &t); err != nil {
+					return err
+				}
+				c.Content = append(c.Content, tbl)
+			case "sdt":
+				sdt := &Sdt{}
+				if err := d.DecodeElement(sdt, &t); err != nil {
+					return err
+				}
+				c.Content = append(c.Content, sdt)
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if t.Name == start.Name {
+				return nil
+			}
+		}
+	}
+}
+
+// MarshalXML implements custom XML marshaling for TxbxContent.
+func (c *TxbxContent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: NS, Local: "txbxContent"}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, elem := range c.Content {
+		if err := e.Encode(elem); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
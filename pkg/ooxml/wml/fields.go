@@ -32,3 +32,94 @@ func NewInstrText(text string) *InstrText {
 	}
 	return it
 }
+
+// FldSimple represents a simple field, whose instruction is stored in an
+// attribute and whose result runs are its children.
+type FldSimple struct {
+	XMLName xml.Name      `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main fldSimple"`
+	Instr   string        `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main instr,attr"`
+	FldLock *bool         `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main fldLock,attr,omitempty"`
+	Dirty   *bool         `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main dirty,attr,omitempty"`
+	Content []interface{} `xml:"-"` // result runs
+}
+
+// UnmarshalXML implements custom XML unmarshaling for FldSimple.
+func (f *FldSimple) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	f.XMLName = start.Name
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "instr":
+			f.Instr = attr.Value
+		case "fldLock":
+			val := attr.Value == "1" || attr.Value == "true" || attr.Value == "on"
+			f.FldLock = &val
+		case "dirty":
+			val := attr.Value == "1" || attr.Value == "true" || attr.Value == "on"
+			f.Dirty = &val
+		}
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "r":
+				r := &R{}
+				if err := d.DecodeElement(r, &t); err != nil {
+					return err
+				}
+				f.Content = append(f.Content, r)
+			case "ins":
+				ins := &Ins{}
+				if err := d.DecodeElement(ins, &t); err != nil {
+					return err
+				}
+				f.Content = append(f.Content, ins)
+			case "del":
+				del := &Del{}
+				if err := d.DecodeElement(del, &t); err != nil {
+					return err
+				}
+				f.Content = append(f.Content, del)
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if t.Name == start.Name {
+				return nil
+			}
+		}
+	}
+}
+
+// MarshalXML implements custom XML marshaling for FldSimple.
+func (f *FldSimple) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: NS, Local: "fldSimple"}
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Space: NS, Local: "instr"}, Value: f.Instr})
+	for _, flag := range []struct {
+		name string
+		val  *bool
+	}{{"fldLock", f.FldLock}, {"dirty", f.Dirty}} {
+		if flag.val != nil {
+			val := "0"
+			if *flag.val {
+				val = "1"
+			}
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Space: NS, Local: flag.name}, Value: val})
+		}
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, elem := range f.Content {
+		if err := e.Encode(elem); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
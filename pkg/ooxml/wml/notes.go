@@ -0,0 +1,136 @@
+package wml
+
+import (
+	"encoding/xml"
+	"strconv"
+)
+
+// Note types. Separator notes hold the lines drawn between the text and the
+// notes; they are not referenced from the text.
+const (
+	NoteTypeNormal                = "normal"
+	NoteTypeSeparator             = "separator"
+	NoteTypeContinuationSeparator = "continuationSeparator"
+	NoteTypeContinuationNotice    = "continuationNotice"
+)
+
+// Footnotes represents the footnotes part.
+type Footnotes struct {
+	XMLName  xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main footnotes"`
+	Footnote []*Note  `xml:"footnote,omitempty"`
+}
+
+// Endnotes represents the endnotes part.
+type Endnotes struct {
+	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main endnotes"`
+	Endnote []*Note  `xml:"endnote,omitempty"`
+}
+
+// Note represents a footnote or an endnote; the element name comes from the
+// Footnotes or Endnotes field that holds it.
+type Note struct {
+	Type    string        `xml:"-"` // empty for normal notes
+	ID      int           `xml:"-"`
+	Content []interface{} `xml:"-"` // Paragraphs, tables, etc.
+}
+
+// UnmarshalXML implements custom XML unmarshaling for Note.
+func (n *Note) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "type":
+			n.Type = attr.Value
+		case "id":
+			n.ID, _ = strconv.Atoi(attr.Value)
+		}
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				p := &P{}
+				if err := d.DecodeElement(p, &t); err != nil {
+					return err
+				}
+				n.Content = append(n.Content, p)
+			case "tbl":
+				tbl := &Tbl{}
+				if err := d.DecodeElement(tbl, &t); err != nil {
+					return err
+				}
+				n.Content = append(n.Content, tbl)
+			case "sdt":
+				sdt := &Sdt{}
+				if err := d.DecodeElement(sdt, &t); err != nil {
+					return err
+				}
+				n.Content = append(n.Content, sdt)
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if t.Name == start.Name {
+				return nil
+			}
+		}
+	}
+}
+
+// MarshalXML implements custom XML marshaling for Note.
+func (n *Note) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: NS, Local: start.Name.Local}
+	start.Attr = nil
+	if n.Type != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Space: NS, Local: "type"}, Value: n.Type})
+	}
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Space: NS, Local: "id"}, Value: strconv.Itoa(n.ID)})
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, elem := range n.Content {
+		if err := e.Encode(elem); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// FootnoteReference references a footnote from the text.
+type FootnoteReference struct {
+	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main footnoteReference"`
+	ID      int      `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main id,attr"`
+}
+
+// EndnoteReference references an endnote from the text.
+type EndnoteReference struct {
+	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main endnoteReference"`
+	ID      int      `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main id,attr"`
+}
+
+// FootnoteRef marks where a footnote shows its number.
+type FootnoteRef struct {
+	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main footnoteRef"`
+}
+
+// EndnoteRef marks where an endnote shows its number.
+type EndnoteRef struct {
+	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main endnoteRef"`
+}
+
+// Separator represents the separator line of a separator note.
+type Separator struct {
+	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main separator"`
+}
+
+// ContinuationSeparator represents the separator line of a continuation
+// separator note.
+type ContinuationSeparator struct {
+	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main continuationSeparator"`
+}
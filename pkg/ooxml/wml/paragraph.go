@@ -109,6 +109,12 @@ func (p *P) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return err
 				}
 				p.Content = append(p.Content, h)
+			case "fldSimple":
+				f := &FldSimple{}
+				if err := d.DecodeElement(f, &t); err != nil {
+					return err
+				}
+				p.Content = append(p.Content, f)
 			case "sdt":
 				sdt := &Sdt{}
 				if err := d.DecodeElement(sdt, &t); err != nil {
@@ -203,6 +209,18 @@ func (h *Hyperlink) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return err
 				}
 				h.Content = append(h.Content, r)
+			case "ins":
+				ins := &Ins{}
+				if err := d.DecodeElement(ins, &t); err != nil {
+					return err
+				}
+				h.Content = append(h.Content, ins)
+			case "del":
+				del := &Del{}
+				if err := d.DecodeElement(del, &t); err != nil {
+					return err
+				}
+				h.Content = append(h.Content, del)
 			default:
 				if err := d.Skip(); err != nil {
 					return err
@@ -299,6 +317,18 @@ func (c *SdtContent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 					return err
 				}
 				c.Content = append(c.Content, r)
+			case "ins":
+				ins := &Ins{}
+				if err := d.DecodeElement(ins, &t); err != nil {
+					return err
+				}
+				c.Content = append(c.Content, ins)
+			case "del":
+				del := &Del{}
+				if err := d.DecodeElement(del, &t); err != nil {
+					return err
+				}
+				c.Content = append(c.Content, del)
 			case "hyperlink":
 				h := &Hyperlink{}
 				if err := d.DecodeElement(h, &t); err != nil {
@@ -415,12 +445,14 @@ type PPr struct {
 	KeepLines  *OnOff      `xml:"keepLines,omitempty"`
 	PageBreakBefore *OnOff `xml:"pageBreakBefore,omitempty"`
 	WidowControl *OnOff    `xml:"widowControl,omitempty"`
+	Tabs       *Tabs       `xml:"tabs,omitempty"`
 	Spacing    *Spacing    `xml:"spacing,omitempty"`
 	Ind        *Ind        `xml:"ind,omitempty"`
 	Jc         *Jc         `xml:"jc,omitempty"`
 	RPr        *RPr        `xml:"rPr,omitempty"`
 	NumPr      *NumPr      `xml:"numPr,omitempty"`
 	OutlineLvl *OutlineLvl `xml:"outlineLvl,omitempty"`
+	SectPr     *SectPr     `xml:"sectPr,omitempty"` // section break after the paragraph
 }
 
 // PStyle references a paragraph style.
@@ -433,6 +465,18 @@ type OnOff struct {
 	Val *bool `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main val,attr,omitempty"`
 }
 
+// Tabs holds the custom tab stops of a paragraph.
+type Tabs struct {
+	Tab []*TabStop `xml:"tab"`
+}
+
+// TabStop represents a custom tab stop.
+type TabStop struct {
+	Val    string `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main val,attr"`
+	Leader string `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main leader,attr,omitempty"`
+	Pos    int64  `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main pos,attr"`
+}
+
 // Spacing represents paragraph spacing.
 type Spacing struct {
 	Before   *int64  `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main before,attr,omitempty"`
@@ -79,6 +79,44 @@ func (r *R) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return err
 				}
 				r.Content = append(r.Content, drawing)
+			case "footnoteReference":
+				ref := &FootnoteReference{}
+				if err := d.DecodeElement(ref, &t); err != nil {
+					return err
+				}
+				r.Content = append(r.Content, ref)
+			case "endnoteReference":
+				ref := &EndnoteReference{}
+				if err := d.DecodeElement(ref, &t); err != nil {
+					return err
+				}
+				r.Content = append(r.Content, ref)
+			case "commentReference":
+				ref := &CommentReference{}
+				if err := d.DecodeElement(ref, &t); err != nil {
+					return err
+				}
+				r.Content = append(r.Content, ref)
+			case "footnoteRef":
+				r.Content = append(r.Content, &FootnoteRef{})
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case "endnoteRef":
+				r.Content = append(r.Content, &EndnoteRef{})
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case "separator":
+				r.Content = append(r.Content, &Separator{})
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case "continuationSeparator":
+				r.Content = append(r.Content, &ContinuationSeparator{})
+				if err := d.Skip(); err != nil {
+					return err
+				}
 			default:
 				if err := d.Skip(); err != nil {
 					return err
@@ -9,6 +9,7 @@ type Settings struct {
 	TrackRevisions     *OnOff              `xml:"trackRevisions,omitempty"`
 	DefaultTabStop     *DefaultTabStop     `xml:"defaultTabStop,omitempty"`
 	CharacterSpacingControl *CharacterSpacingControl `xml:"characterSpacingControl,omitempty"`
+	UpdateFields       *OnOff              `xml:"updateFields,omitempty"`
 	Compat             *Compat             `xml:"compat,omitempty"`
 	Rsids              *Rsids              `xml:"rsids,omitempty"`
 }
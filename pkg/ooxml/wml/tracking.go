@@ -70,6 +70,13 @@ func (ins *Ins) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return err
 				}
 				ins.Content = append(ins.Content, r)
+			case "del":
+				// Inserted text that was deleted again.
+				del := &Del{}
+				if err := d.DecodeElement(del, &t); err != nil {
+					return err
+				}
+				ins.Content = append(ins.Content, del)
 			default:
 				if err := d.Skip(); err != nil {
 					return err
@@ -212,6 +212,37 @@ func TestParagraph_Unmarshal_WithInsertions(t *testing.T) {
 	}
 }
 
+func TestParagraph_Unmarshal_WithFldSimple(t *testing.T) {
+	xmlData := `<w:p xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:fldSimple w:instr=" AUTHOR \* Upper " w:fldLock="1"><w:r><w:t>JANE</w:t></w:r></w:fldSimple>
+</w:p>`
+
+	var p P
+	if err := xml.Unmarshal([]byte(xmlData), &p); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if len(p.Content) != 1 {
+		t.Fatalf("expected 1 element, got %d", len(p.Content))
+	}
+	fld, ok := p.Content[0].(*FldSimple)
+	if !ok {
+		t.Fatalf("expected *FldSimple, got %T", p.Content[0])
+	}
+	if fld.Instr != ` AUTHOR \* Upper ` || fld.FldLock == nil || !*fld.FldLock || len(fld.Content) != 1 {
+		t.Errorf("FldSimple = %+v", fld)
+	}
+
+	out, err := xml.Marshal(&p)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	for _, want := range []string{`fldSimple`, `instr=" AUTHOR \* Upper "`, `fldLock="1"`, `JANE`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("marshaled paragraph %s does not contain %s", out, want)
+		}
+	}
+}
+
 func TestParagraph_Unmarshal_WithDeletions(t *testing.T) {
 	xmlData := `<w:p xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
   <w:del w:id="2" w:author="Editor" w:date="2024-01-02T00:00:00Z">
@@ -0,0 +1,368 @@
+package packaging
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Canonicalization algorithms supported by the signature code.
+const (
+	AlgorithmC14N             = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	AlgorithmC14NComments     = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315#WithComments"
+	AlgorithmExcC14N          = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	AlgorithmExcC14NComments  = "http://www.w3.org/2001/10/xml-exc-c14n#WithComments"
+	AlgorithmRelationshipXfrm = "http://schemas.openxmlformats.org/package/2006/RelationshipTransform"
+)
+
+const nsXML = "http://www.w3.org/XML/1998/namespace"
+
+// xmlNode is a minimal DOM used for canonicalization. Names keep their raw
+// prefixes so the original serialization can be reproduced.
+type xmlNode struct {
+	name     xml.Name // Space holds the prefix
+	attrs    []xml.Attr
+	children []interface{} // *xmlNode, xml.CharData, xml.ProcInst, xml.Comment
+	parent   *xmlNode
+}
+
+// parseXMLTree parses data into a node tree and returns the document element.
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	var root, current *xmlNode
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name, attrs: append([]xml.Attr(nil), t.Attr...), parent: current}
+			if current == nil {
+				if root != nil {
+					return nil, xml.UnmarshalError("multiple document elements")
+				}
+				root = node
+			} else {
+				current.children = append(current.children, node)
+			}
+			current = node
+		case xml.EndElement:
+			if current == nil {
+				return nil, xml.UnmarshalError("unexpected end element")
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			}
+		case xml.Comment:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			}
+		case xml.ProcInst:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			}
+		}
+	}
+	if root == nil {
+		return nil, xml.UnmarshalError("no document element")
+	}
+	return root, nil
+}
+
+// findByID returns the first element carrying an Id (or ID/id) attribute with the given value.
+func (n *xmlNode) findByID(id string) *xmlNode {
+	for _, a := range n.attrs {
+		if a.Name.Space == "" && (a.Name.Local == "Id" || a.Name.Local == "ID" || a.Name.Local == "id") && a.Value == id {
+			return n
+		}
+	}
+	for _, child := range n.children {
+		if c, ok := child.(*xmlNode); ok {
+			if found := c.findByID(id); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// findChild returns the first child element with the given local name.
+func (n *xmlNode) findChild(local string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for _, child := range n.children {
+		if c, ok := child.(*xmlNode); ok && c.name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+// childrenNamed returns the child elements with the given local name.
+func (n *xmlNode) childrenNamed(local string) []*xmlNode {
+	if n == nil {
+		return nil
+	}
+	var out []*xmlNode
+	for _, child := range n.children {
+		if c, ok := child.(*xmlNode); ok && c.name.Local == local {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// attr returns the value of the unprefixed attribute with the given name.
+func (n *xmlNode) attr(local string) string {
+	if n == nil {
+		return ""
+	}
+	for _, a := range n.attrs {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// text returns the concatenated character data of the element's descendants.
+func (n *xmlNode) text() string {
+	if n == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, child := range n.children {
+		switch v := child.(type) {
+		case xml.CharData:
+			sb.Write(v)
+		case *xmlNode:
+			sb.WriteString(v.text())
+		}
+	}
+	return sb.String()
+}
+
+// declarations returns the namespace declarations made on this element.
+func (n *xmlNode) declarations() map[string]string {
+	decls := make(map[string]string)
+	for _, a := range n.attrs {
+		if a.Name.Space == "xmlns" {
+			decls[a.Name.Local] = a.Value
+		} else if a.Name.Space == "" && a.Name.Local == "xmlns" {
+			decls[""] = a.Value
+		}
+	}
+	return decls
+}
+
+// inScope returns all namespace bindings visible at this element.
+func (n *xmlNode) inScope() map[string]string {
+	var chain []*xmlNode
+	for cur := n; cur != nil; cur = cur.parent {
+		chain = append(chain, cur)
+	}
+	ns := map[string]string{"": ""}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for prefix, uri := range chain[i].declarations() {
+			ns[prefix] = uri
+		}
+	}
+	return ns
+}
+
+// canonicalize serializes the subtree rooted at n using the given
+// canonicalization algorithm. inclusivePrefixes applies to exclusive C14N only.
+func canonicalize(n *xmlNode, algorithm string, inclusivePrefixes []string) ([]byte, error) {
+	c := &canonicalizer{}
+	switch algorithm {
+	case AlgorithmC14N, "":
+	case AlgorithmC14NComments:
+		c.comments = true
+	case AlgorithmExcC14N:
+		c.exclusive = true
+	case AlgorithmExcC14NComments:
+		c.exclusive = true
+		c.comments = true
+	default:
+		return nil, errUnsupportedAlgorithm(algorithm)
+	}
+	c.inclusive = make(map[string]bool)
+	for _, prefix := range inclusivePrefixes {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		c.inclusive[prefix] = true
+	}
+	c.writeElement(n, map[string]string{"": ""}, true)
+	return c.buf.Bytes(), nil
+}
+
+type canonicalizer struct {
+	buf       bytes.Buffer
+	exclusive bool
+	comments  bool
+	inclusive map[string]bool
+}
+
+func (c *canonicalizer) writeElement(n *xmlNode, rendered map[string]string, apex bool) {
+	ns := n.inScope()
+
+	// Namespace declarations to emit
+	var prefixes []string
+	if c.exclusive {
+		used := map[string]bool{n.name.Space: true}
+		for _, a := range n.attrs {
+			if a.Name.Space != "" && a.Name.Space != "xmlns" && a.Name.Space != "xml" {
+				used[a.Name.Space] = true
+			}
+		}
+		for prefix := range c.inclusive {
+			if _, ok := ns[prefix]; ok {
+				used[prefix] = true
+			}
+		}
+		for prefix := range used {
+			if prefix == "xml" {
+				continue
+			}
+			uri, ok := ns[prefix]
+			if !ok {
+				continue
+			}
+			if rendered[prefix] != uri {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	} else {
+		for prefix, uri := range ns {
+			if prefix == "xml" {
+				continue
+			}
+			if rendered[prefix] != uri {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+	sort.Strings(prefixes)
+
+	next := make(map[string]string, len(rendered)+len(prefixes))
+	for k, v := range rendered {
+		next[k] = v
+	}
+
+	c.buf.WriteByte('<')
+	c.buf.WriteString(qualifiedName(n.name))
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			c.buf.WriteString(` xmlns="`)
+		} else {
+			c.buf.WriteString(` xmlns:` + prefix + `="`)
+		}
+		c.buf.WriteString(escapeC14NAttr(ns[prefix]))
+		c.buf.WriteByte('"')
+		next[prefix] = ns[prefix]
+	}
+
+	type attr struct {
+		uri   string
+		local string
+		raw   xml.Attr
+	}
+	var attrs []attr
+	seen := make(map[string]bool)
+	for _, a := range n.attrs {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		uri := ""
+		if a.Name.Space == "xml" {
+			uri = nsXML
+			seen[a.Name.Local] = true
+		} else if a.Name.Space != "" {
+			uri = ns[a.Name.Space]
+		}
+		attrs = append(attrs, attr{uri: uri, local: a.Name.Local, raw: a})
+	}
+	// Inclusive C14N of a document subset inherits xml:* attributes.
+	if apex && !c.exclusive {
+		for cur := n.parent; cur != nil; cur = cur.parent {
+			for _, a := range cur.attrs {
+				if a.Name.Space == "xml" && !seen[a.Name.Local] {
+					seen[a.Name.Local] = true
+					attrs = append(attrs, attr{uri: nsXML, local: a.Name.Local, raw: a})
+				}
+			}
+		}
+	}
+	sort.SliceStable(attrs, func(i, j int) bool {
+		if attrs[i].uri != attrs[j].uri {
+			return attrs[i].uri < attrs[j].uri
+		}
+		return attrs[i].local < attrs[j].local
+	})
+	for _, a := range attrs {
+		c.buf.WriteByte(' ')
+		c.buf.WriteString(qualifiedName(a.raw.Name))
+		c.buf.WriteString(`="`)
+		c.buf.WriteString(escapeC14NAttr(a.raw.Value))
+		c.buf.WriteByte('"')
+	}
+	c.buf.WriteByte('>')
+
+	for _, child := range n.children {
+		switch v := child.(type) {
+		case *xmlNode:
+			c.writeElement(v, next, false)
+		case xml.CharData:
+			c.buf.WriteString(escapeC14NText(string(v)))
+		case xml.Comment:
+			if c.comments {
+				c.buf.WriteString("<!--")
+				c.buf.Write(v)
+				c.buf.WriteString("-->")
+			}
+		case xml.ProcInst:
+			c.buf.WriteString("<?" + v.Target)
+			if len(v.Inst) > 0 {
+				c.buf.WriteByte(' ')
+				c.buf.Write(v.Inst)
+			}
+			c.buf.WriteString("?>")
+		}
+	}
+
+	c.buf.WriteString("</" + qualifiedName(n.name) + ">")
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+var (
+	c14nTextReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	c14nAttrReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeC14NText(s string) string {
+	return c14nTextReplacer.Replace(s)
+}
+
+func escapeC14NAttr(s string) string {
+	return c14nAttrReplacer.Replace(s)
+}
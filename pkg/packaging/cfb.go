@@ -0,0 +1,604 @@
+package packaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Compound File Binary (MS-CFB) support. Encrypted OOXML packages are stored
+// as an OLE compound file holding the EncryptionInfo and EncryptedPackage streams.
+
+const (
+	cfbFreeSect   uint32 = 0xFFFFFFFF
+	cfbEndOfChain uint32 = 0xFFFFFFFE
+	cfbFATSect    uint32 = 0xFFFFFFFD
+	cfbDIFSect    uint32 = 0xFFFFFFFC
+	cfbMaxRegSect uint32 = 0xFFFFFFFA
+	cfbNoStream   uint32 = 0xFFFFFFFF
+
+	cfbTypeStorage byte = 1
+	cfbTypeStream  byte = 2
+	cfbTypeRoot    byte = 5
+
+	cfbColorRed   byte = 0
+	cfbColorBlack byte = 1
+
+	cfbHeaderSize       = 512
+	cfbSectorSize       = 512 // version 3 files
+	cfbDirEntrySize     = 128
+	cfbMiniSectorSize   = 64
+	cfbMiniStreamCutoff = 4096
+	cfbHeaderDIFATCount = 109
+)
+
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+var errNotCompoundFile = errors.New("not a compound file")
+
+// isCompoundFile reports whether header starts with the CFB signature.
+func isCompoundFile(header []byte) bool {
+	return len(header) >= len(cfbSignature) && bytes.Equal(header[:len(cfbSignature)], cfbSignature)
+}
+
+// cfbEntry is a directory entry of a compound file.
+type cfbEntry struct {
+	name    string
+	objType byte
+	left    uint32
+	right   uint32
+	child   uint32
+	start   uint32
+	size    uint64
+}
+
+// cfbReader reads streams from an in-memory compound file.
+type cfbReader struct {
+	data       []byte
+	sectorSize int
+	fat        []uint32
+	miniFAT    []uint32
+	miniStream []byte
+	entries    []cfbEntry
+}
+
+// readCompoundFile parses the header, allocation tables and directory of data.
+func readCompoundFile(data []byte) (*cfbReader, error) {
+	if len(data) < cfbHeaderSize || !isCompoundFile(data) {
+		return nil, errNotCompoundFile
+	}
+	le := binary.LittleEndian
+	if le.Uint16(data[28:]) != 0xFFFE {
+		return nil, fmt.Errorf("compound file: invalid byte order mark")
+	}
+	major := le.Uint16(data[26:])
+	shift := le.Uint16(data[30:])
+	if !(major == 3 && shift == 9) && !(major == 4 && shift == 12) {
+		return nil, fmt.Errorf("compound file: unsupported version %d with sector shift %d", major, shift)
+	}
+	r := &cfbReader{data: data, sectorSize: 1 << shift}
+
+	numFAT := le.Uint32(data[44:])
+	firstDir := le.Uint32(data[48:])
+	firstMiniFAT := le.Uint32(data[60:])
+	firstDIFAT := le.Uint32(data[68:])
+	numDIFAT := le.Uint32(data[72:])
+
+	// A valid file never has more FAT or DIFAT sectors than sectors; checking
+	// this bounds the work a hostile header can ask for.
+	sectors := uint64(len(data) / r.sectorSize)
+	if uint64(numFAT) > sectors || uint64(numDIFAT) > sectors {
+		return nil, fmt.Errorf("compound file: %d FAT and %d DIFAT sectors exceed file size", numFAT, numDIFAT)
+	}
+
+	// Locate the FAT sectors through the header DIFAT and the DIFAT chain.
+	var fatSectors []uint32
+	for i := 0; i < cfbHeaderDIFATCount; i++ {
+		if sect := le.Uint32(data[76+4*i:]); sect <= cfbMaxRegSect {
+			fatSectors = append(fatSectors, sect)
+		}
+	}
+	perSector := r.sectorSize / 4
+	next := firstDIFAT
+	for i := uint32(0); i < numDIFAT && next <= cfbMaxRegSect; i++ {
+		sector, err := r.sector(next)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < perSector-1; j++ {
+			if sect := le.Uint32(sector[4*j:]); sect <= cfbMaxRegSect {
+				fatSectors = append(fatSectors, sect)
+			}
+		}
+		next = le.Uint32(sector[4*(perSector-1):])
+	}
+	if uint32(len(fatSectors)) > numFAT {
+		fatSectors = fatSectors[:numFAT]
+	}
+	r.fat = make([]uint32, 0, len(fatSectors)*perSector)
+	for _, sect := range fatSectors {
+		sector, err := r.sector(sect)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < perSector; j++ {
+			r.fat = append(r.fat, le.Uint32(sector[4*j:]))
+		}
+	}
+
+	// Directory
+	dir, err := r.readChain(firstDir, r.fat, r.sectorSize, len(r.data), r.sector)
+	if err != nil {
+		return nil, err
+	}
+	for off := 0; off+cfbDirEntrySize <= len(dir); off += cfbDirEntrySize {
+		r.entries = append(r.entries, parseCFBEntry(dir[off:off+cfbDirEntrySize], major))
+	}
+	if len(r.entries) == 0 || r.entries[0].objType != cfbTypeRoot {
+		return nil, fmt.Errorf("compound file: missing root entry")
+	}
+
+	// Mini FAT and mini stream
+	if firstMiniFAT <= cfbMaxRegSect {
+		miniFAT, err := r.readChain(firstMiniFAT, r.fat, r.sectorSize, len(r.data), r.sector)
+		if err != nil {
+			return nil, err
+		}
+		for off := 0; off+4 <= len(miniFAT); off += 4 {
+			r.miniFAT = append(r.miniFAT, le.Uint32(miniFAT[off:]))
+		}
+	}
+	root := r.entries[0]
+	if root.start <= cfbMaxRegSect && root.size > 0 {
+		mini, err := r.readChain(root.start, r.fat, r.sectorSize, len(r.data), r.sector)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(mini)) > root.size {
+			mini = mini[:root.size]
+		}
+		r.miniStream = mini
+	}
+	return r, nil
+}
+
+func parseCFBEntry(b []byte, major uint16) cfbEntry {
+	le := binary.LittleEndian
+	nameLen := int(le.Uint16(b[64:]))
+	if nameLen > 64 {
+		nameLen = 64
+	}
+	units := make([]uint16, 0, 32)
+	for i := 0; i+1 < nameLen; i += 2 {
+		if u := le.Uint16(b[i:]); u != 0 {
+			units = append(units, u)
+		}
+	}
+	size := le.Uint64(b[120:])
+	if major == 3 {
+		size &= 0xFFFFFFFF
+	}
+	return cfbEntry{
+		name:    string(utf16.Decode(units)),
+		objType: b[66],
+		left:    le.Uint32(b[68:]),
+		right:   le.Uint32(b[72:]),
+		child:   le.Uint32(b[76:]),
+		start:   le.Uint32(b[116:]),
+		size:    size,
+	}
+}
+
+// sector returns the regular sector with the given number.
+func (r *cfbReader) sector(n uint32) ([]byte, error) {
+	off := (int64(n) + 1) * int64(r.sectorSize)
+	if off >= int64(len(r.data)) {
+		return nil, fmt.Errorf("compound file: sector %d out of range", n)
+	}
+	end := off + int64(r.sectorSize)
+	if end > int64(len(r.data)) {
+		// Tolerate a truncated final sector.
+		padded := make([]byte, r.sectorSize)
+		copy(padded, r.data[off:])
+		return padded, nil
+	}
+	return r.data[off:end], nil
+}
+
+// miniSector returns the mini sector with the given number.
+func (r *cfbReader) miniSector(n uint32) ([]byte, error) {
+	off := int64(n) * cfbMiniSectorSize
+	if off+cfbMiniSectorSize > int64(len(r.miniStream)) {
+		return nil, fmt.Errorf("compound file: mini sector %d out of range", n)
+	}
+	return r.miniStream[off : off+cfbMiniSectorSize], nil
+}
+
+// readChain concatenates the sectors of an allocation chain. A chain can
+// visit each of the size-byte sectors held in total bytes at most once.
+func (r *cfbReader) readChain(start uint32, table []uint32, size, total int, read func(uint32) ([]byte, error)) ([]byte, error) {
+	var buf bytes.Buffer
+	maxSteps := total / size
+	for sect, steps := start, 0; sect != cfbEndOfChain; steps++ {
+		if sect > cfbMaxRegSect || int(sect) >= len(table) || steps > len(table) || steps > maxSteps {
+			return nil, fmt.Errorf("compound file: corrupt sector chain")
+		}
+		data, err := read(sect)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data[:size])
+		sect = table[sect]
+	}
+	return buf.Bytes(), nil
+}
+
+// find resolves a storage/stream path below the root entry.
+// Names are compared case-insensitively, as in MS-CFB.
+func (r *cfbReader) find(path ...string) (*cfbEntry, error) {
+	current := &r.entries[0]
+	for _, name := range path {
+		id, ok := r.findChild(current.child, name)
+		if !ok {
+			return nil, fmt.Errorf("compound file: %q not found", strings.Join(path, "/"))
+		}
+		current = &r.entries[id]
+	}
+	return current, nil
+}
+
+func (r *cfbReader) findChild(start uint32, name string) (uint32, bool) {
+	stack := []uint32{start}
+	visited := make(map[uint32]bool)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == cfbNoStream || int(id) >= len(r.entries) || visited[id] {
+			continue
+		}
+		visited[id] = true
+		entry := r.entries[id]
+		if strings.EqualFold(entry.name, name) {
+			return id, true
+		}
+		stack = append(stack, entry.left, entry.right)
+	}
+	return 0, false
+}
+
+// readStream returns the contents of the stream at path.
+func (r *cfbReader) readStream(path ...string) ([]byte, error) {
+	entry, err := r.find(path...)
+	if err != nil {
+		return nil, err
+	}
+	if entry.objType != cfbTypeStream {
+		return nil, fmt.Errorf("compound file: %q is not a stream", strings.Join(path, "/"))
+	}
+	if entry.size == 0 {
+		return []byte{}, nil
+	}
+	var data []byte
+	if entry.size < cfbMiniStreamCutoff {
+		data, err = r.readChain(entry.start, r.miniFAT, cfbMiniSectorSize, len(r.miniStream), r.miniSector)
+	} else {
+		data, err = r.readChain(entry.start, r.fat, r.sectorSize, len(r.data), r.sector)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) < entry.size {
+		return nil, fmt.Errorf("compound file: stream %q is truncated", strings.Join(path, "/"))
+	}
+	return data[:entry.size], nil
+}
+
+// cfbStream is a stream to be written into a compound file.
+type cfbStream struct {
+	path []string
+	data []byte
+}
+
+// cfbNode is a directory entry being built by writeCompoundFile.
+type cfbNode struct {
+	name     string
+	objType  byte
+	data     []byte
+	children []*cfbNode
+	id       uint32
+	left     uint32
+	right    uint32
+	child    uint32
+	color    byte
+	start    uint32
+	size     uint64
+}
+
+// writeCompoundFile writes a version 3 compound file containing streams.
+func writeCompoundFile(w io.Writer, streams []cfbStream) error {
+	root := &cfbNode{name: "Root Entry", objType: cfbTypeRoot}
+	for _, s := range streams {
+		parent := root
+		for i, name := range s.path {
+			var next *cfbNode
+			for _, c := range parent.children {
+				if strings.EqualFold(c.name, name) {
+					next = c
+					break
+				}
+			}
+			if next == nil {
+				next = &cfbNode{name: name, objType: cfbTypeStorage}
+				parent.children = append(parent.children, next)
+			}
+			if i == len(s.path)-1 {
+				next.objType = cfbTypeStream
+				next.data = s.data
+			}
+			parent = next
+		}
+	}
+
+	// Assign directory IDs and build the sibling trees.
+	var nodes []*cfbNode
+	var assign func(n *cfbNode)
+	assign = func(n *cfbNode) {
+		n.id = uint32(len(nodes))
+		nodes = append(nodes, n)
+		sort.Slice(n.children, func(i, j int) bool { return cfbLess(n.children[i].name, n.children[j].name) })
+		for _, c := range n.children {
+			assign(c)
+		}
+	}
+	assign(root)
+	for _, n := range nodes {
+		n.child = buildCFBTree(n.children)
+		if n.objType != cfbTypeStream {
+			n.start = cfbEndOfChain
+			if n.objType == cfbTypeStorage {
+				n.start = 0
+			}
+		}
+	}
+
+	// Lay out the mini stream and the regular streams.
+	var mini bytes.Buffer
+	var miniFAT []uint32
+	var fat []uint32
+	var body bytes.Buffer
+	allocate := func(data []byte) uint32 {
+		if len(data) == 0 {
+			return cfbEndOfChain
+		}
+		start := uint32(len(fat))
+		count := (len(data) + cfbSectorSize - 1) / cfbSectorSize
+		for i := 0; i < count; i++ {
+			fat = append(fat, uint32(len(fat))+1)
+		}
+		fat[len(fat)-1] = cfbEndOfChain
+		body.Write(data)
+		if pad := count*cfbSectorSize - len(data); pad > 0 {
+			body.Write(make([]byte, pad))
+		}
+		return start
+	}
+
+	for _, n := range nodes {
+		if n.objType != cfbTypeStream {
+			continue
+		}
+		n.size = uint64(len(n.data))
+		switch {
+		case len(n.data) == 0:
+			n.start = cfbEndOfChain
+		case len(n.data) < cfbMiniStreamCutoff:
+			n.start = uint32(len(miniFAT))
+			count := (len(n.data) + cfbMiniSectorSize - 1) / cfbMiniSectorSize
+			for i := 0; i < count; i++ {
+				miniFAT = append(miniFAT, uint32(len(miniFAT))+1)
+			}
+			miniFAT[len(miniFAT)-1] = cfbEndOfChain
+			mini.Write(n.data)
+			if pad := count*cfbMiniSectorSize - len(n.data); pad > 0 {
+				mini.Write(make([]byte, pad))
+			}
+		default:
+			n.start = allocate(n.data)
+		}
+	}
+	root.start = allocate(mini.Bytes())
+	root.size = uint64(mini.Len())
+
+	miniFATStart := cfbEndOfChain
+	if len(miniFAT) > 0 {
+		miniFATStart = allocate(uint32Bytes(miniFAT, cfbFreeSect, cfbSectorSize))
+	}
+	numMiniFAT := (len(miniFAT)*4 + cfbSectorSize - 1) / cfbSectorSize
+
+	var dir bytes.Buffer
+	for _, n := range nodes {
+		dir.Write(n.entryBytes())
+	}
+	for dir.Len()%cfbSectorSize != 0 {
+		dir.Write(emptyCFBEntry())
+	}
+	dirStart := allocate(dir.Bytes())
+
+	// Size the FAT and DIFAT so that they also describe their own sectors.
+	perSector := cfbSectorSize / 4
+	numFAT, numDIFAT := 0, 0
+	for {
+		total := len(fat) + numFAT + numDIFAT
+		needFAT := (total + perSector - 1) / perSector
+		needDIFAT := 0
+		if needFAT > cfbHeaderDIFATCount {
+			needDIFAT = (needFAT - cfbHeaderDIFATCount + perSector - 2) / (perSector - 1)
+		}
+		if needFAT == numFAT && needDIFAT == numDIFAT {
+			break
+		}
+		numFAT, numDIFAT = needFAT, needDIFAT
+	}
+	fatStart := uint32(len(fat))
+	for i := 0; i < numFAT; i++ {
+		fat = append(fat, cfbFATSect)
+	}
+	difatStart := uint32(len(fat))
+	for i := 0; i < numDIFAT; i++ {
+		fat = append(fat, cfbDIFSect)
+	}
+
+	// Header
+	le := binary.LittleEndian
+	header := make([]byte, cfbHeaderSize)
+	copy(header, cfbSignature)
+	le.PutUint16(header[24:], 0x003E)
+	le.PutUint16(header[26:], 3)
+	le.PutUint16(header[28:], 0xFFFE)
+	le.PutUint16(header[30:], 9)
+	le.PutUint16(header[32:], 6)
+	le.PutUint32(header[44:], uint32(numFAT))
+	le.PutUint32(header[48:], dirStart)
+	le.PutUint32(header[56:], cfbMiniStreamCutoff)
+	le.PutUint32(header[60:], miniFATStart)
+	le.PutUint32(header[64:], uint32(numMiniFAT))
+	if numDIFAT > 0 {
+		le.PutUint32(header[68:], difatStart)
+	} else {
+		le.PutUint32(header[68:], cfbEndOfChain)
+	}
+	le.PutUint32(header[72:], uint32(numDIFAT))
+	for i := 0; i < cfbHeaderDIFATCount; i++ {
+		sect := cfbFreeSect
+		if i < numFAT {
+			sect = fatStart + uint32(i)
+		}
+		le.PutUint32(header[76+4*i:], sect)
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(body.Bytes()); err != nil {
+		return err
+	}
+	if _, err := w.Write(uint32Bytes(fat, cfbFreeSect, cfbSectorSize)); err != nil {
+		return err
+	}
+	// DIFAT sectors list the FAT sectors beyond the first 109.
+	for i := 0; i < numDIFAT; i++ {
+		entries := make([]uint32, perSector)
+		for j := 0; j < perSector-1; j++ {
+			idx := cfbHeaderDIFATCount + i*(perSector-1) + j
+			entries[j] = cfbFreeSect
+			if idx < numFAT {
+				entries[j] = fatStart + uint32(idx)
+			}
+		}
+		entries[perSector-1] = cfbEndOfChain
+		if i < numDIFAT-1 {
+			entries[perSector-1] = difatStart + uint32(i) + 1
+		}
+		if _, err := w.Write(uint32Bytes(entries, cfbFreeSect, cfbSectorSize)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildCFBTree arranges sorted siblings as a balanced red-black tree and
+// returns the ID of its root.
+func buildCFBTree(siblings []*cfbNode) uint32 {
+	if len(siblings) == 0 {
+		return cfbNoStream
+	}
+	height := 0
+	for n := len(siblings); n > 0; n >>= 1 {
+		height++
+	}
+	perfect := len(siblings) == 1<<height-1
+	var build func(lo, hi, depth int) uint32
+	build = func(lo, hi, depth int) uint32 {
+		if lo > hi {
+			return cfbNoStream
+		}
+		mid := (lo + hi + 1) / 2
+		n := siblings[mid]
+		n.left = build(lo, mid-1, depth+1)
+		n.right = build(mid+1, hi, depth+1)
+		n.color = cfbColorBlack
+		if !perfect && depth == height-1 {
+			n.color = cfbColorRed
+		}
+		return n.id
+	}
+	return build(0, len(siblings)-1, 0)
+}
+
+// cfbLess orders directory names by length, then by upper-cased code points.
+func cfbLess(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	if len(ua) != len(ub) {
+		return len(ua) < len(ub)
+	}
+	for i := range ua {
+		ca, cb := unicode.ToUpper(rune(ua[i])), unicode.ToUpper(rune(ub[i]))
+		if ca != cb {
+			return ca < cb
+		}
+	}
+	return false
+}
+
+func (n *cfbNode) entryBytes() []byte {
+	le := binary.LittleEndian
+	b := make([]byte, cfbDirEntrySize)
+	units := utf16.Encode([]rune(n.name))
+	if len(units) > 31 {
+		units = units[:31]
+	}
+	for i, u := range units {
+		le.PutUint16(b[2*i:], u)
+	}
+	le.PutUint16(b[64:], uint16((len(units)+1)*2))
+	b[66] = n.objType
+	b[67] = n.color
+	le.PutUint32(b[68:], n.left)
+	le.PutUint32(b[72:], n.right)
+	le.PutUint32(b[76:], n.child)
+	le.PutUint32(b[116:], n.start)
+	le.PutUint64(b[120:], n.size)
+	return b
+}
+
+func emptyCFBEntry() []byte {
+	b := make([]byte, cfbDirEntrySize)
+	le := binary.LittleEndian
+	le.PutUint32(b[68:], cfbNoStream)
+	le.PutUint32(b[72:], cfbNoStream)
+	le.PutUint32(b[76:], cfbNoStream)
+	return b
+}
+
+// uint32Bytes serializes values, padding with fill to a multiple of blockSize.
+func uint32Bytes(values []uint32, fill uint32, blockSize int) []byte {
+	n := len(values) * 4
+	if rem := n % blockSize; rem != 0 {
+		n += blockSize - rem
+	}
+	b := make([]byte, n)
+	for i := 0; i < n/4; i++ {
+		v := fill
+		if i < len(values) {
+			v = values[i]
+		}
+		binary.LittleEndian.PutUint32(b[4*i:], v)
+	}
+	return b
+}
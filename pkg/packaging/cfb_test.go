@@ -0,0 +1,65 @@
+package packaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestCompoundFile_RoundTrip(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789abcdef"), 80000) // spans DIFAT sectors
+	streams := []cfbStream{
+		{path: []string{"Small"}, data: []byte("hello")},
+		{path: []string{"Large"}, data: large},
+		{path: []string{"Storage", "Nested", "Leaf"}, data: bytes.Repeat([]byte{7}, 5000)},
+		{path: []string{"Storage", "Sibling"}, data: []byte("mini stream")},
+		{path: []string{"Empty"}, data: nil},
+	}
+	for i := 0; i < 20; i++ {
+		streams = append(streams, cfbStream{path: []string{"Many", string(rune('A' + i))}, data: []byte{byte(i)}})
+	}
+
+	var buf bytes.Buffer
+	if err := writeCompoundFile(&buf, streams); err != nil {
+		t.Fatalf("writeCompoundFile() error = %v", err)
+	}
+	if !isCompoundFile(buf.Bytes()) {
+		t.Fatal("output does not start with the CFB signature")
+	}
+	cf, err := readCompoundFile(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readCompoundFile() error = %v", err)
+	}
+	for _, s := range streams {
+		got, err := cf.readStream(s.path...)
+		if err != nil {
+			t.Fatalf("readStream(%v) error = %v", s.path, err)
+		}
+		if !bytes.Equal(got, s.data) {
+			t.Errorf("readStream(%v) returned %d bytes, want %d", s.path, len(got), len(s.data))
+		}
+	}
+	if _, err := cf.readStream("storage", "SIBLING"); err != nil {
+		t.Errorf("case-insensitive lookup failed: %v", err)
+	}
+	if _, err := cf.readStream("Missing"); err == nil {
+		t.Error("readStream(Missing) should fail")
+	}
+	if _, err := cf.readStream("Storage"); err == nil {
+		t.Error("readStream(Storage) should fail for a storage")
+	}
+}
+
+func TestCompoundFile_RejectsOversizedHeaderCounts(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCompoundFile(&buf, []cfbStream{{path: []string{"Small"}, data: []byte("hello")}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, offset := range []int{44, 72} { // FAT and DIFAT sector counts
+		data := append([]byte(nil), buf.Bytes()...)
+		binary.LittleEndian.PutUint32(data[offset:], 0xFFFFFFF0)
+		if _, err := readCompoundFile(data); err == nil {
+			t.Errorf("readCompoundFile() with count 0xFFFFFFF0 at offset %d should fail", offset)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package packaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"hash/crc32"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// ParallelDeflateThreshold is the smallest part that SaveOptions.ParallelDeflate
+// compresses on its own goroutine. Smaller parts are not worth the overhead.
+const ParallelDeflateThreshold = 1 << 20
+
+// compressedMediaTypes are content types whose data is already compressed.
+var compressedMediaTypes = map[string]bool{
+	ContentTypePNG:            true,
+	ContentTypeJPEG:           true,
+	ContentTypeGIF:            true,
+	ContentTypeAudioMPEG:      true,
+	ContentTypeAudioMP4:       true,
+	ContentTypeVideoMP4:       true,
+	ContentTypeVideoQuickTime: true,
+	ContentTypeVideoAVI:       true,
+}
+
+// compressedMediaExtensions catch media declared with generic content types.
+var compressedMediaExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true,
+	".mp3": true, ".m4a": true, ".wma": true,
+	".mp4": true, ".m4v": true, ".mov": true, ".avi": true, ".wmv": true,
+}
+
+// isCompressedMedia reports whether an entry holds already-compressed media.
+func isCompressedMedia(name, contentType string) bool {
+	return compressedMediaTypes[contentType] || compressedMediaExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// validate checks the option values that can be out of range.
+func (opts *SaveOptions) validate() error {
+	if opts == nil {
+		return nil
+	}
+	if opts.CompressionLevel < flate.HuffmanOnly || opts.CompressionLevel > flate.BestCompression {
+		return utils.NewValidationError("compressionLevel", "must be between -2 and 9", opts.CompressionLevel)
+	}
+	return nil
+}
+
+// compressionLevel returns the flate level for deflated entries.
+func (opts *SaveOptions) compressionLevel() int {
+	if opts == nil || opts.CompressionLevel == 0 {
+		return flate.DefaultCompression
+	}
+	return opts.CompressionLevel
+}
+
+// method returns the ZIP compression method for an entry.
+func (opts *SaveOptions) method(name, contentType string) uint16 {
+	if opts != nil && opts.StoreMedia && isCompressedMedia(name, contentType) {
+		return zip.Store
+	}
+	return zip.Deflate
+}
+
+// canCopy reports whether an untouched ZIP entry can be copied as is rather
+// than recompressed under opts.
+func (opts *SaveOptions) canCopy(part *Part) bool {
+	if part.zipFile == nil || part.modified {
+		return false
+	}
+	if opts == nil {
+		return true
+	}
+	if opts.Deterministic || opts.compressionLevel() != flate.DefaultCompression {
+		return false
+	}
+	return opts.method(part.uri, part.contentType) == zip.Deflate || part.zipFile.Method == zip.Store
+}
+
+// deflatedEntry is an entry compressed ahead of writing.
+type deflatedEntry struct {
+	data  []byte
+	crc32 uint32
+	size  uint64
+}
+
+// header completes h for writing the entry with zip.Writer.CreateRaw.
+func (d *deflatedEntry) header(h *zip.FileHeader) *zip.FileHeader {
+	h.Method = zip.Deflate
+	h.CRC32 = d.crc32
+	h.CompressedSize64 = uint64(len(d.data))
+	h.UncompressedSize64 = d.size
+	return h
+}
+
+// deflateParallel compresses the named entries concurrently, at most one
+// per CPU. content must be safe to call for different names at once.
+func deflateParallel(names []string, content func(name string) ([]byte, error), level int) (map[string]*deflatedEntry, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	results := make(map[string]*deflatedEntry, len(names))
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for _, name := range names {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(name string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			data, err := content(name)
+			var entry *deflatedEntry
+			if err == nil {
+				entry, err = deflate(data, level)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			results[name] = entry
+		}(name)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// deflate compresses data as a raw deflate stream.
+func deflate(data []byte, level int) (*deflatedEntry, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+	return &deflatedEntry{data: buf.Bytes(), crc32: crc32.ChecksumIEEE(data), size: uint64(len(data))}, nil
+}
@@ -0,0 +1,112 @@
+package packaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// zipMethods returns the compression method of every entry in data.
+func zipMethods(t *testing.T, data []byte) map[string]uint16 {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods := make(map[string]uint16)
+	for _, f := range zr.File {
+		methods[f.Name] = f.Method
+	}
+	return methods
+}
+
+func TestSaveOptions_StoreMedia(t *testing.T) {
+	pkg := newFSTestPackage()
+	_, _ = pkg.AddPart("word/media/clip.bin", "video/mp4", bytes.Repeat([]byte("frame"), 100))
+	var src bytes.Buffer
+	if err := pkg.WriteTo(&src); err != nil {
+		t.Fatal(err)
+	}
+	if m := zipMethods(t, src.Bytes()); m["word/media/image1.png"] != zip.Deflate {
+		t.Fatalf("default save stored media: %v", m)
+	}
+
+	// Untouched media of an opened package is recompressed as stored.
+	reopened, err := OpenBytes(src.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := reopened.WriteToWithOptions(&out, &SaveOptions{StoreMedia: true}); err != nil {
+		t.Fatalf("WriteToWithOptions() error = %v", err)
+	}
+	m := zipMethods(t, out.Bytes())
+	if m["word/media/image1.png"] != zip.Store || m["word/media/clip.bin"] != zip.Store {
+		t.Errorf("media methods = %v, want stored", m)
+	}
+	if m[WordDocumentPath] != zip.Deflate || m[ContentTypesPath] != zip.Deflate {
+		t.Errorf("XML methods = %v, want deflated", m)
+	}
+	final, err := OpenBytes(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := final.ReadFile("word/media/clip.bin"); !bytes.Equal(data, bytes.Repeat([]byte("frame"), 100)) {
+		t.Error("stored media content changed")
+	}
+}
+
+func TestSaveOptions_CompressionLevel(t *testing.T) {
+	text := strings.Repeat(`<w:p><w:r><w:t>compressible paragraph text</w:t></w:r></w:p>`, 2000)
+	size := func(level int) int {
+		pkg := New()
+		_, _ = pkg.AddPart(WordDocumentPath, ContentTypeWordDocument, []byte(text))
+		var buf bytes.Buffer
+		if err := pkg.WriteToWithOptions(&buf, &SaveOptions{CompressionLevel: level}); err != nil {
+			t.Fatalf("WriteToWithOptions(level %d) error = %v", level, err)
+		}
+		return buf.Len()
+	}
+	if fast, best := size(flate.BestSpeed), size(flate.BestCompression); best > fast {
+		t.Errorf("BestCompression = %d bytes, BestSpeed = %d bytes", best, fast)
+	}
+
+	err := New().WriteToWithOptions(&bytes.Buffer{}, &SaveOptions{CompressionLevel: 12})
+	var validationErr *utils.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("WriteToWithOptions(level 12) error = %v, want validation error", err)
+	}
+}
+
+func TestSaveOptions_ParallelDeflate(t *testing.T) {
+	pkg := newFSTestPackage()
+	large := map[string][]byte{
+		"word/media/big1.bin": bytes.Repeat([]byte("first large part "), ParallelDeflateThreshold/8),
+		"word/media/big2.bin": bytes.Repeat([]byte("second large part "), ParallelDeflateThreshold/8),
+	}
+	for name, data := range large {
+		_, _ = pkg.AddPart(name, "application/octet-stream", data)
+	}
+	var buf bytes.Buffer
+	if err := pkg.WriteToWithOptions(&buf, &SaveOptions{ParallelDeflate: true, Deterministic: true}); err != nil {
+		t.Fatalf("WriteToWithOptions() error = %v", err)
+	}
+	reopened, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range large {
+		got, err := reopened.ReadFile(name)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s: %d bytes, err %v; want %d bytes", name, len(got), err, len(want))
+		}
+	}
+	if m := zipMethods(t, buf.Bytes()); m["word/media/big1.bin"] != zip.Deflate {
+		t.Errorf("big1.bin method = %d, want deflate", m["word/media/big1.bin"])
+	}
+}
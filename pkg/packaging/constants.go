@@ -28,6 +28,8 @@ const (
 	RelTypeFootnotes        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footnotes"
 	RelTypeCoreProps        = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
 	RelTypeExtendedProps    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"
+	RelTypeCustomProps      = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+	RelTypeThumbnail        = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/thumbnail"
 	RelTypeCustomXML        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/customXml"
 	RelTypeChart            = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
 	RelTypeChartStyle       = "http://schemas.microsoft.com/office/2011/relationships/chartStyle"
@@ -49,6 +51,17 @@ const (
 	RelTypePresProps        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/presProps"
 	RelTypeViewProps        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/viewProps"
 	RelTypeCommentsExtended = "http://schemas.microsoft.com/office/2011/relationships/commentsExtended"
+	RelTypeOLEObject        = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/oleObject"
+	RelTypePackage          = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/package"
+
+	RelTypeVBAProject           = "http://schemas.microsoft.com/office/2006/relationships/vbaProject"
+	RelTypeWordVBAData          = "http://schemas.microsoft.com/office/2006/relationships/wordVbaData"
+	RelTypeKeyMapCustomizations = "http://schemas.microsoft.com/office/2006/relationships/keyMapCustomizations"
+	RelTypeAttachedToolbars     = "http://schemas.microsoft.com/office/2006/relationships/attachedToolbars"
+
+	RelTypeDigitalSignatureOrigin      = "http://schemas.openxmlformats.org/package/2006/relationships/digital-signature/origin"
+	RelTypeDigitalSignature            = "http://schemas.openxmlformats.org/package/2006/relationships/digital-signature/signature"
+	RelTypeDigitalSignatureCertificate = "http://schemas.openxmlformats.org/package/2006/relationships/digital-signature/certificate"
 )
 
 // Content types
@@ -82,6 +95,7 @@ const (
 	ContentTypeRelationships         = "application/vnd.openxmlformats-package.relationships+xml"
 	ContentTypeCoreProps             = "application/vnd.openxmlformats-package.core-properties+xml"
 	ContentTypeExtendedProps         = "application/vnd.openxmlformats-officedocument.extended-properties+xml"
+	ContentTypeCustomProps           = "application/vnd.openxmlformats-officedocument.custom-properties+xml"
 	ContentTypeTheme                 = "application/vnd.openxmlformats-officedocument.theme+xml"
 	ContentTypeExcelStyles           = "application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"
 	ContentTypeTable                 = "application/vnd.openxmlformats-officedocument.spreadsheetml.table+xml"
@@ -105,6 +119,8 @@ const (
 	ContentTypeGIF                   = "image/gif"
 	ContentTypeBMP                   = "image/bmp"
 	ContentTypeTIFF                  = "image/tiff"
+	ContentTypeEMF                   = "image/x-emf"
+	ContentTypeWMF                   = "image/x-wmf"
 	ContentTypeAudioMPEG             = "audio/mpeg"
 	ContentTypeAudioWAV              = "audio/wav"
 	ContentTypeAudioMP4              = "audio/mp4"
@@ -112,29 +128,51 @@ const (
 	ContentTypeVideoQuickTime        = "video/quicktime"
 	ContentTypeVideoAVI              = "video/x-msvideo"
 	ContentTypeXML                   = "application/xml"
+
+	ContentTypeWordDocumentMacroEnabled         = "application/vnd.ms-word.document.macroEnabled.main+xml"
+	ContentTypeWordTemplateMacroEnabled         = "application/vnd.ms-word.template.macroEnabledTemplate.main+xml"
+	ContentTypeWorkbookTemplate                 = "application/vnd.openxmlformats-officedocument.spreadsheetml.template.main+xml"
+	ContentTypeWorkbookMacroEnabled             = "application/vnd.ms-excel.sheet.macroEnabled.main+xml"
+	ContentTypeWorkbookTemplateMacroEnabled     = "application/vnd.ms-excel.template.macroEnabled.main+xml"
+	ContentTypePresentationTemplate             = "application/vnd.openxmlformats-officedocument.presentationml.template.main+xml"
+	ContentTypePresentationMacroEnabled         = "application/vnd.ms-powerpoint.presentation.macroEnabled.main+xml"
+	ContentTypePresentationTemplateMacroEnabled = "application/vnd.ms-powerpoint.template.macroEnabled.main+xml"
+	ContentTypeVBAProject                       = "application/vnd.ms-office.vbaProject"
+	ContentTypeWordVBAData                      = "application/vnd.ms-word.vbaData+xml"
+
+	ContentTypeOLEObject = "application/vnd.openxmlformats-officedocument.oleObject"
+	ContentTypeDOCX      = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	ContentTypeXLSX      = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	ContentTypePPTX      = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+
+	ContentTypeDigitalSignatureOrigin      = "application/vnd.openxmlformats-package.digital-signature-origin"
+	ContentTypeDigitalSignatureXML         = "application/vnd.openxmlformats-package.digital-signature-xmlsignature+xml"
+	ContentTypeDigitalSignatureCertificate = "application/vnd.openxmlformats-package.digital-signature-certificate"
 )
 
 // XML Namespaces
 const (
-	NSWordprocessingML      = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
-	NSSpreadsheetML         = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
-	NSPresentationML        = "http://schemas.openxmlformats.org/presentationml/2006/main"
-	NSDrawingML             = "http://schemas.openxmlformats.org/drawingml/2006/main"
-	NSDrawingMLDiagram      = "http://schemas.openxmlformats.org/drawingml/2006/diagram"
-	NSRelationships         = "http://schemas.openxmlformats.org/package/2006/relationships"
-	NSContentTypes          = "http://schemas.openxmlformats.org/package/2006/content-types"
-	NSDocumentRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
-	NSDublinCore            = "http://purl.org/dc/elements/1.1/"
-	NSDublinCoreTerms       = "http://purl.org/dc/terms/"
-	NSCoreProperties        = "http://schemas.openxmlformats.org/package/2006/metadata/core-properties"
-	NSExtendedProperties    = "http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"
-	NSDrawingMLChart        = "http://schemas.openxmlformats.org/drawingml/2006/chart"
-	NSDrawingMLPicture      = "http://schemas.openxmlformats.org/drawingml/2006/picture"
+	NSWordprocessingML            = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	NSSpreadsheetML               = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	NSPresentationML              = "http://schemas.openxmlformats.org/presentationml/2006/main"
+	NSDrawingML                   = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	NSDrawingMLDiagram            = "http://schemas.openxmlformats.org/drawingml/2006/diagram"
+	NSRelationships               = "http://schemas.openxmlformats.org/package/2006/relationships"
+	NSContentTypes                = "http://schemas.openxmlformats.org/package/2006/content-types"
+	NSDocumentRelationships       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	NSDublinCore                  = "http://purl.org/dc/elements/1.1/"
+	NSDublinCoreTerms             = "http://purl.org/dc/terms/"
+	NSCoreProperties              = "http://schemas.openxmlformats.org/package/2006/metadata/core-properties"
+	NSExtendedProperties          = "http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"
+	NSDrawingMLChart              = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+	NSDrawingMLPicture            = "http://schemas.openxmlformats.org/drawingml/2006/picture"
 	NSDrawingMLSpreadsheetDrawing = "http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing"
-	NSMarkupCompatibility   = "http://schemas.openxmlformats.org/markup-compatibility/2006"
-	NSOfficeDocRels         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
-	NSVML                   = "urn:schemas-microsoft-com:vml"
-	NSWord14                = "http://schemas.microsoft.com/office/word/2010/wordml"
+	NSMarkupCompatibility         = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+	NSOfficeDocRels               = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	NSVML                         = "urn:schemas-microsoft-com:vml"
+	NSWord14                      = "http://schemas.microsoft.com/office/word/2010/wordml"
+	NSDigitalSignature            = "http://schemas.openxmlformats.org/package/2006/digital-signature"
+	NSXMLDSig                     = "http://www.w3.org/2000/09/xmldsig#"
 )
 
 // File paths in OPC package
@@ -143,12 +181,16 @@ const (
 	PackageRelsPath           = "_rels/.rels"
 	CorePropertiesPath        = "docProps/core.xml"
 	AppPropertiesPath         = "docProps/app.xml"
+	CustomPropertiesPath      = "docProps/custom.xml"
+	ThumbnailPathPrefix       = "docProps/thumbnail"
 	WordDocumentPath          = "word/document.xml"
 	WordStylesPath            = "word/styles.xml"
 	WordSettingsPath          = "word/settings.xml"
 	WordNumberingPath         = "word/numbering.xml"
 	WordCommentsPath          = "word/comments.xml"
 	WordCommentsExtendedPath  = "word/commentsExtended.xml"
+	WordFootnotesPath         = "word/footnotes.xml"
+	WordEndnotesPath          = "word/endnotes.xml"
 	ExcelWorkbookPath         = "xl/workbook.xml"
 	ExcelStylesPath           = "xl/styles.xml"
 	ExcelSharedStringsPath    = "xl/sharedStrings.xml"
@@ -156,6 +198,7 @@ const (
 	PPTXPresentationPath      = PresentationPath // Alias for backward compatibility
 	PresentationPropsPath     = "ppt/presProps.xml"
 	PresentationViewPropsPath = "ppt/viewProps.xml"
+	SignatureOriginPath       = "_xmlsignatures/origin.sigs"
 )
 
 // TargetMode indicates whether the relationship target is internal or external.
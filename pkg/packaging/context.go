@@ -0,0 +1,36 @@
+package packaging
+
+import (
+	"context"
+	"io"
+)
+
+// OpenContext opens an OPC package from a file path like OpenWithOptions,
+// giving up with ctx.Err() once ctx is done. opts may be nil. The context
+// only covers reading the package index; parts are still loaded on demand
+// afterwards.
+func OpenContext(ctx context.Context, filePath string, opts *OpenOptions) (*Package, error) {
+	return openFile(ctx, filePath, opts)
+}
+
+// SaveContext saves the package to its original path like SaveWithOptions,
+// stopping between parts once ctx is done. The target is only replaced
+// once complete. opts may be nil.
+func (p *Package) SaveContext(ctx context.Context, opts *SaveOptions) error {
+	return p.saveWithOptions(ctx, opts)
+}
+
+// SaveAsContext saves the package to a new path like SaveAsWithOptions,
+// stopping between parts once ctx is done. The target is only replaced
+// once complete. opts may be nil.
+func (p *Package) SaveAsContext(ctx context.Context, filePath string, opts *SaveOptions) error {
+	return p.saveAsWithOptions(ctx, filePath, opts)
+}
+
+// WriteToContext writes the package to w the way SaveAsWithOptions would,
+// encrypting it when a password is set, and stops between parts once ctx is
+// done. It returns the number of bytes written; on cancellation w holds an
+// incomplete archive. opts may be nil.
+func (p *Package) WriteToContext(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error) {
+	return p.writeTo(ctx, w, opts)
+}
@@ -0,0 +1,135 @@
+package packaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// countdownContext reports context.Canceled once Err has been called n times.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestOpenContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.docx")
+	if err := newFSTestPackage().SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := OpenContext(context.Background(), path, nil)
+	if err != nil {
+		t.Fatalf("OpenContext() error = %v", err)
+	}
+	if pkg.MainPartPath() != WordDocumentPath {
+		t.Error("OpenContext() should return an indexed package")
+	}
+	pkg.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := OpenContext(ctx, path, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("OpenContext() with canceled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestPackage_SaveAsContext(t *testing.T) {
+	dir := t.TempDir()
+	pkg := newFSTestPackage()
+
+	path := filepath.Join(dir, "done.docx")
+	if err := pkg.SaveAsContext(context.Background(), path, nil); err != nil {
+		t.Fatalf("SaveAsContext() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("saved file missing: %v", err)
+	}
+
+	// Cancel after the first entries have been written.
+	partial := filepath.Join(dir, "partial.docx")
+	err := pkg.SaveAsContext(&countdownContext{Context: context.Background(), n: 3}, partial, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SaveAsContext() error = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("partial file was not removed: %v", err)
+	}
+}
+
+func TestPackage_SaveContextKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "original.docx")
+	if err := newFSTestPackage().SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pkg.Close()
+	err = pkg.SaveContext(&countdownContext{Context: context.Background(), n: 3}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SaveContext() error = %v, want context.Canceled", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("original file was removed: %v", err)
+	}
+	if !bytes.Equal(data, original) {
+		t.Error("original file was modified by a canceled save")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+
+	if err := pkg.Save(); err != nil {
+		t.Fatalf("Save() after canceled save error = %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() after Save() error = %v", err)
+	}
+	reopened.Close()
+}
+
+func TestPackage_WriteToContext(t *testing.T) {
+	pkg := newFSTestPackage()
+	var buf bytes.Buffer
+	n, err := pkg.WriteToContext(context.Background(), &buf, nil)
+	if err != nil {
+		t.Fatalf("WriteToContext() error = %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteToContext() = %d, wrote %d bytes", n, buf.Len())
+	}
+	if _, err := OpenBytes(buf.Bytes()); err != nil {
+		t.Errorf("OpenBytes() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pkg.WriteToContext(ctx, &bytes.Buffer{}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("WriteToContext() with canceled context error = %v, want context.Canceled", err)
+	}
+}
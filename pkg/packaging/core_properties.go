@@ -35,7 +35,7 @@ func (p *Package) CoreProperties() (*common.CoreProperties, error) {
 	}
 
 	props := &common.CoreProperties{}
-	if err := utils.UnmarshalXML(data, props); err != nil {
+	if err := p.DecodeXML(data, props); err != nil {
 		return nil, err
 	}
 	return props, nil
@@ -0,0 +1,140 @@
+package packaging
+
+import (
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// CustomProperties returns the package custom properties (docProps/custom.xml).
+// An empty set is returned when the package has none.
+func (p *Package) CustomProperties() (*common.CustomProperties, error) {
+	if p.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+
+	partPath := p.customPropertiesPath()
+	if partPath == "" {
+		return common.NewCustomProperties(), nil
+	}
+
+	part, err := p.GetPart(partPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := part.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	props := &common.CustomProperties{}
+	if err := p.DecodeXML(data, props); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// SetCustomProperties writes custom properties to docProps/custom.xml and
+// ensures its relationship and content type. An empty set removes the part.
+func (p *Package) SetCustomProperties(props *common.CustomProperties) error {
+	if p.closed {
+		return utils.ErrDocumentClosed
+	}
+	if props == nil {
+		return utils.NewValidationError("customProperties", "cannot be nil", nil)
+	}
+
+	if len(props.Properties) == 0 {
+		return p.removeCustomProperties()
+	}
+
+	data, err := utils.MarshalXMLWithHeader(props)
+	if err != nil {
+		return err
+	}
+
+	partPath := p.customPropertiesPath()
+	if partPath == "" {
+		partPath = CustomPropertiesPath
+	}
+	part, err := p.GetPart(partPath)
+	if err != nil {
+		if _, err := p.AddPart(partPath, ContentTypeCustomProps, data); err != nil {
+			return err
+		}
+	} else if err := part.SetContent(data); err != nil {
+		return err
+	}
+
+	p.contentTypes.EnsureContentType(partPath, ContentTypeCustomProps)
+
+	rels := p.GetRelationships("")
+	if rels.FirstByType(RelTypeCustomProps) == nil {
+		rels.Add(RelTypeCustomProps, partPath, TargetModeInternal)
+	}
+	p.modified = true
+	return nil
+}
+
+// CustomProperty returns the named custom property, or utils.ErrPropertyNotFound.
+func (p *Package) CustomProperty(name string) (*common.CustomProperty, error) {
+	props, err := p.CustomProperties()
+	if err != nil {
+		return nil, err
+	}
+	prop := props.Get(name)
+	if prop == nil {
+		return nil, utils.ErrPropertyNotFound
+	}
+	return prop, nil
+}
+
+// SetCustomProperty adds or replaces a single custom property.
+// See common.CustomProperties.Set for the supported value types.
+func (p *Package) SetCustomProperty(name string, value interface{}) error {
+	props, err := p.CustomProperties()
+	if err != nil {
+		return err
+	}
+	if err := props.Set(name, value); err != nil {
+		return err
+	}
+	return p.SetCustomProperties(props)
+}
+
+// DeleteCustomProperty removes a single custom property.
+func (p *Package) DeleteCustomProperty(name string) error {
+	props, err := p.CustomProperties()
+	if err != nil {
+		return err
+	}
+	if !props.Delete(name) {
+		return utils.ErrPropertyNotFound
+	}
+	return p.SetCustomProperties(props)
+}
+
+// customPropertiesPath resolves the custom properties part, or "".
+func (p *Package) customPropertiesPath() string {
+	if rel := p.GetRelationships("").FirstByType(RelTypeCustomProps); rel != nil && rel.TargetMode != TargetModeExternal {
+		return ResolveRelationshipTarget("", rel.Target)
+	}
+	if p.PartExists(CustomPropertiesPath) {
+		return CustomPropertiesPath
+	}
+	return ""
+}
+
+// removeCustomProperties deletes the custom properties part and relationship.
+func (p *Package) removeCustomProperties() error {
+	if partPath := p.customPropertiesPath(); partPath != "" && p.PartExists(partPath) {
+		if err := p.DeletePart(partPath); err != nil {
+			return err
+		}
+	}
+	rels := p.GetRelationships("")
+	for rel := rels.FirstByType(RelTypeCustomProps); rel != nil; rel = rels.FirstByType(RelTypeCustomProps) {
+		rels.Remove(rel.ID)
+	}
+	p.modified = true
+	return nil
+}
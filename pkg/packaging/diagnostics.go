@@ -0,0 +1,170 @@
+package packaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// Severity ranks a Diagnostic.
+type Severity int
+
+const (
+	// SeverityInfo marks a problem that was repaired without losing content.
+	SeverityInfo Severity = iota
+	// SeverityWarning marks a problem that lost secondary content, such as
+	// comments or formatting.
+	SeverityWarning
+	// SeverityError marks a problem that lost document content, such as a
+	// slide or the cells of a worksheet.
+	SeverityError
+)
+
+// String returns "info", "warning" or "error".
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Diagnostic describes a problem found while opening a package with
+// OpenOptions.Recover and what was done about it.
+type Diagnostic struct {
+	// PartURI is the part that could not be loaded, or "" for the package.
+	PartURI  string
+	Severity Severity
+	// Err is the error the part failed with.
+	Err error
+	// Action is what recovery did, e.g. "dropped comments part".
+	Action string
+}
+
+// String returns a human-readable description of the diagnostic.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: /%s: %s: %v", d.Severity, d.PartURI, d.Action, d.Err)
+}
+
+// CanRecover reports whether a loader may recover from err: the package was
+// opened with OpenOptions.Recover and err is neither a resource limit
+// violation nor a cancellation.
+func (p *Package) CanRecover(err error) bool {
+	return p.recover && !errors.Is(err, utils.ErrLimitExceeded) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Diagnostics returns the problems recorded while opening the package in
+// recover mode, in the order they were found.
+func (p *Package) Diagnostics() []Diagnostic {
+	return append([]Diagnostic(nil), p.diagnostics...)
+}
+
+// AddDiagnostic records a problem found while loading the package. The
+// document, spreadsheet and presentation packages use it to report the parts
+// they drop in recover mode.
+func (p *Package) AddDiagnostic(d Diagnostic) {
+	p.diagnostics = append(p.diagnostics, d)
+}
+
+// RecoverPart handles a part that failed to load with err. When the error
+// can be recovered from, the part is dropped, a diagnostic with severity
+// and action is recorded, and nil is returned; otherwise err is returned.
+func (p *Package) RecoverPart(uri string, err error, severity Severity, action string) error {
+	if err == nil || !p.CanRecover(err) {
+		return err
+	}
+	p.DropPart(uri)
+	p.AddDiagnostic(Diagnostic{PartURI: normalizePath(uri), Severity: severity, Err: err, Action: action})
+	return nil
+}
+
+// DropPart removes a part that cannot be loaded, together with its own
+// relationships and every relationship that targets it, so that saving does
+// not write it back.
+func (p *Package) DropPart(uri string) {
+	if p.closed || uri == "" {
+		return
+	}
+	uri = normalizePath(uri)
+	if p.PartExists(uri) {
+		_ = p.DeletePart(uri)
+	}
+	p.removeRelationshipsOf(uri)
+	for source, rels := range p.relationships {
+		sourceURI := source
+		if sourceURI == "." {
+			sourceURI = ""
+		}
+		var ids []string
+		for _, rel := range rels.Relationships {
+			if rel.TargetMode != TargetModeExternal && ResolveRelationshipTarget(sourceURI, rel.Target) == uri {
+				ids = append(ids, rel.ID)
+			}
+		}
+		for _, id := range ids {
+			rels.Remove(id)
+			p.modified = true
+		}
+	}
+}
+
+// wellKnownContentTypes are the content types of parts at their customary
+// locations, used to rebuild a missing or malformed [Content_Types].xml.
+var wellKnownContentTypes = map[string]string{
+	WordDocumentPath:       ContentTypeWordDocument,
+	ExcelWorkbookPath:      ContentTypeWorkbook,
+	PresentationPath:       ContentTypePresentation,
+	CorePropertiesPath:     ContentTypeCoreProps,
+	AppPropertiesPath:      ContentTypeExtendedProps,
+	"word/styles.xml":      ContentTypeStyles,
+	"word/settings.xml":    ContentTypeSettings,
+	"word/numbering.xml":   ContentTypeNumbering,
+	"word/comments.xml":    ContentTypeComments,
+	ExcelStylesPath:        ContentTypeExcelStyles,
+	ExcelSharedStringsPath: ContentTypeSharedStrings,
+}
+
+// wellKnownPrefixContentTypes map part name prefixes to content types.
+var wellKnownPrefixContentTypes = []struct {
+	prefix, contentType string
+}{
+	{"word/header", ContentTypeHeader},
+	{"word/footer", ContentTypeFooter},
+	{"xl/worksheets/sheet", ContentTypeWorksheet},
+	{"ppt/slides/slide", ContentTypeSlide},
+	{"ppt/slideLayouts/slideLayout", ContentTypeSlideLayout},
+	{"ppt/slideMasters/slideMaster", ContentTypeSlideMaster},
+	{"word/theme/theme", ContentTypeTheme},
+	{"xl/theme/theme", ContentTypeTheme},
+	{"ppt/theme/theme", ContentTypeTheme},
+}
+
+// rebuildContentTypes replaces the content types index with defaults and
+// overrides guessed from part names.
+func (p *Package) rebuildContentTypes() {
+	p.contentTypes = NewContentTypes()
+	for uri := range p.parts {
+		if uri == ContentTypesPath || path.Ext(uri) != ".xml" {
+			continue
+		}
+		if ct, ok := wellKnownContentTypes[uri]; ok {
+			p.contentTypes.AddOverride(uri, ct)
+			continue
+		}
+		for _, known := range wellKnownPrefixContentTypes {
+			if strings.HasPrefix(uri, known.prefix) && !strings.Contains(uri[len(known.prefix):], "/") {
+				p.contentTypes.AddOverride(uri, known.contentType)
+				break
+			}
+		}
+	}
+}
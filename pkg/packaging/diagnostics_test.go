@@ -0,0 +1,113 @@
+package packaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// damagedPackage returns the ZIP of newFSTestPackage with the named entries
+// replaced.
+func damagedPackage(t *testing.T, replace map[string]string) []byte {
+	t.Helper()
+	var src bytes.Buffer
+	if err := newFSTestPackage().WriteTo(&src); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(src.Bytes()), int64(src.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, ok := replace[f.Name]; ok {
+			_, _ = w.Write([]byte(data))
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(rc)
+		rc.Close()
+		_, _ = w.Write(buf.Bytes())
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestOpenOptions_RecoverContentTypes(t *testing.T) {
+	data := damagedPackage(t, map[string]string{ContentTypesPath: "<Types"})
+	if _, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), nil); err == nil {
+		t.Fatal("OpenReaderWithOptions() without Recover succeeded on malformed content types")
+	}
+
+	pkg, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), &OpenOptions{Recover: true})
+	if err != nil {
+		t.Fatalf("OpenReaderWithOptions() error = %v", err)
+	}
+	if ct := pkg.GetContentType(WordDocumentPath); ct != ContentTypeWordDocument {
+		t.Errorf("GetContentType(document) = %q, want %q", ct, ContentTypeWordDocument)
+	}
+	if ct := pkg.GetContentType("word/media/image1.png"); ct != ContentTypePNG {
+		t.Errorf("GetContentType(image) = %q, want %q", ct, ContentTypePNG)
+	}
+	diags := pkg.Diagnostics()
+	if len(diags) != 1 || diags[0].PartURI != ContentTypesPath || diags[0].Severity != SeverityWarning || diags[0].Err == nil {
+		t.Fatalf("Diagnostics() = %v", diags)
+	}
+}
+
+func TestOpenOptions_RecoverRelationships(t *testing.T) {
+	data := damagedPackage(t, map[string]string{"word/_rels/document.xml.rels": "<Relationships><"})
+	if _, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), nil); err == nil {
+		t.Fatal("OpenReaderWithOptions() without Recover succeeded on malformed relationships")
+	}
+
+	pkg, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), &OpenOptions{Recover: true})
+	if err != nil {
+		t.Fatalf("OpenReaderWithOptions() error = %v", err)
+	}
+	if rels := pkg.GetRelationships(WordDocumentPath); len(rels.Relationships) != 0 {
+		t.Errorf("relationships of document = %v, want none", rels.Relationships)
+	}
+	diags := pkg.Diagnostics()
+	if len(diags) != 1 || diags[0].PartURI != "word/_rels/document.xml.rels" || diags[0].Action != "ignored relationships part" {
+		t.Fatalf("Diagnostics() = %v", diags)
+	}
+
+	// The recovered package saves and reopens cleanly.
+	var out bytes.Buffer
+	if err := pkg.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	reopened, err := OpenBytes(out.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	if len(reopened.Diagnostics()) != 0 {
+		t.Errorf("Diagnostics() after reopening = %v, want none", reopened.Diagnostics())
+	}
+}
+
+func TestPackage_DropPart(t *testing.T) {
+	pkg := newFSTestPackage()
+	pkg.DropPart("word/media/image1.png")
+	if pkg.PartExists("word/media/image1.png") {
+		t.Error("DropPart() kept the part")
+	}
+	if rels := pkg.GetRelationships(WordDocumentPath); len(rels.ByType(RelTypeImage)) != 0 {
+		t.Errorf("DropPart() kept the relationship to the part: %v", rels.Relationships)
+	}
+	if !pkg.PartExists(WordDocumentPath) {
+		t.Error("DropPart() removed the source part")
+	}
+}
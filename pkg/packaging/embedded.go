@@ -0,0 +1,215 @@
+package packaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// EmbeddedObject describes a part embedded in a package through an
+// oleObject or package relationship, such as a workbook inserted in a
+// document or the data workbook behind a chart.
+type EmbeddedObject struct {
+	// PartPath is the URI of the embedded part.
+	PartPath string
+	// ContentType is the content type of the embedded part.
+	ContentType string
+	// ProgID identifies the embedding application, e.g. "Excel.Sheet.12".
+	// It is read from the owning part or, for chart data, derived from the
+	// content type.
+	ProgID string
+	// SourcePart is the URI of the part that owns the relationship.
+	SourcePart string
+	// RelationshipID is the ID of the relationship in SourcePart.
+	RelationshipID string
+	// RelationshipType is RelTypeOLEObject or RelTypePackage.
+	RelationshipType string
+
+	pkg *Package
+}
+
+// packageProgIDs maps embedded OOXML package content types to the ProgID
+// Office writes for them.
+var packageProgIDs = map[string]string{
+	ContentTypeDOCX: "Word.Document.12",
+	ContentTypeXLSX: "Excel.Sheet.12",
+	ContentTypePPTX: "PowerPoint.Show.12",
+}
+
+// IsPackage reports whether the embedded part is itself an OOXML package
+// that can be opened with EmbeddedObject.Open.
+func (e *EmbeddedObject) IsPackage() bool {
+	if e.RelationshipType == RelTypePackage {
+		return true
+	}
+	if _, ok := packageProgIDs[e.ContentType]; ok {
+		return true
+	}
+	switch strings.ToLower(path.Ext(e.PartPath)) {
+	case ".docx", ".docm", ".xlsx", ".xlsm", ".pptx", ".pptm":
+		return true
+	}
+	return false
+}
+
+// Data returns the content of the embedded part.
+func (e *EmbeddedObject) Data() ([]byte, error) {
+	part, err := e.part()
+	if err != nil {
+		return nil, err
+	}
+	return part.Content()
+}
+
+// SetData replaces the content of the embedded part in the parent package.
+func (e *EmbeddedObject) SetData(data []byte) error {
+	part, err := e.part()
+	if err != nil {
+		return err
+	}
+	e.pkg.modified = true
+	return part.SetContent(data)
+}
+
+// Open opens the embedded part as a package. Saving the returned package
+// with Save or SaveWithOptions writes it back into the parent package,
+// which must then be saved itself.
+func (e *EmbeddedObject) Open() (*Package, error) {
+	if !e.IsPackage() {
+		return nil, utils.NewValidationError("partPath", "embedded object is not an OOXML package", e.PartPath)
+	}
+	data, err := e.Data()
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := openZip(context.Background(), bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		return nil, err
+	}
+	pkg.xmlLimits = e.pkg.xmlLimits
+	pkg.embedded = e
+	return pkg, nil
+}
+
+func (e *EmbeddedObject) part() (*Part, error) {
+	if e.pkg == nil || e.pkg.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+	return e.pkg.GetPart(e.PartPath)
+}
+
+// Embedded returns the object the package was opened from with
+// EmbeddedObject.Open, or nil for a top-level package.
+func (p *Package) Embedded() *EmbeddedObject {
+	return p.embedded
+}
+
+// saveEmbedded writes the package back into the part it was opened from.
+func (p *Package) saveEmbedded(ctx context.Context, opts *SaveOptions) error {
+	var buf bytes.Buffer
+	if err := p.writeZip(ctx, &buf, opts); err != nil {
+		return err
+	}
+	if err := p.embedded.SetData(buf.Bytes()); err != nil {
+		return err
+	}
+	p.modified = false
+	return nil
+}
+
+// EmbeddedObjects lists the objects embedded anywhere in the package,
+// grouped by owning part in relationship order.
+func (p *Package) EmbeddedObjects() []*EmbeddedObject {
+	if p.closed {
+		return nil
+	}
+	sources := make([]string, 0, len(p.relationships))
+	for source := range p.relationships {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var objects []*EmbeddedObject
+	for _, source := range sources {
+		sourceURI := source
+		if sourceURI == "." {
+			sourceURI = ""
+		}
+		var found []*EmbeddedObject
+		for _, rel := range p.relationships[source].Relationships {
+			if rel.Type != RelTypeOLEObject && rel.Type != RelTypePackage {
+				continue
+			}
+			if rel.TargetMode == TargetModeExternal {
+				continue
+			}
+			target := ResolveRelationshipTarget(sourceURI, rel.Target)
+			if !p.PartExists(target) {
+				continue
+			}
+			found = append(found, &EmbeddedObject{
+				PartPath:         target,
+				ContentType:      p.GetContentType(target),
+				SourcePart:       sourceURI,
+				RelationshipID:   rel.ID,
+				RelationshipType: rel.Type,
+				pkg:              p,
+			})
+		}
+		if len(found) == 0 {
+			continue
+		}
+		progIDs := p.progIDs(sourceURI)
+		for _, obj := range found {
+			obj.ProgID = progIDs[obj.RelationshipID]
+			if obj.ProgID == "" {
+				obj.ProgID = packageProgIDs[obj.ContentType]
+			}
+		}
+		objects = append(objects, found...)
+	}
+	return objects
+}
+
+// progIDs maps relationship IDs to the ProgID attribute of the element that
+// references them in the source part (o:OLEObject in Word, p:oleObj in
+// PowerPoint, oleObject in SpreadsheetML).
+func (p *Package) progIDs(sourceURI string) map[string]string {
+	ids := make(map[string]string)
+	part, err := p.GetPart(sourceURI)
+	if err != nil {
+		return ids
+	}
+	content, err := part.Content()
+	if err != nil {
+		return ids
+	}
+	dec := utils.NewXMLDecoderWithLimits(bytes.NewReader(content), p.xmlLimits)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ids
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		var relID, progID string
+		for _, attr := range start.Attr {
+			switch {
+			case attr.Name.Space == NSOfficeDocRels && attr.Name.Local == "id":
+				relID = attr.Value
+			case strings.EqualFold(attr.Name.Local, "progId"):
+				progID = attr.Value
+			}
+		}
+		if relID != "" && progID != "" {
+			ids[relID] = progID
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package packaging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPackage_EmbeddedObjects(t *testing.T) {
+	child := New()
+	_, _ = child.AddPart(ExcelWorkbookPath, ContentTypeWorkbook, []byte(`<workbook/>`))
+	_, _ = child.AddPart("xl/worksheets/sheet1.xml", ContentTypeWorksheet, []byte(`<worksheet>1</worksheet>`))
+	child.AddRelationship("", ExcelWorkbookPath, RelTypeOfficeDocument)
+	var childData bytes.Buffer
+	if err := child.WriteTo(&childData); err != nil {
+		t.Fatal(err)
+	}
+
+	parent := New()
+	_, _ = parent.AddPart(WordDocumentPath, ContentTypeWordDocument, []byte(`<w:document xmlns:w="`+NSWordprocessingML+`" xmlns:o="urn:schemas-microsoft-com:office:office" xmlns:r="`+NSOfficeDocRels+`"><w:body><w:p><w:r><w:object><o:OLEObject Type="Embed" ProgID="Equation.3" r:id="rId2"/></w:object></w:r></w:p></w:body></w:document>`))
+	_, _ = parent.AddPart("word/charts/chart1.xml", ContentTypeChart, []byte(`<c:chartSpace/>`))
+	_, _ = parent.AddPart("word/embeddings/Microsoft_Excel_Worksheet.xlsx", ContentTypeXLSX, childData.Bytes())
+	_, _ = parent.AddPart("word/embeddings/oleObject1.bin", ContentTypeOLEObject, []byte{0xD0, 0xCF})
+	parent.AddRelationship("", WordDocumentPath, RelTypeOfficeDocument)
+	parent.GetRelationships(WordDocumentPath).AddWithID("rId1", RelTypeChart, "charts/chart1.xml", TargetModeInternal)
+	parent.GetRelationships(WordDocumentPath).AddWithID("rId2", RelTypeOLEObject, "embeddings/oleObject1.bin", TargetModeInternal)
+	parent.AddRelationship("word/charts/chart1.xml", "../embeddings/Microsoft_Excel_Worksheet.xlsx", RelTypePackage)
+
+	objects := parent.EmbeddedObjects()
+	if len(objects) != 2 {
+		t.Fatalf("EmbeddedObjects() = %d objects, want 2", len(objects))
+	}
+	chartData, ole := objects[0], objects[1]
+	if chartData.PartPath != "word/embeddings/Microsoft_Excel_Worksheet.xlsx" || chartData.SourcePart != "word/charts/chart1.xml" ||
+		chartData.ProgID != "Excel.Sheet.12" || !chartData.IsPackage() {
+		t.Errorf("chart data object = %+v", chartData)
+	}
+	if ole.PartPath != "word/embeddings/oleObject1.bin" || ole.RelationshipID != "rId2" || ole.ProgID != "Equation.3" || ole.IsPackage() {
+		t.Errorf("OLE object = %+v", ole)
+	}
+	if _, err := ole.Open(); err == nil {
+		t.Error("Open() of a binary OLE object should fail")
+	}
+
+	nested, err := chartData.Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if nested.Embedded() != chartData {
+		t.Error("Embedded() does not return the source object")
+	}
+	sheet, err := nested.GetPart("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = sheet.SetContent([]byte(`<worksheet>2</worksheet>`))
+	if err := nested.Save(); err != nil {
+		t.Fatalf("Save() of embedded package error = %v", err)
+	}
+	if !parent.IsModified() {
+		t.Error("parent not marked modified after nested save")
+	}
+
+	var out bytes.Buffer
+	if err := parent.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenBytes(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	again, err := reopened.EmbeddedObjects()[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	part, err := again.GetPart("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := part.Content(); string(content) != `<worksheet>2</worksheet>` {
+		t.Errorf("nested sheet = %s, want the updated content", content)
+	}
+}
@@ -0,0 +1,689 @@
+package packaging
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"unicode/utf16"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// Password-protected packages (MS-OFFCRYPTO). The encrypted ZIP package is
+// stored in a compound file together with the EncryptionInfo stream that
+// describes the key derivation. Agile encryption is read and written;
+// Standard encryption is read only.
+
+const (
+	encryptionInfoStream   = "EncryptionInfo"
+	encryptedPackageStream = "EncryptedPackage"
+	dataSpacesStorage      = "\x06DataSpaces"
+
+	nsEncryption       = "http://schemas.microsoft.com/office/2006/encryption"
+	nsPasswordKey      = "http://schemas.microsoft.com/office/2006/keyEncryptor/password"
+	nsCertificateKey   = "http://schemas.microsoft.com/office/2006/keyEncryptor/certificate"
+	agileSegmentLength = 4096
+
+	// Agile write parameters
+	agileSaltSize  = 16
+	agileKeyBits   = 256
+	agileSpinCount = 100000
+
+	// Agile read limits (MS-OFFCRYPTO §2.3.4.10): larger values are invalid
+	// and would let a hostile file allocate or spin without bound.
+	agileMaxSaltSize  = 65536
+	agileMaxSpinCount = 10000000
+
+	// Standard encryption parameters
+	standardSpinCount = 50000
+	standardFlagAES   = 0x20
+	algIDAES128       = 0x660E
+	algIDAES192       = 0x660F
+	algIDAES256       = 0x6610
+)
+
+// Agile block keys (MS-OFFCRYPTO §2.3.4.11-§2.3.4.14).
+var (
+	blockKeyVerifierInput = []byte{0xfe, 0xa7, 0xd2, 0x76, 0x3b, 0x4b, 0x9e, 0x79}
+	blockKeyVerifierValue = []byte{0xd7, 0xaa, 0x0f, 0x6d, 0x30, 0x61, 0x34, 0x4e}
+	blockKeyKeyValue      = []byte{0x14, 0x6e, 0x0b, 0xe7, 0xab, 0xac, 0xd0, 0xd6}
+	blockKeyHMACKey       = []byte{0x5f, 0xb2, 0xad, 0x01, 0x0c, 0xb9, 0xe1, 0xf6}
+	blockKeyHMACValue     = []byte{0xa0, 0x67, 0x7f, 0x02, 0xb2, 0x2c, 0x84, 0x33}
+)
+
+// agileEncryption is the XML descriptor of the Agile EncryptionInfo stream.
+type agileEncryption struct {
+	XMLName       xml.Name            `xml:"encryption"`
+	KeyData       agileKeyData        `xml:"keyData"`
+	DataIntegrity *agileDataIntegrity `xml:"dataIntegrity"`
+	KeyEncryptors []agileKeyEncryptor `xml:"keyEncryptors>keyEncryptor"`
+}
+
+type agileKeyData struct {
+	SaltSize        int    `xml:"saltSize,attr"`
+	BlockSize       int    `xml:"blockSize,attr"`
+	KeyBits         int    `xml:"keyBits,attr"`
+	HashSize        int    `xml:"hashSize,attr"`
+	CipherAlgorithm string `xml:"cipherAlgorithm,attr"`
+	CipherChaining  string `xml:"cipherChaining,attr"`
+	HashAlgorithm   string `xml:"hashAlgorithm,attr"`
+	SaltValue       string `xml:"saltValue,attr"`
+}
+
+type agileDataIntegrity struct {
+	EncryptedHmacKey   string `xml:"encryptedHmacKey,attr"`
+	EncryptedHmacValue string `xml:"encryptedHmacValue,attr"`
+}
+
+type agileKeyEncryptor struct {
+	URI          string             `xml:"uri,attr"`
+	EncryptedKey *agileEncryptedKey `xml:"encryptedKey"`
+}
+
+type agileEncryptedKey struct {
+	agileKeyData
+	SpinCount                  int    `xml:"spinCount,attr"`
+	EncryptedVerifierHashInput string `xml:"encryptedVerifierHashInput,attr"`
+	EncryptedVerifierHashValue string `xml:"encryptedVerifierHashValue,attr"`
+	EncryptedKeyValue          string `xml:"encryptedKeyValue,attr"`
+}
+
+// decryptPackage returns the ZIP package stored in an encrypted compound file,
+// decoding the encryption descriptor within limits.
+func decryptPackage(data []byte, password string, limits utils.XMLLimits) ([]byte, error) {
+	cf, err := readCompoundFile(data)
+	if err != nil {
+		return nil, err
+	}
+	info, err := cf.readStream(encryptionInfoStream)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrUnsupportedEncryption, err)
+	}
+	encrypted, err := cf.readStream(encryptedPackageStream)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrCorruptedFile, err)
+	}
+	if len(info) < 8 || len(encrypted) < 8 {
+		return nil, utils.ErrCorruptedFile
+	}
+
+	major := binary.LittleEndian.Uint16(info[0:])
+	minor := binary.LittleEndian.Uint16(info[2:])
+	switch {
+	case major == 4 && minor == 4:
+		return decryptAgile(info[8:], encrypted, password, limits)
+	case (major == 2 || major == 3 || major == 4) && minor == 2:
+		return decryptStandard(info[8:], encrypted, password)
+	default:
+		return nil, fmt.Errorf("%w: EncryptionInfo version %d.%d", utils.ErrUnsupportedEncryption, major, minor)
+	}
+}
+
+// encryptPackage writes zipData as an Agile-encrypted compound file.
+func encryptPackage(w io.Writer, zipData []byte, password string) error {
+	newHash := sha512.New
+	hashSize := sha512.Size
+	blockSize := aes.BlockSize
+	keyBytes := agileKeyBits / 8
+
+	keySalt, err := randomBytes(agileSaltSize)
+	if err != nil {
+		return err
+	}
+	passwordSalt, err := randomBytes(agileSaltSize)
+	if err != nil {
+		return err
+	}
+	key, err := randomBytes(keyBytes)
+	if err != nil {
+		return err
+	}
+	verifierInput, err := randomBytes(agileSaltSize)
+	if err != nil {
+		return err
+	}
+	hmacKey, err := randomBytes(hashSize)
+	if err != nil {
+		return err
+	}
+
+	// Encrypted package: size prefix followed by independently encrypted segments.
+	var pkg bytes.Buffer
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(zipData)))
+	pkg.Write(size[:])
+	for i := 0; i*agileSegmentLength < len(zipData); i++ {
+		end := (i + 1) * agileSegmentLength
+		if end > len(zipData) {
+			end = len(zipData)
+		}
+		iv := agileSegmentIV(newHash, keySalt, uint32(i), blockSize)
+		segment, err := aesCBC(key, iv, padBlock(zipData[i*agileSegmentLength:end], blockSize), true)
+		if err != nil {
+			return err
+		}
+		pkg.Write(segment)
+	}
+	encrypted := pkg.Bytes()
+
+	// Data integrity
+	mac := hmac.New(newHash, hmacKey)
+	mac.Write(encrypted)
+	encHMACKey, err := aesCBC(key, agileBlockIV(newHash, keySalt, blockKeyHMACKey, blockSize), padBlock(hmacKey, blockSize), true)
+	if err != nil {
+		return err
+	}
+	encHMACValue, err := aesCBC(key, agileBlockIV(newHash, keySalt, blockKeyHMACValue, blockSize), padBlock(mac.Sum(nil), blockSize), true)
+	if err != nil {
+		return err
+	}
+
+	// Password key encryptor
+	h := agilePasswordHash(newHash, passwordSalt, password, agileSpinCount)
+	passwordIV := fitBlock(passwordSalt, blockSize)
+	encrypt := func(blockKey, data []byte) (string, error) {
+		out, err := aesCBC(agileDerivedKey(newHash, h, blockKey, keyBytes), passwordIV, padBlock(data, blockSize), true)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(out), nil
+	}
+	verifierHash := newHash()
+	verifierHash.Write(verifierInput)
+	encVerifierInput, err := encrypt(blockKeyVerifierInput, verifierInput)
+	if err != nil {
+		return err
+	}
+	encVerifierValue, err := encrypt(blockKeyVerifierValue, verifierHash.Sum(nil))
+	if err != nil {
+		return err
+	}
+	encKeyValue, err := encrypt(blockKeyKeyValue, key)
+	if err != nil {
+		return err
+	}
+
+	params := fmt.Sprintf(`saltSize="%d" blockSize="%d" keyBits="%d" hashSize="%d" cipherAlgorithm="AES" cipherChaining="ChainingModeCBC" hashAlgorithm="SHA512"`,
+		agileSaltSize, blockSize, agileKeyBits, hashSize)
+	descriptor := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\r\n"+
+		`<encryption xmlns="%s" xmlns:p="%s" xmlns:c="%s">`+
+		`<keyData %s saltValue="%s"/>`+
+		`<dataIntegrity encryptedHmacKey="%s" encryptedHmacValue="%s"/>`+
+		`<keyEncryptors><keyEncryptor uri="%s">`+
+		`<p:encryptedKey spinCount="%d" %s saltValue="%s" encryptedVerifierHashInput="%s" encryptedVerifierHashValue="%s" encryptedKeyValue="%s"/>`+
+		`</keyEncryptor></keyEncryptors></encryption>`,
+		nsEncryption, nsPasswordKey, nsCertificateKey,
+		params, base64.StdEncoding.EncodeToString(keySalt),
+		base64.StdEncoding.EncodeToString(encHMACKey), base64.StdEncoding.EncodeToString(encHMACValue),
+		nsPasswordKey,
+		agileSpinCount, params, base64.StdEncoding.EncodeToString(passwordSalt),
+		encVerifierInput, encVerifierValue, encKeyValue)
+
+	info := make([]byte, 8, 8+len(descriptor))
+	binary.LittleEndian.PutUint16(info[0:], 4)
+	binary.LittleEndian.PutUint16(info[2:], 4)
+	binary.LittleEndian.PutUint32(info[4:], 0x40)
+	info = append(info, descriptor...)
+
+	streams := append(dataSpacesStreams(),
+		cfbStream{path: []string{encryptionInfoStream}, data: info},
+		cfbStream{path: []string{encryptedPackageStream}, data: encrypted},
+	)
+	return writeCompoundFile(w, streams)
+}
+
+// decryptAgile decrypts an Agile-encrypted package (MS-OFFCRYPTO §2.3.4.10).
+func decryptAgile(descriptor, encrypted []byte, password string, limits utils.XMLLimits) ([]byte, error) {
+	var enc agileEncryption
+	if err := utils.UnmarshalXMLWithLimits(descriptor, &enc, limits); err != nil {
+		if errors.Is(err, utils.ErrLimitExceeded) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", utils.ErrCorruptedFile, err)
+	}
+	var ek *agileEncryptedKey
+	for _, ke := range enc.KeyEncryptors {
+		if ke.URI == nsPasswordKey && ke.EncryptedKey != nil {
+			ek = ke.EncryptedKey
+			break
+		}
+	}
+	if ek == nil {
+		return nil, fmt.Errorf("%w: no password key encryptor", utils.ErrUnsupportedEncryption)
+	}
+	if err := checkAgileParams(&enc.KeyData); err != nil {
+		return nil, err
+	}
+	if err := checkAgileParams(&ek.agileKeyData); err != nil {
+		return nil, err
+	}
+	if ek.SpinCount < 0 || ek.SpinCount > agileMaxSpinCount {
+		return nil, fmt.Errorf("%w: spin count %d", utils.ErrUnsupportedEncryption, ek.SpinCount)
+	}
+
+	// Derive the intermediate key and check the password verifier.
+	pwHash, _ := agileHashFunc(ek.HashAlgorithm)
+	pwSalt, err := decodeBase64(ek.SaltValue)
+	if err != nil {
+		return nil, err
+	}
+	h := agilePasswordHash(pwHash, pwSalt, password, ek.SpinCount)
+	pwIV := fitBlock(pwSalt, ek.BlockSize)
+	decrypt := func(blockKey []byte, value string) ([]byte, error) {
+		data, err := decodeBase64(value)
+		if err != nil {
+			return nil, err
+		}
+		return aesCBC(agileDerivedKey(pwHash, h, blockKey, ek.KeyBits/8), pwIV, data, false)
+	}
+	verifierInput, err := decrypt(blockKeyVerifierInput, ek.EncryptedVerifierHashInput)
+	if err != nil {
+		return nil, err
+	}
+	verifierValue, err := decrypt(blockKeyVerifierValue, ek.EncryptedVerifierHashValue)
+	if err != nil {
+		return nil, err
+	}
+	if len(verifierInput) < ek.SaltSize || len(verifierValue) < ek.HashSize {
+		return nil, utils.ErrCorruptedFile
+	}
+	verifierHash := pwHash()
+	verifierHash.Write(verifierInput[:ek.SaltSize])
+	if subtle.ConstantTimeCompare(verifierHash.Sum(nil), verifierValue[:ek.HashSize]) != 1 {
+		return nil, utils.ErrInvalidPassword
+	}
+	key, err := decrypt(blockKeyKeyValue, ek.EncryptedKeyValue)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) < enc.KeyData.KeyBits/8 {
+		return nil, utils.ErrCorruptedFile
+	}
+	key = key[:enc.KeyData.KeyBits/8]
+
+	kd := enc.KeyData
+	newHash, _ := agileHashFunc(kd.HashAlgorithm)
+	keySalt, err := decodeBase64(kd.SaltValue)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the HMAC over the whole EncryptedPackage stream.
+	if di := enc.DataIntegrity; di != nil {
+		decryptIntegrity := func(blockKey []byte, value string) ([]byte, error) {
+			data, err := decodeBase64(value)
+			if err != nil {
+				return nil, err
+			}
+			out, err := aesCBC(key, agileBlockIV(newHash, keySalt, blockKey, kd.BlockSize), data, false)
+			if err != nil {
+				return nil, err
+			}
+			if len(out) < kd.HashSize {
+				return nil, utils.ErrCorruptedFile
+			}
+			return out[:kd.HashSize], nil
+		}
+		hmacKey, err := decryptIntegrity(blockKeyHMACKey, di.EncryptedHmacKey)
+		if err != nil {
+			return nil, err
+		}
+		hmacValue, err := decryptIntegrity(blockKeyHMACValue, di.EncryptedHmacValue)
+		if err != nil {
+			return nil, err
+		}
+		mac := hmac.New(newHash, hmacKey)
+		mac.Write(encrypted)
+		if !hmac.Equal(mac.Sum(nil), hmacValue) {
+			return nil, utils.ErrIntegrityCheckFailed
+		}
+	}
+
+	size := binary.LittleEndian.Uint64(encrypted)
+	data := encrypted[8:]
+	out := make([]byte, 0, len(data))
+	for i := 0; i*agileSegmentLength < len(data); i++ {
+		end := (i + 1) * agileSegmentLength
+		if end > len(data) {
+			end = len(data)
+		}
+		segment := data[i*agileSegmentLength : end]
+		segment = segment[:len(segment)-len(segment)%kd.BlockSize]
+		plain, err := aesCBC(key, agileSegmentIV(newHash, keySalt, uint32(i), kd.BlockSize), segment, false)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, plain...)
+	}
+	if uint64(len(out)) < size {
+		return nil, fmt.Errorf("%w: encrypted package is truncated", utils.ErrCorruptedFile)
+	}
+	return out[:size], nil
+}
+
+// checkAgileParams rejects cipher settings other than AES-CBC and salt
+// sizes outside the range allowed by MS-OFFCRYPTO.
+func checkAgileParams(kd *agileKeyData) error {
+	if kd.SaltSize < 1 || kd.SaltSize > agileMaxSaltSize {
+		return fmt.Errorf("%w: salt size %d", utils.ErrUnsupportedEncryption, kd.SaltSize)
+	}
+	if kd.CipherAlgorithm != "AES" || kd.CipherChaining != "ChainingModeCBC" {
+		return fmt.Errorf("%w: cipher %s/%s", utils.ErrUnsupportedEncryption, kd.CipherAlgorithm, kd.CipherChaining)
+	}
+	switch kd.KeyBits {
+	case 128, 192, 256:
+	default:
+		return fmt.Errorf("%w: key size %d", utils.ErrUnsupportedEncryption, kd.KeyBits)
+	}
+	if kd.BlockSize != aes.BlockSize {
+		return fmt.Errorf("%w: block size %d", utils.ErrUnsupportedEncryption, kd.BlockSize)
+	}
+	newHash, ok := agileHashFunc(kd.HashAlgorithm)
+	if !ok {
+		return fmt.Errorf("%w: hash algorithm %s", utils.ErrUnsupportedEncryption, kd.HashAlgorithm)
+	}
+	if kd.HashSize != newHash().Size() {
+		return fmt.Errorf("%w: hash size %d", utils.ErrUnsupportedEncryption, kd.HashSize)
+	}
+	return nil
+}
+
+func agileHashFunc(name string) (func() hash.Hash, bool) {
+	switch name {
+	case "SHA1", "SHA-1":
+		return sha1.New, true
+	case "SHA256", "SHA-256":
+		return sha256.New, true
+	case "SHA384", "SHA-384":
+		return sha512.New384, true
+	case "SHA512", "SHA-512":
+		return sha512.New, true
+	}
+	return nil, false
+}
+
+// agilePasswordHash computes the iterated password hash H(n).
+func agilePasswordHash(newHash func() hash.Hash, salt []byte, password string, spinCount int) []byte {
+	h := newHash()
+	h.Write(salt)
+	h.Write(utf16LE(password))
+	sum := h.Sum(nil)
+	var iter [4]byte
+	for i := 0; i < spinCount; i++ {
+		binary.LittleEndian.PutUint32(iter[:], uint32(i))
+		h.Reset()
+		h.Write(iter[:])
+		h.Write(sum)
+		sum = h.Sum(sum[:0])
+	}
+	return sum
+}
+
+// agileDerivedKey hashes the password hash with a block key and sizes it
+// to keyBytes, padding with 0x36.
+func agileDerivedKey(newHash func() hash.Hash, passwordHash, blockKey []byte, keyBytes int) []byte {
+	h := newHash()
+	h.Write(passwordHash)
+	h.Write(blockKey)
+	return fitBytes(h.Sum(nil), keyBytes, 0x36)
+}
+
+// agileBlockIV derives an IV from the key data salt and a block key.
+func agileBlockIV(newHash func() hash.Hash, salt, blockKey []byte, blockSize int) []byte {
+	h := newHash()
+	h.Write(salt)
+	h.Write(blockKey)
+	return fitBlock(h.Sum(nil), blockSize)
+}
+
+// agileSegmentIV derives the IV of an EncryptedPackage segment.
+func agileSegmentIV(newHash func() hash.Hash, salt []byte, index uint32, blockSize int) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], index)
+	return agileBlockIV(newHash, salt, b[:], blockSize)
+}
+
+// decryptStandard decrypts a Standard-encrypted package (MS-OFFCRYPTO §2.3.4.5).
+// info starts at the EncryptionHeader size field.
+func decryptStandard(info, encrypted []byte, password string) ([]byte, error) {
+	le := binary.LittleEndian
+	if len(info) < 4 {
+		return nil, utils.ErrCorruptedFile
+	}
+	headerSize := int(le.Uint32(info))
+	if headerSize < 32 || len(info) < 4+headerSize {
+		return nil, utils.ErrCorruptedFile
+	}
+	header := info[4 : 4+headerSize]
+	flags := le.Uint32(header[0:])
+	algID := le.Uint32(header[8:])
+	keyBits := int(le.Uint32(header[16:]))
+	if flags&standardFlagAES == 0 {
+		return nil, fmt.Errorf("%w: RC4 encryption", utils.ErrUnsupportedEncryption)
+	}
+	switch algID {
+	case algIDAES128, algIDAES192, algIDAES256:
+	default:
+		return nil, fmt.Errorf("%w: algorithm 0x%04X", utils.ErrUnsupportedEncryption, algID)
+	}
+	if keyBits != 128 && keyBits != 192 && keyBits != 256 {
+		return nil, fmt.Errorf("%w: key size %d", utils.ErrUnsupportedEncryption, keyBits)
+	}
+
+	verifier := info[4+headerSize:]
+	if len(verifier) < 4 {
+		return nil, utils.ErrCorruptedFile
+	}
+	saltSize := int(le.Uint32(verifier))
+	if len(verifier) < 4+saltSize+16+4+32 {
+		return nil, utils.ErrCorruptedFile
+	}
+	salt := verifier[4 : 4+saltSize]
+	encVerifier := verifier[4+saltSize : 4+saltSize+16]
+	verifierHashSize := int(le.Uint32(verifier[4+saltSize+16:]))
+	if verifierHashSize != sha1.Size {
+		return nil, fmt.Errorf("%w: verifier hash size %d", utils.ErrCorruptedFile, verifierHashSize)
+	}
+	encVerifierHash := verifier[4+saltSize+20 : 4+saltSize+20+32]
+
+	key := standardKey(salt, password, keyBits/8)
+	plainVerifier, err := aesECB(key, encVerifier, false)
+	if err != nil {
+		return nil, err
+	}
+	plainHash, err := aesECB(key, encVerifierHash, false)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(plainVerifier)
+	if subtle.ConstantTimeCompare(sum[:], plainHash[:sha1.Size]) != 1 {
+		return nil, utils.ErrInvalidPassword
+	}
+
+	size := le.Uint64(encrypted)
+	data := encrypted[8:]
+	data = data[:len(data)-len(data)%aes.BlockSize]
+	out, err := aesECB(key, data, false)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(out)) < size {
+		return nil, fmt.Errorf("%w: encrypted package is truncated", utils.ErrCorruptedFile)
+	}
+	return out[:size], nil
+}
+
+// standardKey derives the Standard encryption key (MS-OFFCRYPTO §2.3.4.7).
+func standardKey(salt []byte, password string, keyBytes int) []byte {
+	h := agilePasswordHash(sha1.New, salt, password, standardSpinCount)
+	final := sha1.Sum(append(h, 0, 0, 0, 0))
+	derive := func(fill byte) []byte {
+		buf := bytes.Repeat([]byte{fill}, 64)
+		for i, b := range final {
+			buf[i] ^= b
+		}
+		sum := sha1.Sum(buf)
+		return sum[:]
+	}
+	key := append(derive(0x36), derive(0x5c)...)
+	return key[:keyBytes]
+}
+
+// dataSpacesStreams returns the \x06DataSpaces storage content declaring
+// the strong encryption transform (MS-OFFCRYPTO §2.1).
+func dataSpacesStreams() []cfbStream {
+	const (
+		transformName = "StrongEncryptionTransform"
+		dataSpaceName = "StrongEncryptionDataSpace"
+	)
+	versions := func(b *bytes.Buffer) {
+		// reader, updater and writer versions 1.0
+		for i := 0; i < 3; i++ {
+			writeUint16s(b, 1, 0)
+		}
+	}
+
+	var version bytes.Buffer
+	writeLPP4(&version, "Microsoft.Container.DataSpaces")
+	versions(&version)
+
+	var entry bytes.Buffer
+	writeUint32s(&entry, 1, 0) // one stream reference component
+	writeLPP4(&entry, encryptedPackageStream)
+	writeLPP4(&entry, dataSpaceName)
+	var dataSpaceMap bytes.Buffer
+	writeUint32s(&dataSpaceMap, 8, 1, uint32(entry.Len()+4))
+	dataSpaceMap.Write(entry.Bytes())
+
+	var definition bytes.Buffer
+	writeUint32s(&definition, 8, 1)
+	writeLPP4(&definition, transformName)
+
+	var primary bytes.Buffer
+	writeUint32s(&primary, 88, 1)
+	writeLPP4(&primary, "{FF9A3F03-56EF-4613-BDD5-5A41C1D07246}")
+	writeLPP4(&primary, "Microsoft.Container.EncryptionTransform")
+	versions(&primary)
+	writeUint32s(&primary, 0, 0, 0, 4)
+
+	return []cfbStream{
+		{path: []string{dataSpacesStorage, "Version"}, data: version.Bytes()},
+		{path: []string{dataSpacesStorage, "DataSpaceMap"}, data: dataSpaceMap.Bytes()},
+		{path: []string{dataSpacesStorage, "DataSpaceInfo", dataSpaceName}, data: definition.Bytes()},
+		{path: []string{dataSpacesStorage, "TransformInfo", transformName, "\x06Primary"}, data: primary.Bytes()},
+	}
+}
+
+// writeLPP4 writes a length-prefixed UTF-16LE string padded to 4 bytes.
+func writeLPP4(b *bytes.Buffer, s string) {
+	data := utf16LE(s)
+	writeUint32s(b, uint32(len(data)))
+	b.Write(data)
+	if pad := len(data) % 4; pad != 0 {
+		b.Write(make([]byte, 4-pad))
+	}
+}
+
+func writeUint32s(b *bytes.Buffer, values ...uint32) {
+	for _, v := range values {
+		_ = binary.Write(b, binary.LittleEndian, v)
+	}
+}
+
+func writeUint16s(b *bytes.Buffer, values ...uint16) {
+	for _, v := range values {
+		_ = binary.Write(b, binary.LittleEndian, v)
+	}
+}
+
+func utf16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[2*i:], u)
+	}
+	return b
+}
+
+func aesCBC(key, iv, data []byte, encrypt bool) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("%w: ciphertext is not a multiple of the block size", utils.ErrCorruptedFile)
+	}
+	out := make([]byte, len(data))
+	if encrypt {
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+	} else {
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	}
+	return out, nil
+}
+
+func aesECB(key, data []byte, encrypt bool) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	size := block.BlockSize()
+	if len(data)%size != 0 {
+		return nil, fmt.Errorf("%w: ciphertext is not a multiple of the block size", utils.ErrCorruptedFile)
+	}
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += size {
+		if encrypt {
+			block.Encrypt(out[i:i+size], data[i:i+size])
+		} else {
+			block.Decrypt(out[i:i+size], data[i:i+size])
+		}
+	}
+	return out, nil
+}
+
+// padBlock zero-pads data to a multiple of blockSize.
+func padBlock(data []byte, blockSize int) []byte {
+	if rem := len(data) % blockSize; rem != 0 {
+		return append(append([]byte(nil), data...), make([]byte, blockSize-rem)...)
+	}
+	return data
+}
+
+// fitBlock truncates or pads b with 0x36 to one block.
+func fitBlock(b []byte, blockSize int) []byte {
+	return fitBytes(b, blockSize, 0x36)
+}
+
+func fitBytes(b []byte, n int, fill byte) []byte {
+	if len(b) >= n {
+		return b[:n]
+	}
+	out := make([]byte, n)
+	copy(out, b)
+	for i := len(b); i < n; i++ {
+		out[i] = fill
+	}
+	return out
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,275 @@
+package packaging
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+func newEncryptionTestPackage(t *testing.T) *Package {
+	t.Helper()
+	pkg := New()
+	if _, err := pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<w:document xmlns:w="urn:test"><w:body>secret</w:body></w:document>`)); err != nil {
+		t.Fatalf("AddPart() error = %v", err)
+	}
+	// Large enough to span several encryption segments.
+	if _, err := pkg.AddPart("word/media/image1.png", ContentTypePNG, bytes.Repeat([]byte{0x89, 0x50}, 10000)); err != nil {
+		t.Fatalf("AddPart() error = %v", err)
+	}
+	pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	pkg.AddRelationship("word/document.xml", "media/image1.png", RelTypeImage)
+	return pkg
+}
+
+func TestPackage_EncryptedRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret.docx")
+	pkg := newEncryptionTestPackage(t)
+	if err := pkg.SaveAsWithOptions(path, &SaveOptions{Password: "Pa55w0rd"}); err != nil {
+		t.Fatalf("SaveAsWithOptions() error = %v", err)
+	}
+	if !pkg.IsEncrypted() {
+		t.Error("IsEncrypted() = false after saving with a password")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isCompoundFile(data) {
+		t.Fatal("encrypted package is not a compound file")
+	}
+	if bytes.Contains(data, []byte("secret</w:body>")) {
+		t.Error("encrypted package contains plaintext")
+	}
+
+	if _, err := Open(path); !errors.Is(err, utils.ErrPasswordRequired) {
+		t.Errorf("Open() error = %v, want ErrPasswordRequired", err)
+	}
+	if _, err := OpenWithOptions(path, &OpenOptions{Password: "wrong"}); !errors.Is(err, utils.ErrInvalidPassword) {
+		t.Errorf("OpenWithOptions(wrong) error = %v, want ErrInvalidPassword", err)
+	}
+
+	reopened, err := OpenWithOptions(path, &OpenOptions{Password: "Pa55w0rd"})
+	if err != nil {
+		t.Fatalf("OpenWithOptions() error = %v", err)
+	}
+	if !reopened.IsEncrypted() {
+		t.Error("IsEncrypted() = false for a decrypted package")
+	}
+	part, err := reopened.GetPart("word/media/image1.png")
+	if err != nil {
+		t.Fatalf("GetPart() error = %v", err)
+	}
+	content, _ := part.Content()
+	if len(content) != 20000 {
+		t.Errorf("image part has %d bytes, want 20000", len(content))
+	}
+
+	// Saving again keeps the password; clearing it writes a plain ZIP.
+	if err := reopened.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := OpenWithOptions(path, &OpenOptions{Password: "Pa55w0rd"}); err != nil {
+		t.Fatalf("reopen after Save() error = %v", err)
+	}
+	reopened.SetPassword("")
+	if err := reopened.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	plain, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() after removing password error = %v", err)
+	}
+	plain.Close()
+	reopened.Close()
+}
+
+func TestPackage_EncryptedIntegrity(t *testing.T) {
+	pkg := newEncryptionTestPackage(t)
+	var zipData bytes.Buffer
+	if err := pkg.WriteTo(&zipData); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := encryptPackage(&buf, zipData.Bytes(), "pw"); err != nil {
+		t.Fatal(err)
+	}
+
+	cf, err := readCompoundFile(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := cf.readStream(encryptionInfoStream)
+	encrypted, _ := cf.readStream(encryptedPackageStream)
+	encrypted[len(encrypted)-1] ^= 0xFF
+	if _, err := decryptAgile(info[8:], encrypted, "pw", utils.XMLLimits{}); !errors.Is(err, utils.ErrIntegrityCheckFailed) {
+		t.Errorf("decryptAgile() error = %v, want ErrIntegrityCheckFailed", err)
+	}
+}
+
+func TestPackage_EncryptedResourceLimits(t *testing.T) {
+	pkg := newEncryptionTestPackage(t)
+	pkg.SetPassword("pw")
+	var buf bytes.Buffer
+	if _, err := pkg.WriterTo().WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	tests := []struct {
+		name  string
+		opts  *OpenOptions
+		limit string
+	}{
+		{"total size", &OpenOptions{Password: "pw", MaxTotalSize: 1024}, "total size"},
+		{"descriptor depth", &OpenOptions{Password: "pw", MaxXMLDepth: 2}, "xml depth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), tt.opts)
+			var limitErr *utils.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("OpenReaderWithOptions() error = %v, want *utils.LimitError", err)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("Limit = %q, want %q", limitErr.Limit, tt.limit)
+			}
+		})
+	}
+}
+
+// encryptStandard produces a Standard-encrypted (AES-128) compound file.
+func encryptStandard(t *testing.T, zipData []byte, password string) []byte {
+	t.Helper()
+	info, encrypted := standardEncryptionStreams(t, zipData, password, sha1.Size)
+	var buf bytes.Buffer
+	if err := writeCompoundFile(&buf, []cfbStream{
+		{path: []string{encryptionInfoStream}, data: info},
+		{path: []string{encryptedPackageStream}, data: encrypted},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// standardEncryptionStreams returns the EncryptionInfo and EncryptedPackage
+// streams of a Standard-encrypted package declaring verifierHashSize.
+func standardEncryptionStreams(t *testing.T, zipData []byte, password string, verifierHashSize uint32) (info, encrypted []byte) {
+	t.Helper()
+	le := binary.LittleEndian
+	salt := bytes.Repeat([]byte{0x42}, 16)
+	key := standardKey(salt, password, 16)
+
+	verifier := bytes.Repeat([]byte{0x24}, 16)
+	verifierHash := sha1.Sum(verifier)
+	encVerifier, err := aesECB(key, verifier, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encVerifierHash, err := aesECB(key, padBlock(verifierHash[:], aes.BlockSize), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header bytes.Buffer
+	writeUint32s(&header, 0x24, 0, algIDAES128, 0x8004, 128, 0x18, 0, 0)
+	header.Write(utf16LE("Microsoft Enhanced RSA and AES Cryptographic Provider\x00"))
+
+	var infoBuf bytes.Buffer
+	writeUint16s(&infoBuf, 3, 2)
+	writeUint32s(&infoBuf, 0x24, uint32(header.Len()))
+	infoBuf.Write(header.Bytes())
+	writeUint32s(&infoBuf, 16)
+	infoBuf.Write(salt)
+	infoBuf.Write(encVerifier)
+	writeUint32s(&infoBuf, verifierHashSize)
+	infoBuf.Write(encVerifierHash)
+
+	body, err := aesECB(key, padBlock(zipData, aes.BlockSize), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted = make([]byte, 8, 8+len(body))
+	le.PutUint64(encrypted, uint64(len(zipData)))
+	encrypted = append(encrypted, body...)
+	return infoBuf.Bytes(), encrypted
+}
+
+func TestPackage_OpenStandardEncryption(t *testing.T) {
+	pkg := newEncryptionTestPackage(t)
+	var zipData bytes.Buffer
+	if err := pkg.WriteTo(&zipData); err != nil {
+		t.Fatal(err)
+	}
+	data := encryptStandard(t, zipData.Bytes(), "standard")
+
+	if _, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), &OpenOptions{Password: "nope"}); !errors.Is(err, utils.ErrInvalidPassword) {
+		t.Errorf("OpenReaderWithOptions(wrong) error = %v, want ErrInvalidPassword", err)
+	}
+	reopened, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), &OpenOptions{Password: "standard"})
+	if err != nil {
+		t.Fatalf("OpenReaderWithOptions() error = %v", err)
+	}
+	defer reopened.Close()
+	if !reopened.PartExists("word/document.xml") {
+		t.Error("decrypted package is missing word/document.xml")
+	}
+}
+
+func TestDecryptAgile_RejectsInvalidParams(t *testing.T) {
+	var buf bytes.Buffer
+	if err := encryptPackage(&buf, []byte("PK"), "pw"); err != nil {
+		t.Fatal(err)
+	}
+	cf, err := readCompoundFile(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := cf.readStream(encryptionInfoStream)
+	encrypted, _ := cf.readStream(encryptedPackageStream)
+	descriptor := string(info[8:])
+	if _, err := decryptAgile([]byte(descriptor), encrypted, "pw", utils.XMLLimits{}); err != nil {
+		t.Fatalf("decryptAgile() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		old, new string
+	}{
+		{"negative salt size", `saltSize="16"`, `saltSize="-1"`},
+		{"zero salt size", `saltSize="16"`, `saltSize="0"`},
+		{"oversized salt size", `saltSize="16"`, `saltSize="1000000"`},
+		{"negative key bits", `keyBits="256"`, `keyBits="-256"`},
+		{"oversized key bits", `keyBits="256"`, `keyBits="4096"`},
+		{"zero block size", `blockSize="16"`, `blockSize="0"`},
+		{"oversized block size", `blockSize="16"`, `blockSize="1024"`},
+		{"negative spin count", `spinCount="100000"`, `spinCount="-1"`},
+		{"oversized spin count", `spinCount="100000"`, `spinCount="2000000000"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(descriptor, tt.old) {
+				t.Fatalf("descriptor has no %s", tt.old)
+			}
+			bad := strings.ReplaceAll(descriptor, tt.old, tt.new)
+			if _, err := decryptAgile([]byte(bad), encrypted, "pw", utils.XMLLimits{}); !errors.Is(err, utils.ErrUnsupportedEncryption) {
+				t.Errorf("decryptAgile() error = %v, want ErrUnsupportedEncryption", err)
+			}
+		})
+	}
+}
+
+func TestDecryptStandard_RejectsEmptyVerifierHash(t *testing.T) {
+	info, encrypted := standardEncryptionStreams(t, []byte("PK"), "standard", 0)
+	if _, err := decryptStandard(info[8:], encrypted, "anything"); !errors.Is(err, utils.ErrCorruptedFile) {
+		t.Errorf("decryptStandard() error = %v, want ErrCorruptedFile", err)
+	}
+}
@@ -0,0 +1,85 @@
+package packaging
+
+import (
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// ExtendedProperties returns the package extended properties (docProps/app.xml).
+// An empty set is returned when the package has none.
+func (p *Package) ExtendedProperties() (*common.ExtendedProperties, error) {
+	if p.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+
+	partPath := p.extendedPropertiesPath()
+	if partPath == "" {
+		return common.NewExtendedProperties(), nil
+	}
+
+	part, err := p.GetPart(partPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := part.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	props := common.NewExtendedProperties()
+	if err := p.DecodeXML(data, props); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// SetExtendedProperties writes extended properties to docProps/app.xml and
+// ensures its relationship and content type.
+func (p *Package) SetExtendedProperties(props *common.ExtendedProperties) error {
+	if p.closed {
+		return utils.ErrDocumentClosed
+	}
+	if props == nil {
+		return utils.NewValidationError("extendedProperties", "cannot be nil", nil)
+	}
+
+	// Preserved elements may use the vt: prefix.
+	props.XMLNSVT = common.NSDocPropsVTypes
+	data, err := utils.MarshalXMLWithHeader(props)
+	if err != nil {
+		return err
+	}
+
+	partPath := p.extendedPropertiesPath()
+	if partPath == "" {
+		partPath = AppPropertiesPath
+	}
+	part, err := p.GetPart(partPath)
+	if err != nil {
+		if _, err := p.AddPart(partPath, ContentTypeExtendedProps, data); err != nil {
+			return err
+		}
+	} else if err := part.SetContent(data); err != nil {
+		return err
+	}
+
+	p.contentTypes.EnsureContentType(partPath, ContentTypeExtendedProps)
+
+	rels := p.GetRelationships("")
+	if rels.FirstByType(RelTypeExtendedProps) == nil {
+		rels.Add(RelTypeExtendedProps, partPath, TargetModeInternal)
+	}
+	p.modified = true
+	return nil
+}
+
+// extendedPropertiesPath resolves the extended properties part, or "".
+func (p *Package) extendedPropertiesPath() string {
+	if rel := p.GetRelationships("").FirstByType(RelTypeExtendedProps); rel != nil && rel.TargetMode != TargetModeExternal {
+		return ResolveRelationshipTarget("", rel.Target)
+	}
+	if p.PartExists(AppPropertiesPath) {
+		return AppPropertiesPath
+	}
+	return ""
+}
@@ -0,0 +1,238 @@
+package packaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// NSFlatOPC is the namespace of the Flat OPC (single XML file) package format.
+const NSFlatOPC = "http://schemas.microsoft.com/office/2006/xmlPackage"
+
+// flatPackage is the pkg:package root of a Flat OPC document.
+type flatPackage struct {
+	XMLName xml.Name   `xml:"http://schemas.microsoft.com/office/2006/xmlPackage package"`
+	Parts   []flatPart `xml:"http://schemas.microsoft.com/office/2006/xmlPackage part"`
+}
+
+// flatPart is a pkg:part carrying either inline XML or base64 binary data.
+type flatPart struct {
+	Name        string       `xml:"http://schemas.microsoft.com/office/2006/xmlPackage name,attr"`
+	ContentType string       `xml:"http://schemas.microsoft.com/office/2006/xmlPackage contentType,attr"`
+	XMLData     *flatXMLData `xml:"http://schemas.microsoft.com/office/2006/xmlPackage xmlData"`
+	BinaryData  string       `xml:"http://schemas.microsoft.com/office/2006/xmlPackage binaryData"`
+}
+
+type flatXMLData struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+// OpenFlatOPC reads a Flat OPC package (pkg:package), as saved by Word and
+// PowerPoint as "XML Document" / "XML Presentation". Part content types
+// become [Content_Types].xml entries and .rels parts become relationships.
+func OpenFlatOPC(r io.Reader) (*Package, error) {
+	return OpenFlatOPCWithOptions(r, nil)
+}
+
+// OpenFlatOPCWithOptions reads a Flat OPC package like OpenFlatOPC, enforcing
+// the resource limits of opts. The file is read whole, so its size counts
+// against MaxTotalSize; the XML limits apply when parts are decoded.
+func OpenFlatOPCWithOptions(r io.Reader, opts *OpenOptions) (*Package, error) {
+	raw, err := opts.readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var flat flatPackage
+	if err := utils.NewXMLDecoder(bytes.NewReader(raw)).Decode(&flat); err != nil {
+		return nil, err
+	}
+	if opts != nil && opts.MaxParts > 0 && len(flat.Parts) > opts.MaxParts {
+		return nil, &utils.LimitError{Limit: "part count", Max: int64(opts.MaxParts)}
+	}
+
+	pkg := New()
+	pkg.xmlLimits = opts.xmlLimits()
+	for _, fp := range flat.Parts {
+		uri := normalizePath(fp.Name)
+		if uri == "." || uri == ContentTypesPath {
+			continue
+		}
+
+		var content []byte
+		if fp.XMLData != nil {
+			content = append([]byte(utils.XMLHeader), bytes.TrimSpace(fp.XMLData.Inner)...)
+		} else {
+			data, err := base64.StdEncoding.DecodeString(stripWhitespace(fp.BinaryData))
+			if err != nil {
+				return nil, utils.NewValidationError("binaryData", "invalid base64", fp.Name)
+			}
+			content = data
+		}
+		if opts != nil && opts.MaxPartSize > 0 && int64(len(content)) > opts.MaxPartSize {
+			return nil, &utils.LimitError{Limit: "part size", Max: opts.MaxPartSize, Part: uri}
+		}
+
+		if strings.HasSuffix(uri, ".rels") {
+			rels := &Relationships{}
+			if err := pkg.DecodeXML(content, rels); err != nil {
+				return nil, err
+			}
+			pkg.relationships[sourceURIForRelationshipsPath(uri)] = rels
+			continue
+		}
+
+		part := newPart(uri, fp.ContentType, content, pkg)
+		part.modified = true
+		pkg.parts[uri] = part
+		if ext := path.Ext(uri); !isXMLContentType(fp.ContentType) && ext != "" && pkg.contentTypes.GetContentType(uri) == "" {
+			pkg.contentTypes.AddDefault(ext, fp.ContentType)
+		} else {
+			pkg.contentTypes.EnsureContentType(uri, fp.ContentType)
+		}
+	}
+
+	if len(pkg.parts) == 0 {
+		return nil, utils.ErrInvalidFormat
+	}
+	pkg.modified = false
+	return pkg, nil
+}
+
+// WriteFlatOPC writes the package as a single Flat OPC XML document. XML
+// parts are embedded inline; all other parts are base64 encoded.
+func (p *Package) WriteFlatOPC(w io.Writer) error {
+	if p.closed {
+		return utils.ErrDocumentClosed
+	}
+
+	type flatEntry struct {
+		contentType string
+		data        []byte
+	}
+	entries := make(map[string]flatEntry)
+	for sourceURI, rels := range p.relationships {
+		if len(rels.Relationships) == 0 {
+			continue
+		}
+		relsPath := PackageRelsPath
+		if sourceURI != "" && sourceURI != "." {
+			relsPath = RelationshipsPathForPart(sourceURI)
+		}
+		data, err := xml.Marshal(rels)
+		if err != nil {
+			return err
+		}
+		entries[relsPath] = flatEntry{contentType: ContentTypeRelationships, data: data}
+	}
+	for uri, part := range p.parts {
+		if uri == ContentTypesPath || strings.HasSuffix(uri, ".rels") {
+			continue
+		}
+		data, err := part.Content()
+		if err != nil {
+			return err
+		}
+		entries[uri] = flatEntry{contentType: p.GetContentType(uri), data: data}
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(utils.XMLHeader)
+	if progID := p.flatOPCProgID(); progID != "" {
+		bw.WriteString(`<?mso-application progid="` + progID + `"?>` + "\n")
+	}
+	bw.WriteString(`<pkg:package xmlns:pkg="` + NSFlatOPC + `">`)
+	for _, name := range names {
+		entry := entries[name]
+		bw.WriteString(`<pkg:part pkg:name="`)
+		xml.EscapeText(bw, []byte("/"+name))
+		bw.WriteString(`" pkg:contentType="`)
+		xml.EscapeText(bw, []byte(entry.contentType))
+		bw.WriteString(`">`)
+		if inner, ok := flatXMLBody(entry.contentType, entry.data); ok {
+			bw.WriteString("<pkg:xmlData>")
+			bw.Write(inner)
+			bw.WriteString("</pkg:xmlData>")
+		} else {
+			bw.WriteString("<pkg:binaryData>")
+			writeBase64Lines(bw, entry.data)
+			bw.WriteString("</pkg:binaryData>")
+		}
+		bw.WriteString("</pkg:part>")
+	}
+	bw.WriteString("</pkg:package>")
+	return bw.Flush()
+}
+
+// flatOPCProgID returns the mso-application program ID for the main part.
+func (p *Package) flatOPCProgID() string {
+	rel := p.GetRelationships("").FirstByType(RelTypeOfficeDocument)
+	if rel == nil {
+		return ""
+	}
+	contentType := p.GetContentType(ResolveRelationshipTarget("", rel.Target))
+	switch {
+	case strings.Contains(contentType, "wordprocessingml"):
+		return "Word.Document"
+	case strings.Contains(contentType, "presentationml"):
+		return "PowerPoint.Show"
+	}
+	return ""
+}
+
+// flatXMLBody returns XML part content without its declaration, or false
+// when the part must be stored as binary data.
+func flatXMLBody(contentType string, data []byte) ([]byte, bool) {
+	if !isXMLContentType(contentType) {
+		return nil, false
+	}
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("<?xml")) {
+		end := bytes.Index(data, []byte("?>"))
+		if end < 0 {
+			return nil, false
+		}
+		data = bytes.TrimSpace(data[end+2:])
+	}
+	if !bytes.HasPrefix(data, []byte("<")) {
+		return nil, false
+	}
+	return data, true
+}
+
+func isXMLContentType(contentType string) bool {
+	return strings.HasSuffix(contentType, "+xml") || contentType == ContentTypeXML || contentType == "text/xml"
+}
+
+// writeBase64Lines writes data as base64 in 76-character lines, as Office does.
+func writeBase64Lines(w *bufio.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.WriteString(encoded[:76])
+		w.WriteByte('\n')
+		encoded = encoded[76:]
+	}
+	w.WriteString(encoded)
+}
+
+func stripWhitespace(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,176 @@
+package packaging
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+func TestFlatOPC_RoundTrip(t *testing.T) {
+	png := bytes.Repeat([]byte{0x89, 'P', 'N', 'G', 0x00, 0xFF}, 40)
+	emf := []byte{0x01, 0x00, 0x00, 0x00, 0x6C}
+
+	pkg := New()
+	_, _ = pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>a &amp; b</w:t></w:r></w:p></w:body></w:document>`))
+	_, _ = pkg.AddPart("word/media/image1.png", ContentTypePNG, png)
+	_, _ = pkg.AddPart("word/media/image2.emf", ContentTypeEMF, emf)
+	pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	pkg.AddRelationship("word/document.xml", "media/image1.png", RelTypeImage)
+	pkg.AddRelationship("word/document.xml", "media/image2.emf", RelTypeImage)
+
+	var buf bytes.Buffer
+	if err := pkg.WriteFlatOPC(&buf); err != nil {
+		t.Fatalf("WriteFlatOPC() error = %v", err)
+	}
+	flat := buf.String()
+	for _, want := range []string{
+		`<?mso-application progid="Word.Document"?>`,
+		`<pkg:part pkg:name="/_rels/.rels" pkg:contentType="` + ContentTypeRelationships + `">`,
+		`<pkg:xmlData><w:document xmlns:w=`,
+		`<w:t>a &amp; b</w:t>`,
+		`<pkg:part pkg:name="/word/media/image1.png" pkg:contentType="image/png"><pkg:binaryData>`,
+	} {
+		if !strings.Contains(flat, want) {
+			t.Errorf("flat output missing %q", want)
+		}
+	}
+	if strings.Contains(flat, ContentTypesPath) {
+		t.Error("flat output should not contain [Content_Types].xml")
+	}
+
+	reopened, err := OpenFlatOPC(strings.NewReader(flat))
+	if err != nil {
+		t.Fatalf("OpenFlatOPC() error = %v", err)
+	}
+	for uri, want := range map[string][]byte{"word/media/image1.png": png, "word/media/image2.emf": emf} {
+		part, err := reopened.GetPart(uri)
+		if err != nil {
+			t.Fatalf("GetPart(%q) error = %v", uri, err)
+		}
+		got, _ := part.Content()
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s content changed in round trip", uri)
+		}
+	}
+	if ct := reopened.GetContentType("word/media/image2.emf"); ct != ContentTypeEMF {
+		t.Errorf("emf content type = %q, want %q", ct, ContentTypeEMF)
+	}
+	if ct := reopened.GetContentType("word/document.xml"); ct != ContentTypeWordDocument {
+		t.Errorf("document content type = %q, want %q", ct, ContentTypeWordDocument)
+	}
+	if rels := reopened.GetRelationships("word/document.xml"); len(rels.ByType(RelTypeImage)) != 2 {
+		t.Errorf("document image relationships = %d, want 2", len(rels.ByType(RelTypeImage)))
+	}
+
+	// The imported package saves as a regular ZIP package.
+	var zipped bytes.Buffer
+	if err := reopened.WriteTo(&zipped); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	fromZip, err := OpenBytes(zipped.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer fromZip.Close()
+	part, err := fromZip.GetPart("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := part.Content()
+	if !bytes.Contains(content, []byte(`<w:t>a &amp; b</w:t>`)) {
+		t.Errorf("document.xml = %s", content)
+	}
+}
+
+func TestOpenFlatOPC_OfficeOutput(t *testing.T) {
+	// Abridged from a presentation saved by PowerPoint as XML.
+	flat := `<?xml version="1.0" standalone="yes"?>
+<?mso-application progid="PowerPoint.Show"?>
+<pkg:package xmlns:pkg="http://schemas.microsoft.com/office/2006/xmlPackage">
+  <pkg:part pkg:name="/_rels/.rels" pkg:contentType="application/vnd.openxmlformats-package.relationships+xml" pkg:padding="512">
+    <pkg:xmlData>
+      <Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="ppt/presentation.xml"/></Relationships>
+    </pkg:xmlData>
+  </pkg:part>
+  <pkg:part pkg:name="/ppt/presentation.xml" pkg:contentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml">
+    <pkg:xmlData>
+      <p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"/>
+    </pkg:xmlData>
+  </pkg:part>
+  <pkg:part pkg:name="/docProps/thumbnail.jpeg" pkg:contentType="image/jpeg" pkg:compression="store">
+    <pkg:binaryData>/9j/4AAQ
+SkZJRg==</pkg:binaryData>
+  </pkg:part>
+</pkg:package>`
+
+	pkg, err := OpenFlatOPC(strings.NewReader(flat))
+	if err != nil {
+		t.Fatalf("OpenFlatOPC() error = %v", err)
+	}
+	rel := pkg.GetRelationships("").FirstByType(RelTypeOfficeDocument)
+	if rel == nil || rel.Target != "ppt/presentation.xml" {
+		t.Fatalf("officeDocument relationship = %+v", rel)
+	}
+	part, err := pkg.GetPart("docProps/thumbnail.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := part.Content(); !bytes.Equal(data, []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F'}) {
+		t.Errorf("thumbnail = % x", data)
+	}
+	if pkg.flatOPCProgID() != "PowerPoint.Show" {
+		t.Errorf("flatOPCProgID() = %q", pkg.flatOPCProgID())
+	}
+
+	if _, err := OpenFlatOPC(strings.NewReader(`<pkg:package xmlns:pkg="` + NSFlatOPC + `"/>`)); err == nil {
+		t.Error("OpenFlatOPC() of an empty package should fail")
+	}
+}
+
+func TestFlatOPC_OpenResourceLimits(t *testing.T) {
+	pkg := New()
+	_, _ = pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<document/>`))
+	_, _ = pkg.AddPart("word/media/image1.png", ContentTypePNG, make([]byte, 4096))
+	pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	var buf bytes.Buffer
+	if err := pkg.WriteFlatOPC(&buf); err != nil {
+		t.Fatal(err)
+	}
+	flat := buf.String()
+
+	tests := []struct {
+		name  string
+		opts  *OpenOptions
+		limit string
+	}{
+		{"no limits", nil, ""},
+		{"generous limits", &OpenOptions{MaxTotalSize: 1 << 20, MaxPartSize: 8192, MaxParts: 10, MaxXMLDepth: 32, MaxXMLTokens: 1000}, ""},
+		{"total size", &OpenOptions{MaxTotalSize: 1024}, "total size"},
+		{"part count", &OpenOptions{MaxParts: 1}, "part count"},
+		{"part size", &OpenOptions{MaxPartSize: 1024}, "part size"},
+		{"xml depth", &OpenOptions{MaxXMLDepth: 1}, "xml depth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, err := OpenFlatOPCWithOptions(strings.NewReader(flat), tt.opts)
+			if tt.limit == "" {
+				if err != nil {
+					t.Fatalf("OpenFlatOPCWithOptions() error = %v", err)
+				}
+				opened.Close()
+				return
+			}
+			var limitErr *utils.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("OpenFlatOPCWithOptions() error = %v, want *utils.LimitError", err)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("Limit = %q, want %q", limitErr.Limit, tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+package packaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// A Package is a read-only file system of its entries, laid out as they are
+// written to the ZIP archive.
+var (
+	_ fs.FS         = (*Package)(nil)
+	_ fs.ReadFileFS = (*Package)(nil)
+)
+
+// Open opens a package entry such as "word/document.xml" or
+// "[Content_Types].xml". Directories list the entries below them. Open
+// implements fs.FS, so a package can be walked with fs.WalkDir.
+func (p *Package) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if p.closed {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrClosed}
+	}
+	files, err := p.fsFiles()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if entry, ok := files[name]; ok {
+		data, err := entry.read()
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &fsFile{info: fsInfo{name: path.Base(name), size: int64(len(data))}, r: bytes.NewReader(data)}, nil
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	children := make(map[string]fs.DirEntry)
+	for file := range files {
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(file, prefix)
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			children[rest[:i]] = fs.FileInfoToDirEntry(fsInfo{name: rest[:i], dir: true})
+		} else if _, ok := children[rest]; !ok {
+			size, err := files[file].size()
+			if err != nil {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			children[rest] = fs.FileInfoToDirEntry(fsInfo{name: rest, size: size})
+		}
+	}
+	if len(children) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	dir := &fsDir{info: fsInfo{name: path.Base(name), dir: true}}
+	for _, entry := range children {
+		dir.entries = append(dir.entries, entry)
+	}
+	sort.Slice(dir.entries, func(i, j int) bool { return dir.entries[i].Name() < dir.entries[j].Name() })
+	return dir, nil
+}
+
+// ReadFile returns the content of a package entry, implementing fs.ReadFileFS.
+func (p *Package) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	if p.closed {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrClosed}
+	}
+	files, err := p.fsFiles()
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	entry, ok := files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	data, err := entry.read()
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// fsEntry reads a package entry. part is set for entries backed by a part.
+type fsEntry struct {
+	read func() ([]byte, error)
+	part *Part
+}
+
+// size returns the size of the entry without decompressing unloaded parts.
+func (e fsEntry) size() (int64, error) {
+	if e.part != nil {
+		return int64(e.part.Size()), nil
+	}
+	data, err := e.read()
+	return int64(len(data)), err
+}
+
+// fsFiles maps every entry name to its content.
+func (p *Package) fsFiles() (map[string]fsEntry, error) {
+	entries, err := p.entries(false)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]fsEntry, len(entries)+1)
+	files[ContentTypesPath] = fsEntry{read: p.contentTypesXML}
+	for name, entry := range entries {
+		entry := entry
+		if entry.part != nil {
+			files[name] = fsEntry{read: entry.part.Content, part: entry.part}
+		} else {
+			files[name] = fsEntry{read: func() ([]byte, error) { return entry.data, nil }}
+		}
+	}
+	return files, nil
+}
+
+// OpenFS reads a package from an unzipped directory tree, such as one
+// written by WriteDir, os.DirFS or an embed.FS. Every regular file becomes a
+// package entry; [Content_Types].xml must be at the root.
+func OpenFS(fsys fs.FS) (*Package, error) {
+	pkg := &Package{
+		parts:         make(map[string]*Part),
+		relationships: make(map[string]*Relationships),
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		pkg.parts[name] = newPart(name, "", data, pkg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := pkg.parseIndex(context.Background()); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// WriteDir writes every package entry as a file below dir, creating
+// directories as needed. With pretty set, XML entries are indented for
+// review; text content and elements with xml:space="preserve" are left as
+// they are. Files in dir that are not package entries are not removed, so
+// write to an empty directory when parts may have been deleted.
+func (p *Package) WriteDir(dir string, pretty bool) error {
+	if p.closed {
+		return utils.ErrDocumentClosed
+	}
+	if dir == "" {
+		return utils.ErrPathNotSet
+	}
+	files, err := p.fsFiles()
+	if err != nil {
+		return err
+	}
+	// Part names come from the archive or AddPart; refuse any that would
+	// land outside dir before writing anything.
+	for name := range files {
+		if !fs.ValidPath(name) || !filepath.IsLocal(filepath.FromSlash(name)) {
+			return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+		}
+	}
+	for name, entry := range files {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		data, err := entry.read()
+		if err != nil {
+			return err
+		}
+		if pretty && (name == ContentTypesPath || hasStrictNames(p.fsContentType(name))) {
+			if indented, err := indentXML(data); err == nil {
+				data = indented
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fsContentType returns the content type of an entry, including the
+// relationship parts that are not held as parts.
+func (p *Package) fsContentType(name string) string {
+	if strings.HasSuffix(name, ".rels") {
+		return ContentTypeRelationships
+	}
+	return p.GetContentType(name)
+}
+
+// indentXML re-indents element-only content. Whitespace is only added or
+// removed between tags of elements that contain no text of their own.
+func indentXML(data []byte) ([]byte, error) {
+	type token struct {
+		raw   []byte
+		tok   xml.Token
+		depth int
+	}
+	type element struct {
+		children bool
+		text     bool
+		preserve bool
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var tokens []token
+	var stack []*element
+	elements := make(map[int]*element) // start and end token index -> element
+	offset := int64(0)
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := dec.InputOffset()
+		t := token{raw: data[offset:end], tok: xml.CopyToken(tok), depth: len(stack)}
+		offset = end
+		index := len(tokens)
+		switch v := tok.(type) {
+		case xml.StartElement:
+			if len(stack) > 0 {
+				stack[len(stack)-1].children = true
+			}
+			el := &element{}
+			for _, attr := range v.Attr {
+				if attr.Name.Space == "xml" && attr.Name.Local == "space" && attr.Value == "preserve" {
+					el.preserve = true
+				}
+			}
+			if len(stack) > 0 && stack[len(stack)-1].preserve {
+				el.preserve = true
+			}
+			elements[index] = el
+			stack = append(stack, el)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, utils.ErrInvalidFormat
+			}
+			elements[index] = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			t.depth = len(stack)
+		case xml.CharData:
+			if len(stack) > 0 && len(bytes.TrimSpace(v)) > 0 {
+				stack[len(stack)-1].text = true
+			}
+		}
+		tokens = append(tokens, t)
+	}
+	if len(stack) != 0 {
+		return nil, utils.ErrInvalidFormat
+	}
+
+	// indentable reports whether whitespace may be changed inside el.
+	indentable := func(el *element) bool {
+		return el == nil || (el.children && !el.text && !el.preserve)
+	}
+	var out bytes.Buffer
+	stack = stack[:0]
+	newline := func(depth int) {
+		if out.Len() > 0 {
+			out.WriteByte('\n')
+			out.WriteString(strings.Repeat("  ", depth))
+		}
+	}
+	for i, t := range tokens {
+		var parent *element
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+		switch t.tok.(type) {
+		case xml.StartElement:
+			if indentable(parent) {
+				newline(t.depth)
+			}
+			out.Write(t.raw)
+			stack = append(stack, elements[i])
+			continue
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			if len(t.raw) == 0 {
+				continue // end of a self-closing element
+			}
+			if indentable(elements[i]) {
+				newline(t.depth)
+			}
+		case xml.CharData:
+			if indentable(parent) && len(bytes.TrimSpace(t.raw)) == 0 {
+				continue
+			}
+		default:
+			if indentable(parent) {
+				newline(t.depth)
+			}
+		}
+		out.Write(t.raw)
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// fsFile is an open package entry.
+type fsFile struct {
+	info fsInfo
+	r    *bytes.Reader
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *fsFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *fsFile) Close() error               { return nil }
+
+// fsDir is an open directory of package entries.
+type fsDir struct {
+	info    fsInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *fsDir) Close() error               { return nil }
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
+
+// fsInfo describes a package entry or directory.
+type fsInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i fsInfo) Name() string       { return i.name }
+func (i fsInfo) Size() int64        { return i.size }
+func (i fsInfo) ModTime() time.Time { return time.Time{} }
+func (i fsInfo) IsDir() bool        { return i.dir }
+func (i fsInfo) Sys() interface{}   { return nil }
+
+func (i fsInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
@@ -0,0 +1,195 @@
+package packaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+func newFSTestPackage() *Package {
+	pkg := New()
+	_, _ = pkg.AddPart(WordDocumentPath, ContentTypeWordDocument, []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="`+NSWordprocessingML+`"><w:body><w:p><w:r><w:t xml:space="preserve">  two  spaces </w:t></w:r><w:r><w:t>a &amp; b</w:t></w:r></w:p><w:p/></w:body></w:document>`))
+	_, _ = pkg.AddPart("word/media/image1.png", ContentTypePNG, []byte{0x89, 'P', 'N', 'G', '\n', 0})
+	pkg.AddRelationship("", WordDocumentPath, RelTypeOfficeDocument)
+	pkg.AddRelationship(WordDocumentPath, "media/image1.png", RelTypeImage)
+	return pkg
+}
+
+func TestPackage_FS(t *testing.T) {
+	pkg := newFSTestPackage()
+	if err := fstest.TestFS(pkg, ContentTypesPath, PackageRelsPath, WordDocumentPath, "word/_rels/document.xml.rels", "word/media/image1.png"); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	_ = fs.WalkDir(pkg, ".", func(name string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			names = append(names, name)
+		}
+		return err
+	})
+	if want := "[Content_Types].xml _rels/.rels word/_rels/document.xml.rels word/document.xml word/media/image1.png"; strings.Join(names, " ") != want {
+		t.Errorf("WalkDir() = %v, want %s", names, want)
+	}
+	if _, err := fs.ReadFile(pkg, "word/missing.xml"); !os.IsNotExist(err) {
+		t.Errorf("ReadFile() of a missing entry error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestPackage_FSWalkKeepsPartsUnloaded(t *testing.T) {
+	var src bytes.Buffer
+	if err := newFSTestPackage().WriteTo(&src); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := OpenBytes(src.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pkg.Close()
+
+	sizes := make(map[string]int64)
+	err = fs.WalkDir(pkg, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sizes[name] = info.Size()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sizes["word/media/image1.png"]; got != 6 {
+		t.Errorf("image size = %d, want 6", got)
+	}
+	for _, uri := range []string{WordDocumentPath, "word/media/image1.png"} {
+		part, err := pkg.GetPart(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.IsLoaded() {
+			t.Errorf("walking the package loaded %s", uri)
+		}
+	}
+}
+
+func TestPackage_WriteDirRoundTrip(t *testing.T) {
+	for _, pretty := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := newFSTestPackage().WriteDir(dir, pretty); err != nil {
+			t.Fatalf("WriteDir(pretty=%v) error = %v", pretty, err)
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, "word", "document.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pretty != bytes.Contains(raw, []byte("\n    <w:p>")) {
+			t.Errorf("pretty=%v document.xml = %s", pretty, raw)
+		}
+
+		pkg, err := OpenFS(os.DirFS(dir))
+		if err != nil {
+			t.Fatalf("OpenFS() error = %v", err)
+		}
+		if pkg.MainPartPath() != WordDocumentPath || pkg.GetContentType(WordDocumentPath) != ContentTypeWordDocument {
+			t.Errorf("main part = %q (%q)", pkg.MainPartPath(), pkg.GetContentType(WordDocumentPath))
+		}
+		if rel := pkg.GetRelationships(WordDocumentPath).FirstByType(RelTypeImage); rel == nil || rel.Target != "media/image1.png" {
+			t.Errorf("image relationship = %+v", rel)
+		}
+		image, _ := pkg.ReadFile("word/media/image1.png")
+		if !bytes.Equal(image, []byte{0x89, 'P', 'N', 'G', '\n', 0}) {
+			t.Errorf("image = % x", image)
+		}
+		doc, _ := pkg.ReadFile(WordDocumentPath)
+		for _, want := range []string{`<w:t xml:space="preserve">  two  spaces </w:t>`, `<w:t>a &amp; b</w:t>`} {
+			if !bytes.Contains(doc, []byte(want)) {
+				t.Errorf("pretty=%v document.xml lost %s", pretty, want)
+			}
+		}
+
+		var zipped bytes.Buffer
+		if err := pkg.WriteTo(&zipped); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenBytes(zipped.Bytes()); err != nil {
+			t.Errorf("zip written from directory does not open: %v", err)
+		}
+	}
+}
+
+func TestPackage_WriteDirRejectsEscapingNames(t *testing.T) {
+	// A ZIP entry named "../../escaped.xml" is refused when opening.
+	var src bytes.Buffer
+	if err := newFSTestPackage().WriteTo(&src); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(src.Bytes()), int64(src.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		if err := zw.Copy(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, _ := zw.Create("../../escaped.xml")
+	_, _ = w.Write([]byte("<x/>"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenReader(bytes.NewReader(out.Bytes()), int64(out.Len())); !errors.Is(err, utils.ErrInvalidFormat) {
+		t.Errorf("OpenReader() error = %v, want ErrInvalidFormat", err)
+	}
+
+	// Names added in memory are checked again by WriteDir.
+	base := t.TempDir()
+	pkg := newFSTestPackage()
+	_, _ = pkg.AddPart("../../escaped.xml", ContentTypeXML, []byte("<x/>"))
+	if err := pkg.WriteDir(filepath.Join(base, "a", "b"), false); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("WriteDir() error = %v, want fs.ErrInvalid", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "escaped.xml")); !os.IsNotExist(err) {
+		t.Errorf("WriteDir() wrote outside its directory: %v", err)
+	}
+}
+
+func TestIndentXML(t *testing.T) {
+	in := `<?xml version="1.0"?>` + "\n" +
+		`<root><a x="1"><b/><c>text</c></a><mixed>one <i>two</i> three</mixed><!-- note --><keep xml:space="preserve"><k> </k></keep></root>`
+	want := `<?xml version="1.0"?>
+<root>
+  <a x="1">
+    <b/>
+    <c>text</c>
+  </a>
+  <mixed>one <i>two</i> three</mixed>
+  <!-- note -->
+  <keep xml:space="preserve"><k> </k></keep>
+</root>
+`
+	got, err := indentXML([]byte(in))
+	if err != nil {
+		t.Fatalf("indentXML() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("indentXML() =\n%s\nwant\n%s", got, want)
+	}
+	if _, err := indentXML([]byte(`<a><b></a>`)); err == nil {
+		t.Error("indentXML() of malformed XML should fail")
+	}
+}
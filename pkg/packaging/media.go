@@ -0,0 +1,218 @@
+package packaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+type mediaHash [sha256.Size]byte
+
+// isMediaPart reports whether uri lies in a media folder (word/media,
+// ppt/media, xl/media, ...).
+func isMediaPart(uri string) bool {
+	return path.Base(path.Dir(uri)) == "media"
+}
+
+// AddMedia adds a media part at uri, unless a media part with the same
+// content type and bytes already exists. It returns the URI of the part to
+// reference, which is the existing part when one was reused.
+func (p *Package) AddMedia(uri, contentType string, data []byte) (string, error) {
+	if p.closed {
+		return "", utils.ErrDocumentClosed
+	}
+	if err := p.indexMedia(len(data)); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	if existing, ok := p.mediaIndex[sum]; ok {
+		if p.GetContentType(existing) == contentType {
+			if part, err := p.GetPart(existing); err == nil {
+				if same, err := partEquals(part, bytes.NewReader(data)); err == nil && same {
+					return existing, nil
+				}
+			}
+		}
+	}
+	part, err := p.AddPart(uri, contentType, data)
+	if err != nil {
+		return "", err
+	}
+	p.mediaIndex[sum] = part.URI()
+	p.mediaSums[part.URI()] = sum
+	return part.URI(), nil
+}
+
+// indexMedia hashes the media parts of the given size that are not in the
+// media index yet, or all of them when size is negative. Parts are hashed
+// through Stream, so media of other sizes is never decompressed.
+func (p *Package) indexMedia(size int) error {
+	if p.mediaIndex == nil {
+		p.mediaIndex = make(map[mediaHash]string)
+		p.mediaSums = make(map[string]mediaHash)
+	}
+	uris := make([]string, 0, len(p.parts))
+	for uri, part := range p.parts {
+		if _, ok := p.mediaSums[uri]; ok || !isMediaPart(uri) {
+			continue
+		}
+		if size < 0 || part.Size() == size {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		sum, err := hashPart(p.parts[uri])
+		if err != nil {
+			return err
+		}
+		p.mediaSums[uri] = sum
+		if _, ok := p.mediaIndex[sum]; !ok {
+			p.mediaIndex[sum] = uri
+		}
+	}
+	return nil
+}
+
+// hashPart returns the SHA-256 of a part's content, read through Stream.
+func hashPart(part *Part) (mediaHash, error) {
+	var sum mediaHash
+	rc, err := part.Stream()
+	if err != nil {
+		return sum, err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// partEquals reports whether the content of part, read through Stream, is
+// the content of r.
+func partEquals(part *Part, r io.Reader) (bool, error) {
+	rc, err := part.Stream()
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+	a, b := make([]byte, 32*1024), make([]byte, 32*1024)
+	for {
+		na, errA := io.ReadFull(rc, a)
+		nb, errB := io.ReadFull(r, b)
+		if !bytes.Equal(a[:na], b[:nb]) {
+			return false, nil
+		}
+		endA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		endB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		if errA != nil && !endA {
+			return false, errA
+		}
+		if errB != nil && !endB {
+			return false, errB
+		}
+		if endA || endB {
+			return endA == endB, nil
+		}
+	}
+}
+
+// DedupeMedia merges media parts with identical content and content type.
+// Relationships to a duplicate are retargeted to the first part in name
+// order, and the duplicates are deleted. It returns the sorted URIs of the
+// removed parts.
+func (p *Package) DedupeMedia() ([]string, error) {
+	if p.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+	p.mediaIndex, p.mediaSums = nil, nil
+	if err := p.indexMedia(-1); err != nil {
+		return nil, err
+	}
+
+	replacements := make(map[string]string)
+	for uri, part := range p.parts {
+		if !isMediaPart(uri) {
+			continue
+		}
+		keep := p.mediaIndex[p.mediaSums[uri]]
+		if keep == uri || p.GetContentType(keep) != p.GetContentType(uri) {
+			continue
+		}
+		kept, err := p.GetPart(keep)
+		if err != nil || kept.Size() != part.Size() {
+			continue
+		}
+		rc, err := part.Stream()
+		if err != nil {
+			return nil, err
+		}
+		same, err := partEquals(kept, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			replacements[uri] = keep
+		}
+	}
+	if len(replacements) == 0 {
+		return nil, nil
+	}
+
+	for source, rels := range p.relationships {
+		sourceURI := source
+		if sourceURI == "." {
+			sourceURI = ""
+		}
+		for i := range rels.Relationships {
+			rel := &rels.Relationships[i]
+			if rel.TargetMode == TargetModeExternal {
+				continue
+			}
+			keep, ok := replacements[ResolveRelationshipTarget(sourceURI, rel.Target)]
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				rel.Target = "/" + keep
+			} else {
+				rel.Target = relativeTarget(sourceURI, keep)
+			}
+		}
+	}
+
+	removed := make([]string, 0, len(replacements))
+	for uri := range replacements {
+		if err := p.DeletePart(uri); err != nil {
+			return nil, err
+		}
+		p.removeRelationshipsOf(uri)
+		removed = append(removed, uri)
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
+
+// relativeTarget returns the relationship target that reaches target from
+// the part source.
+func relativeTarget(source, target string) string {
+	dir := path.Dir(source)
+	if dir == "." {
+		return target
+	}
+	from := strings.Split(dir, "/")
+	to := strings.Split(target, "/")
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+	return strings.Repeat("../", len(from)-common) + strings.Join(to[common:], "/")
+}
@@ -0,0 +1,136 @@
+package packaging
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPackage_AddMedia(t *testing.T) {
+	logo := []byte{0x89, 'P', 'N', 'G', 1, 2, 3}
+	pkg := New()
+
+	first, err := pkg.AddMedia("ppt/media/image1.png", ContentTypePNG, logo)
+	if err != nil {
+		t.Fatalf("AddMedia() error = %v", err)
+	}
+	second, err := pkg.AddMedia("ppt/media/image2.png", ContentTypePNG, append([]byte(nil), logo...))
+	if err != nil {
+		t.Fatalf("AddMedia() error = %v", err)
+	}
+	if first != "ppt/media/image1.png" || second != first {
+		t.Errorf("AddMedia() = %q, %q, want both %q", first, second, "ppt/media/image1.png")
+	}
+	if pkg.PartExists("ppt/media/image2.png") {
+		t.Error("duplicate media part was added")
+	}
+	other, _ := pkg.AddMedia("ppt/media/image3.png", ContentTypePNG, []byte{0x89, 'P', 'N', 'G', 9})
+	if other != "ppt/media/image3.png" {
+		t.Errorf("AddMedia() of new content = %q", other)
+	}
+
+	// A replaced part is no longer reused.
+	part, _ := pkg.GetPart(first)
+	_ = part.SetContent([]byte("changed"))
+	if uri, _ := pkg.AddMedia("ppt/media/image4.png", ContentTypePNG, logo); uri != "ppt/media/image4.png" {
+		t.Errorf("AddMedia() after change = %q, want a new part", uri)
+	}
+}
+
+func TestPackage_AddMediaKeepsPartsUnloaded(t *testing.T) {
+	logo := []byte{0x89, 'P', 'N', 'G', 1, 2, 3}
+	src := New()
+	_, _ = src.AddPart("ppt/media/image1.png", ContentTypePNG, logo)
+	_, _ = src.AddPart("ppt/media/video1.mp4", "video/mp4", bytes.Repeat([]byte{7}, 4096))
+	var buf bytes.Buffer
+	if err := src.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pkg.Close()
+
+	uri, err := pkg.AddMedia("ppt/media/image2.png", ContentTypePNG, append([]byte(nil), logo...))
+	if err != nil {
+		t.Fatalf("AddMedia() error = %v", err)
+	}
+	if uri != "ppt/media/image1.png" {
+		t.Errorf("AddMedia() = %q, want %q", uri, "ppt/media/image1.png")
+	}
+	for _, name := range []string{"ppt/media/image1.png", "ppt/media/video1.mp4"} {
+		if part, _ := pkg.GetPart(name); part == nil || part.IsLoaded() {
+			t.Errorf("AddMedia() loaded %s", name)
+		}
+	}
+}
+
+func TestPackage_DedupeMedia(t *testing.T) {
+	logo := []byte{0xFF, 0xD8, 0xFF, 0xE0, 'l', 'o', 'g', 'o'}
+	pkg := New()
+	_, _ = pkg.AddPart(PresentationPath, ContentTypePresentation, []byte(`<p:presentation/>`))
+	pkg.AddRelationship("", PresentationPath, RelTypeOfficeDocument)
+	for i, slide := range []string{"ppt/slides/slide1.xml", "ppt/slides/slide2.xml", "ppt/slides/slide3.xml"} {
+		_, _ = pkg.AddPart(slide, ContentTypeSlide, []byte(`<p:sld/>`))
+		pkg.AddRelationship(PresentationPath, "slides/"+slide[len("ppt/slides/"):], RelTypeSlide)
+		media := []string{"ppt/media/image1.jpeg", "ppt/media/image2.jpeg", "ppt/media/image3.jpeg"}[i]
+		_, _ = pkg.AddPart(media, ContentTypeJPEG, logo)
+		pkg.AddRelationship(slide, "../media/"+media[len("ppt/media/"):], RelTypeImage)
+	}
+	_, _ = pkg.AddPart("ppt/media/image4.jpeg", ContentTypeJPEG, []byte{0xFF, 0xD8, 'x'})
+	pkg.AddRelationship("ppt/slides/slide3.xml", "/ppt/media/image4.jpeg", RelTypeImage)
+	pkg.AddRelationship("ppt/slides/slide3.xml", "/ppt/media/image2.jpeg", RelTypeImage)
+
+	removed, err := pkg.DedupeMedia()
+	if err != nil {
+		t.Fatalf("DedupeMedia() error = %v", err)
+	}
+	if want := []string{"ppt/media/image2.jpeg", "ppt/media/image3.jpeg"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("DedupeMedia() = %v, want %v", removed, want)
+	}
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+	for _, slide := range []string{"ppt/slides/slide1.xml", "ppt/slides/slide2.xml", "ppt/slides/slide3.xml"} {
+		rel := saved.GetRelationships(slide).Relationships[0]
+		if rel.Target != "../media/image1.jpeg" {
+			t.Errorf("%s image target = %q, want ../media/image1.jpeg", slide, rel.Target)
+		}
+	}
+	rels := saved.GetRelationships("ppt/slides/slide3.xml").Relationships
+	if rels[1].Target != "/ppt/media/image4.jpeg" || rels[2].Target != "/ppt/media/image1.jpeg" {
+		t.Errorf("absolute targets = %q, %q", rels[1].Target, rels[2].Target)
+	}
+	if issues, err := saved.Validate(); err != nil || len(issues) != 0 {
+		t.Errorf("Validate() = %v, %v", issues, err)
+	}
+
+	if removed, err := saved.DedupeMedia(); err != nil || len(removed) != 0 {
+		t.Errorf("second DedupeMedia() = %v, %v", removed, err)
+	}
+}
+
+func TestRelativeTarget(t *testing.T) {
+	for _, tc := range []struct{ source, target, want string }{
+		{"", "word/document.xml", "word/document.xml"},
+		{"word/document.xml", "word/media/image1.png", "media/image1.png"},
+		{"ppt/slides/slide1.xml", "ppt/media/image1.png", "../media/image1.png"},
+		{"word/charts/chart1.xml", "word/embeddings/a.xlsx", "../embeddings/a.xlsx"},
+		{"xl/drawings/drawing1.xml", "docProps/thumbnail.jpeg", "../../docProps/thumbnail.jpeg"},
+	} {
+		if got := relativeTarget(tc.source, tc.target); got != tc.want {
+			t.Errorf("relativeTarget(%q, %q) = %q, want %q", tc.source, tc.target, got, tc.want)
+		}
+		if got := ResolveRelationshipTarget(tc.source, relativeTarget(tc.source, tc.target)); got != tc.target {
+			t.Errorf("round trip of %q from %q = %q", tc.target, tc.source, got)
+		}
+	}
+}
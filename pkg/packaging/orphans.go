@@ -0,0 +1,73 @@
+package packaging
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// OrphanParts returns the sorted URIs of parts that no chain of internal
+// relationships from the package root reaches.
+func (p *Package) OrphanParts() ([]string, error) {
+	if p.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+	reachable := p.reachableParts()
+	var orphans []string
+	for _, uri := range p.validationPartNames() {
+		if !reachable[uri] {
+			orphans = append(orphans, uri)
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphanParts deletes every orphan part together with its content type
+// override and its own relationships, and returns the removed part URIs.
+func (p *Package) RemoveOrphanParts() ([]string, error) {
+	orphans, err := p.OrphanParts()
+	if err != nil {
+		return nil, err
+	}
+	for _, uri := range orphans {
+		if err := p.DeletePart(uri); err != nil {
+			return nil, err
+		}
+		p.removeRelationshipsOf(uri)
+	}
+
+	// Relationship parts whose source no longer exists
+	for source := range p.relationships {
+		if source != "" && source != "." && !p.PartExists(source) {
+			p.removeRelationshipsOf(source)
+		}
+	}
+	for uri := range p.parts {
+		if !strings.HasSuffix(uri, ".rels") || uri == PackageRelsPath {
+			continue
+		}
+		if !p.PartExists(sourceURIForRelationshipsPath(uri)) {
+			delete(p.parts, uri)
+			p.contentTypes.RemoveOverride(uri)
+		}
+	}
+
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// removeRelationshipsOf drops the relationships whose source is uri.
+func (p *Package) removeRelationshipsOf(uri string) {
+	uri = normalizePath(uri)
+	if _, ok := p.relationships[uri]; ok {
+		delete(p.relationships, uri)
+		p.modified = true
+	}
+	relsPath := RelationshipsPathForPart(uri)
+	if _, ok := p.parts[relsPath]; ok {
+		delete(p.parts, relsPath)
+		p.contentTypes.RemoveOverride(relsPath)
+		p.modified = true
+	}
+}
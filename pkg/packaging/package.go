@@ -3,12 +3,18 @@ package packaging
 import (
 	"archive/zip"
 	"bytes"
+	"compress/flate"
+	"context"
 	"encoding/xml"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/rcarmo/go-ooxml/pkg/utils"
 )
@@ -19,12 +25,123 @@ type Package struct {
 	contentTypes  *ContentTypes
 	parts         map[string]*Part
 	relationships map[string]*Relationships // key is source part URI ("" for package-level)
+	source        *os.File                  // backing file for lazily loaded parts
+	password      string                    // encrypts the package on save when set
+	xmlLimits     utils.XMLLimits           // applied when decoding part XML
+	strict        bool                      // read from an ISO/IEC 29500 Strict file
+	embedded      *EmbeddedObject           // parent part written on Save, if any
+	mediaIndex    map[mediaHash]string      // media content hash -> part URI, built by AddMedia
+	mediaSums     map[string]mediaHash      // media part URI -> content hash, for parts in mediaIndex
+	recover       bool                      // opened with OpenOptions.Recover
+	diagnostics   []Diagnostic              // problems recovered from while opening
 	closed        bool
 	modified      bool
 }
 
 // Open opens an existing OPC package from a file path.
 func Open(filePath string) (*Package, error) {
+	return OpenWithOptions(filePath, nil)
+}
+
+// OpenOptions configures how a package is opened.
+type OpenOptions struct {
+	// Password decrypts password-protected packages. Opening an encrypted
+	// package without it fails with utils.ErrPasswordRequired.
+	Password string
+
+	// Resource limits for untrusted input. Zero fields are unlimited; a
+	// package exceeding any of them fails with a *utils.LimitError.
+
+	// MaxTotalSize caps the total uncompressed size of all parts. Encrypted
+	// and Flat OPC packages are read whole, so their file size counts too.
+	MaxTotalSize int64
+	// MaxPartSize caps the uncompressed size of any single part.
+	MaxPartSize int64
+	// MaxParts caps the number of ZIP entries.
+	MaxParts int
+	// MaxCompressionRatio caps uncompressed/compressed size per entry.
+	// Entries under 1 MiB are exempt, as small XML parts compress very well.
+	MaxCompressionRatio int
+	// MaxXMLDepth caps element nesting when decoding part XML.
+	MaxXMLDepth int
+	// MaxXMLTokens caps the number of XML tokens in a single part.
+	MaxXMLTokens int64
+
+	// Recover loads whatever can be loaded from a damaged package. Malformed
+	// relationship parts are ignored, a broken [Content_Types].xml is rebuilt
+	// from part names, and the document, spreadsheet and presentation
+	// packages drop parts they cannot parse. Each problem is recorded as a
+	// Diagnostic. Limit violations and encryption errors still fail.
+	Recover bool
+}
+
+// compressionRatioThreshold is the entry size below which
+// OpenOptions.MaxCompressionRatio is not enforced.
+const compressionRatioThreshold = 1 << 20
+
+// xmlLimits returns the XML decoding limits set by opts.
+func (opts *OpenOptions) xmlLimits() utils.XMLLimits {
+	if opts == nil {
+		return utils.XMLLimits{}
+	}
+	return utils.XMLLimits{MaxDepth: opts.MaxXMLDepth, MaxTokens: opts.MaxXMLTokens}
+}
+
+// readAll reads r whole for input that is decoded in memory rather than
+// indexed, failing with a *utils.LimitError once it exceeds MaxTotalSize.
+func (opts *OpenOptions) readAll(r io.Reader) ([]byte, error) {
+	if opts == nil || opts.MaxTotalSize <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, opts.MaxTotalSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > opts.MaxTotalSize {
+		return nil, &utils.LimitError{Limit: "total size", Max: opts.MaxTotalSize}
+	}
+	return data, nil
+}
+
+// checkEntries enforces the archive limits of opts against the ZIP directory.
+// Declared sizes can be trusted: archive/zip rejects entries that inflate
+// beyond them.
+func (opts *OpenOptions) checkEntries(files []*zip.File) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.MaxParts > 0 && len(files) > opts.MaxParts {
+		return &utils.LimitError{Limit: "part count", Max: int64(opts.MaxParts)}
+	}
+	var total uint64
+	for _, f := range files {
+		size := f.UncompressedSize64
+		if opts.MaxPartSize > 0 && size > uint64(opts.MaxPartSize) {
+			return &utils.LimitError{Limit: "part size", Max: opts.MaxPartSize, Part: f.Name}
+		}
+		if opts.MaxCompressionRatio > 0 && size >= compressionRatioThreshold {
+			compressed := f.CompressedSize64
+			if compressed == 0 {
+				compressed = 1
+			}
+			if size/compressed > uint64(opts.MaxCompressionRatio) {
+				return &utils.LimitError{Limit: "compression ratio", Max: int64(opts.MaxCompressionRatio), Part: f.Name}
+			}
+		}
+		total += size
+		if opts.MaxTotalSize > 0 && total > uint64(opts.MaxTotalSize) {
+			return &utils.LimitError{Limit: "total size", Max: opts.MaxTotalSize}
+		}
+	}
+	return nil
+}
+
+// OpenWithOptions opens an existing OPC package from a file path using opts.
+func OpenWithOptions(filePath string, opts *OpenOptions) (*Package, error) {
+	return openFile(context.Background(), filePath, opts)
+}
+
+func openFile(ctx context.Context, filePath string, opts *OpenOptions) (*Package, error) {
 	if filePath == "" {
 		return nil, utils.ErrPathNotSet
 	}
@@ -33,61 +150,142 @@ func Open(filePath string) (*Package, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
 	stat, err := f.Stat()
 	if err != nil {
+		f.Close()
 		return nil, err
 	}
 
-	pkg, err := OpenReader(f, stat.Size())
+	pkg, err := openReader(ctx, f, stat.Size(), opts)
 	if err != nil {
+		f.Close()
 		return nil, err
 	}
 	pkg.path = cleanPath
+	if pkg.password != "" {
+		// Decrypted parts live in memory; the file is no longer needed.
+		f.Close()
+	} else {
+		pkg.source = f
+	}
 	return pkg, nil
 }
 
 // OpenReader opens an OPC package from an io.ReaderAt.
+//
+// Only [Content_Types].xml and the relationship parts are read up front;
+// all other parts are decompressed on demand, so r must remain readable
+// until the package is closed.
 func OpenReader(r io.ReaderAt, size int64) (*Package, error) {
+	return OpenReaderWithOptions(r, size, nil)
+}
+
+// OpenReaderWithOptions opens an OPC package from an io.ReaderAt using opts.
+// Encrypted packages are decrypted into memory; otherwise r must remain
+// readable until the package is closed.
+func OpenReaderWithOptions(r io.ReaderAt, size int64, opts *OpenOptions) (*Package, error) {
+	return openReader(context.Background(), r, size, opts)
+}
+
+func openReader(ctx context.Context, r io.ReaderAt, size int64, opts *OpenOptions) (*Package, error) {
+	header := make([]byte, len(cfbSignature))
+	if n, _ := r.ReadAt(header, 0); n == len(header) && isCompoundFile(header) {
+		return openEncrypted(ctx, r, size, opts)
+	}
+	return openZip(ctx, r, size, opts)
+}
+
+// openEncrypted decrypts a password-protected package held in a compound file.
+func openEncrypted(ctx context.Context, r io.ReaderAt, size int64, opts *OpenOptions) (*Package, error) {
+	if opts == nil || opts.Password == "" {
+		return nil, utils.ErrPasswordRequired
+	}
+	// The compound file is read whole, so its size counts against
+	// MaxTotalSize before the decrypted archive is checked entry by entry.
+	data, err := opts.readAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decryptPackage(data, opts.Password, opts.xmlLimits())
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := openZip(ctx, bytes.NewReader(plain), int64(len(plain)), opts)
+	if err != nil {
+		return nil, err
+	}
+	pkg.password = opts.Password
+	return pkg, nil
+}
+
+// openZip indexes the parts of a ZIP package, giving up once ctx is done.
+func openZip(ctx context.Context, r io.ReaderAt, size int64, opts *OpenOptions) (*Package, error) {
 	zr, err := zip.NewReader(r, size)
 	if err != nil {
 		return nil, err
 	}
+	if err := opts.checkEntries(zr.File); err != nil {
+		return nil, err
+	}
 
 	pkg := &Package{
 		parts:         make(map[string]*Part),
 		relationships: make(map[string]*Relationships),
+		xmlLimits:     opts.xmlLimits(),
+		recover:       opts != nil && opts.Recover,
 	}
-
-	// Read all files from ZIP
+	// Index all files from ZIP; content is read on demand
 	for _, f := range zr.File {
-		content, err := readZipFile(f)
-		if err != nil {
-			return nil, err
-		}
-
 		// Normalize path (remove leading /)
 		uri := strings.TrimPrefix(f.Name, "/")
-		pkg.parts[uri] = newPart(uri, "", content, pkg)
+		if strings.HasSuffix(uri, "/") && f.UncompressedSize64 == 0 {
+			continue // directory entry, not a part
+		}
+		// Names such as "../x.xml" would escape the target of WriteDir.
+		if !fs.ValidPath(uri) {
+			return nil, fmt.Errorf("%w: invalid part name %q", utils.ErrInvalidFormat, f.Name)
+		}
+		pkg.parts[uri] = newZipPart(uri, f, pkg)
 	}
 
-	// Parse [Content_Types].xml
-	if err := pkg.parseContentTypes(); err != nil {
+	if err := pkg.parseIndex(ctx); err != nil {
 		return nil, err
 	}
+	return pkg, nil
+}
+
+// parseIndex reads [Content_Types].xml and the relationship parts of a
+// package whose parts have been loaded or indexed.
+func (p *Package) parseIndex(ctx context.Context) error {
+	// Parse [Content_Types].xml
+	if err := p.parseContentTypes(ctx); err != nil {
+		return err
+	}
 
 	// Set content types on parts
-	for uri, part := range pkg.parts {
-		part.contentType = pkg.contentTypes.GetContentType(uri)
+	for uri, part := range p.parts {
+		part.contentType = p.contentTypes.GetContentType(uri)
 	}
 
 	// Parse relationships
-	if err := pkg.parseRelationships(); err != nil {
-		return nil, err
+	if err := p.parseRelationships(ctx); err != nil {
+		return err
 	}
+	p.convertStrictRelationships()
+	return nil
+}
 
-	return pkg, nil
+// DecodeXML unmarshals part XML, enforcing the XML limits the package was
+// opened with.
+func (p *Package) DecodeXML(data []byte, v interface{}) error {
+	return p.DecodeXMLContext(context.Background(), data, v)
+}
+
+// DecodeXMLContext unmarshals part XML like DecodeXML and stops with
+// ctx.Err() once ctx is done.
+func (p *Package) DecodeXMLContext(ctx context.Context, data []byte, v interface{}) error {
+	return utils.UnmarshalXMLContext(ctx, data, v, p.xmlLimits)
 }
 
 // OpenBytes opens an OPC package from a byte slice.
@@ -105,16 +303,135 @@ func New() *Package {
 	}
 }
 
-// Save saves the package to its original path.
+// Save saves the package to its original path. A package opened with
+// EmbeddedObject.Open is written back into its parent package instead.
 func (p *Package) Save() error {
+	if p.embedded != nil {
+		return p.SaveWithOptions(nil)
+	}
 	if p.path == "" {
 		return utils.ErrPathNotSet
 	}
 	return p.SaveAs(p.path)
 }
 
+// SaveOptions configures how a package is written.
+type SaveOptions struct {
+	// RemoveOrphanParts drops every part that cannot be reached from the
+	// package relationships, along with its content type override.
+	RemoveOrphanParts bool
+	// Password encrypts the saved package (Agile encryption, AES-256/SHA-512).
+	// It is remembered for later saves; see Package.SetPassword.
+	Password string
+	// UpdateExtendedProperties recomputes the docProps/app.xml statistics
+	// and part titles from the content before saving. It is honoured by the
+	// document, spreadsheet and presentation packages when saving with
+	// options (SaveWithOptions, SaveAsWithOptions and the Context variants);
+	// Save, SaveAs and WriteTo leave docProps/app.xml as it is.
+	UpdateExtendedProperties bool
+	// DedupeMedia merges media parts with identical content before saving;
+	// see Package.DedupeMedia.
+	DedupeMedia bool
+	// StripMacros removes any VBA project before saving and switches a
+	// macro-enabled main part to its macro-free variant.
+	StripMacros bool
+	// Strict writes ISO/IEC 29500 Strict namespaces and relationship types
+	// instead of Transitional ones. Content is not otherwise checked for
+	// Strict conformance.
+	Strict bool
+	// Deterministic produces byte-identical output for identical content:
+	// every entry is recompressed and stamped with DeterministicModTime.
+	Deterministic bool
+	// CompressionLevel is the deflate level, from flate.BestSpeed (1) to
+	// flate.BestCompression (9), or flate.HuffmanOnly. Zero keeps
+	// flate.DefaultCompression. Setting a level recompresses every entry
+	// instead of copying untouched ones.
+	CompressionLevel int
+	// StoreMedia stores already-compressed media (PNG, JPEG, GIF, MP3, MP4,
+	// ...) without deflating it again, which saves time at almost no cost
+	// in size.
+	StoreMedia bool
+	// ParallelDeflate compresses parts of at least ParallelDeflateThreshold
+	// bytes concurrently before writing them. Their compressed data is held
+	// in memory until written.
+	ParallelDeflate bool
+}
+
+// DeterministicModTime is the ZIP entry time used by SaveOptions.Deterministic.
+var DeterministicModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// SaveWithOptions saves the package to its original path using opts. Like
+// Save, it writes an embedded package back into its parent.
+func (p *Package) SaveWithOptions(opts *SaveOptions) error {
+	return p.saveWithOptions(context.Background(), opts)
+}
+
+func (p *Package) saveWithOptions(ctx context.Context, opts *SaveOptions) error {
+	if p.embedded != nil {
+		if err := p.applySaveOptions(opts); err != nil {
+			return err
+		}
+		return p.saveEmbedded(ctx, opts)
+	}
+	if p.path == "" {
+		return utils.ErrPathNotSet
+	}
+	return p.saveAsWithOptions(ctx, p.path, opts)
+}
+
+// SaveAsWithOptions saves the package to a new path using opts.
+func (p *Package) SaveAsWithOptions(filePath string, opts *SaveOptions) error {
+	return p.saveAsWithOptions(context.Background(), filePath, opts)
+}
+
+func (p *Package) saveAsWithOptions(ctx context.Context, filePath string, opts *SaveOptions) error {
+	if err := p.applySaveOptions(opts); err != nil {
+		return err
+	}
+	return p.saveAs(ctx, filePath, opts)
+}
+
+// applySaveOptions performs the package rewrites requested by opts.
+func (p *Package) applySaveOptions(opts *SaveOptions) error {
+	if p.closed {
+		return utils.ErrDocumentClosed
+	}
+	if opts == nil {
+		return nil
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if opts.RemoveOrphanParts {
+		if _, err := p.RemoveOrphanParts(); err != nil {
+			return err
+		}
+	}
+	if opts.DedupeMedia {
+		if _, err := p.DedupeMedia(); err != nil {
+			return err
+		}
+	}
+	if opts.StripMacros {
+		if _, err := p.RemoveMacros(); err != nil {
+			return err
+		}
+	}
+	if opts.Password != "" {
+		p.password = opts.Password
+	}
+	return nil
+}
+
 // SaveAs saves the package to a new path.
 func (p *Package) SaveAs(filePath string) error {
+	return p.saveAs(context.Background(), filePath, nil)
+}
+
+// saveAs writes the package to filePath. The archive is written to a
+// temporary file in the same directory and renamed over filePath only once
+// complete, so an error or a canceled context never damages an existing file.
+func (p *Package) saveAs(ctx context.Context, filePath string, opts *SaveOptions) error {
 	if p.closed {
 		return utils.ErrDocumentClosed
 	}
@@ -122,14 +439,46 @@ func (p *Package) SaveAs(filePath string) error {
 	if filePath == "" {
 		return utils.ErrPathNotSet
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	cleanPath := filepath.Clean(filePath)
-	f, err := os.Create(cleanPath)
+	if p.isSourceFile(cleanPath) {
+		// Overwriting the file that backs lazy parts: pull them into memory first.
+		if err := p.detachSource(); err != nil {
+			return err
+		}
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(cleanPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(cleanPath), "."+filepath.Base(cleanPath)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	tmpPath := f.Name()
 
-	if err := p.WriteTo(f); err != nil {
+	if p.password != "" {
+		var buf bytes.Buffer
+		err = p.writeZip(ctx, &buf, opts)
+		if err == nil {
+			err = encryptPackage(f, buf.Bytes(), p.password)
+		}
+	} else {
+		err = p.writeZip(ctx, f, opts)
+	}
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, cleanPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
 
@@ -138,22 +487,194 @@ func (p *Package) SaveAs(filePath string) error {
 	return nil
 }
 
-// WriteTo writes the package to an io.Writer.
+// WriteTo writes the package to an io.Writer as an unencrypted ZIP archive.
 func (p *Package) WriteTo(w io.Writer) error {
+	return p.writeZip(context.Background(), w, nil)
+}
+
+// WriteToWithOptions writes the package to an io.Writer using opts. Like
+// WriteTo, the output is never encrypted.
+func (p *Package) WriteToWithOptions(w io.Writer, opts *SaveOptions) error {
+	if err := p.applySaveOptions(opts); err != nil {
+		return err
+	}
+	return p.writeZip(context.Background(), w, opts)
+}
+
+// WriterTo returns an io.WriterTo that writes the package the way SaveAs
+// would, encrypting it when a password is set.
+func (p *Package) WriterTo() io.WriterTo {
+	return packageWriterTo{p}
+}
+
+// packageWriterTo adapts a Package to io.WriterTo.
+type packageWriterTo struct {
+	p *Package
+}
+
+func (pw packageWriterTo) WriteTo(w io.Writer) (int64, error) {
+	return pw.p.writeTo(context.Background(), w, nil)
+}
+
+// writeTo writes the package to w the way SaveAs would once opts have been
+// applied, encrypting it when a password is set, and returns the number of
+// bytes written.
+func (p *Package) writeTo(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error) {
+	if err := p.applySaveOptions(opts); err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	cw := &countingWriter{w: w}
+	if p.password == "" {
+		err := p.writeZip(ctx, cw, opts)
+		return cw.n, err
+	}
+	var buf bytes.Buffer
+	if err := p.writeZip(ctx, &buf, opts); err != nil {
+		return 0, err
+	}
+	err := encryptPackage(cw, buf.Bytes(), p.password)
+	return cw.n, err
+}
+
+// writeZip writes [Content_Types].xml first and every other entry in name
+// order, so the layout never depends on map iteration. It stops between
+// entries once ctx is done. opts may be nil.
+func (p *Package) writeZip(ctx context.Context, w io.Writer, opts *SaveOptions) error {
 	zw := zip.NewWriter(w)
-	defer zw.Close()
+	deterministic := opts != nil && opts.Deterministic
+	strict := opts != nil && opts.Strict
+	level := opts.compressionLevel()
+	if level != flate.DefaultCompression {
+		zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, level)
+		})
+	}
+
+	header := func(name, contentType string) *zip.FileHeader {
+		h := &zip.FileHeader{Name: name, Method: opts.method(name, contentType)}
+		if deterministic {
+			h.Modified = DeterministicModTime
+		}
+		return h
+	}
 
 	// Write [Content_Types].xml first
-	ctData, err := xml.Marshal(p.contentTypes)
+	ctData, err := p.contentTypesXML()
 	if err != nil {
 		return err
 	}
-	ctData = append([]byte(utils.XMLHeader), ctData...)
-	if err := writeZipFile(zw, ContentTypesPath, ctData); err != nil {
+	if err := writeZipEntry(zw, header(ContentTypesPath, ContentTypeXML), ctData); err != nil {
+		return err
+	}
+
+	entries, err := p.entries(strict)
+	if err != nil {
 		return err
 	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Untouched parts are copied without recompression, unless their
+	// namespaces change between Strict and Transitional.
+	copyRaw := func(part *Part) bool {
+		return opts.canCopy(part) && (!hasStrictNames(part.contentType) || p.strict == strict)
+	}
+	content := func(part *Part) ([]byte, error) {
+		data, err := part.Content()
+		if err != nil {
+			return nil, err
+		}
+		if strict && hasStrictNames(part.contentType) {
+			data = toStrict(data)
+		}
+		return data, nil
+	}
+
+	var deflated map[string]*deflatedEntry
+	if opts != nil && opts.ParallelDeflate {
+		var large []string
+		for _, name := range names {
+			part := entries[name].part
+			if part != nil && !copyRaw(part) && opts.method(name, part.contentType) == zip.Deflate && part.Size() >= ParallelDeflateThreshold {
+				large = append(large, name)
+			}
+		}
+		if len(large) > 1 {
+			deflated, err = deflateParallel(large, func(name string) ([]byte, error) {
+				return content(entries[name].part)
+			}, level)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry := entries[name]
+		if entry.part == nil {
+			if err := writeZipEntry(zw, header(name, ContentTypeRelationships), entry.data); err != nil {
+				return err
+			}
+			continue
+		}
+		if copyRaw(entry.part) {
+			if err := copyZipFile(zw, name, entry.part.zipFile); err != nil {
+				return err
+			}
+			continue
+		}
+		if d, ok := deflated[name]; ok {
+			fw, err := zw.CreateRaw(d.header(header(name, entry.part.contentType)))
+			if err != nil {
+				return err
+			}
+			if _, err := fw.Write(d.data); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := content(entry.part)
+		if err != nil {
+			return err
+		}
+		if err := writeZipEntry(zw, header(name, entry.part.contentType), data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// packageEntry is a saved package entry: serialized relationships or a part.
+type packageEntry struct {
+	data []byte
+	part *Part
+}
+
+// contentTypesXML serializes [Content_Types].xml.
+func (p *Package) contentTypesXML() ([]byte, error) {
+	data, err := xml.Marshal(p.contentTypes)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(utils.XMLHeader), data...), nil
+}
 
-	// Write relationships files
+// entries returns every saved entry other than [Content_Types].xml, keyed by
+// name. Relationship parts are serialized from the package relationships.
+func (p *Package) entries(strict bool) (map[string]packageEntry, error) {
+	entries := make(map[string]packageEntry)
+
+	// Relationships files
 	for sourceURI, rels := range p.relationships {
 		if len(rels.Relationships) == 0 {
 			continue
@@ -166,33 +687,34 @@ func (p *Package) WriteTo(w io.Writer) error {
 		}
 		relsData, err := xml.Marshal(rels)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		relsData = append([]byte(utils.XMLHeader), relsData...)
-		if err := writeZipFile(zw, relsPath, relsData); err != nil {
-			return err
+		if strict {
+			relsData = toStrict(relsData)
 		}
+		entries[relsPath] = packageEntry{data: append([]byte(utils.XMLHeader), relsData...)}
 	}
 
-	// Write all parts
+	// All other parts, skipping [Content_Types].xml and .rels files
 	for uri, part := range p.parts {
-		// Skip [Content_Types].xml and .rels files (already written)
 		if uri == ContentTypesPath || strings.HasSuffix(uri, ".rels") {
 			continue
 		}
-		if err := writeZipFile(zw, uri, part.content); err != nil {
-			return err
-		}
+		entries[uri] = packageEntry{part: part}
 	}
-
-	return nil
+	return entries, nil
 }
 
-// Close closes the package.
+// Close closes the package and releases the backing file, if any.
 func (p *Package) Close() error {
 	p.closed = true
 	p.parts = nil
 	p.relationships = nil
+	if p.source != nil {
+		err := p.source.Close()
+		p.source = nil
+		return err
+	}
 	return nil
 }
 
@@ -301,59 +823,128 @@ func (p *Package) Path() string {
 	return p.path
 }
 
+// IsEncrypted reports whether the package is saved password-protected.
+func (p *Package) IsEncrypted() bool {
+	return p.password != ""
+}
+
+// SetPassword sets the password used to encrypt the package when it is
+// saved. An empty password saves the package unencrypted.
+func (p *Package) SetPassword(password string) {
+	p.password = password
+	p.modified = true
+}
+
 // IsModified returns true if the package has been modified.
 func (p *Package) IsModified() bool {
 	return p.modified
 }
 
-// parseContentTypes reads and parses [Content_Types].xml.
-func (p *Package) parseContentTypes() error {
+// parseContentTypes reads and parses [Content_Types].xml. In recover mode a
+// missing or malformed index is rebuilt from the part names.
+func (p *Package) parseContentTypes(ctx context.Context) error {
+	err := p.decodeContentTypes(ctx)
+	if err == nil || !p.CanRecover(err) {
+		return err
+	}
+	p.rebuildContentTypes()
+	p.AddDiagnostic(Diagnostic{PartURI: ContentTypesPath, Severity: SeverityWarning, Err: err, Action: "rebuilt content types from part names"})
+	return nil
+}
+
+func (p *Package) decodeContentTypes(ctx context.Context) error {
 	part, ok := p.parts[ContentTypesPath]
 	if !ok {
 		return utils.ErrMissingContentTypes
 	}
 
+	content, err := part.Content()
+	if err != nil {
+		return err
+	}
+
 	p.contentTypes = &ContentTypes{}
-	if err := utils.UnmarshalXML(part.content, p.contentTypes); err != nil {
+	if err := p.DecodeXMLContext(ctx, content, p.contentTypes); err != nil {
 		return err
 	}
 	return nil
 }
 
 // parseRelationships reads and parses all .rels files.
-func (p *Package) parseRelationships() error {
+func (p *Package) parseRelationships(ctx context.Context) error {
 	for uri, part := range p.parts {
 		if !strings.HasSuffix(uri, ".rels") {
 			continue
 		}
-
-		rels := &Relationships{}
-		if err := utils.UnmarshalXML(part.content, rels); err != nil {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 
-		// Determine source part URI
-		sourceURI := ""
-		if uri != PackageRelsPath {
-			// Extract source part from .rels path
-			// e.g., "word/_rels/document.xml.rels" -> "word/document.xml"
-			dir := path.Dir(path.Dir(uri))
-			base := strings.TrimSuffix(path.Base(uri), ".rels")
-			if dir == "." {
-				sourceURI = base
-			} else {
-				sourceURI = dir + "/" + base
+		content, err := part.Content()
+		rels := &Relationships{}
+		if err == nil {
+			err = p.DecodeXMLContext(ctx, content, rels)
+		}
+		if err != nil {
+			if !p.CanRecover(err) {
+				return err
 			}
+			p.AddDiagnostic(Diagnostic{PartURI: uri, Severity: SeverityWarning, Err: err, Action: "ignored relationships part"})
+			continue
 		}
-		sourceURI = normalizePath(sourceURI)
 
+		sourceURI := sourceURIForRelationshipsPath(uri)
 		p.relationships[sourceURI] = rels
 	}
 	return nil
 }
 
+// isSourceFile reports whether filePath refers to the file backing lazy parts.
+func (p *Package) isSourceFile(filePath string) bool {
+	if p.source == nil {
+		return false
+	}
+	target, err := os.Stat(filePath)
+	if err != nil {
+		return false
+	}
+	source, err := p.source.Stat()
+	if err != nil {
+		return false
+	}
+	return os.SameFile(target, source)
+}
+
+// detachSource loads every lazy part into memory and releases the backing file.
+func (p *Package) detachSource() error {
+	for _, part := range p.parts {
+		if err := part.load(); err != nil {
+			return err
+		}
+		part.zipFile = nil
+	}
+	if p.source == nil {
+		return nil
+	}
+	err := p.source.Close()
+	p.source = nil
+	return err
+}
+
 // Helper functions
 
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
 func readZipFile(f *zip.File) ([]byte, error) {
 	rc, err := f.Open()
 	if err != nil {
@@ -363,8 +954,8 @@ func readZipFile(f *zip.File) ([]byte, error) {
 	return io.ReadAll(rc)
 }
 
-func writeZipFile(zw *zip.Writer, name string, data []byte) error {
-	w, err := zw.Create(name)
+func writeZipEntry(zw *zip.Writer, header *zip.FileHeader, data []byte) error {
+	w, err := zw.CreateHeader(header)
 	if err != nil {
 		return err
 	}
@@ -372,6 +963,21 @@ func writeZipFile(zw *zip.Writer, name string, data []byte) error {
 	return err
 }
 
+func copyZipFile(zw *zip.Writer, name string, f *zip.File) error {
+	header := f.FileHeader
+	header.Name = name
+	w, err := zw.CreateRaw(&header)
+	if err != nil {
+		return err
+	}
+	rc, err := f.OpenRaw()
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, rc)
+	return err
+}
+
 func normalizePath(p string) string {
 	p = strings.TrimPrefix(p, "/")
 	return path.Clean(p)
@@ -1,12 +1,17 @@
 package packaging
 
 import (
+	"archive/zip"
 	"bytes"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
 )
 
 func TestNewPackage(t *testing.T) {
@@ -223,6 +228,52 @@ func TestPackage_OpenBytes(t *testing.T) {
 	}
 }
 
+func TestPackage_OpenResourceLimits(t *testing.T) {
+	pkg := New()
+	_, _ = pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<document/>`))
+	_, _ = pkg.AddPart("word/media/zeros.bin", "application/octet-stream", make([]byte, 4<<20))
+	pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	tests := []struct {
+		name  string
+		opts  *OpenOptions
+		limit string
+	}{
+		{"no limits", nil, ""},
+		{"generous limits", &OpenOptions{MaxTotalSize: 8 << 20, MaxPartSize: 4 << 20, MaxParts: 10, MaxCompressionRatio: 10000, MaxXMLDepth: 32, MaxXMLTokens: 1000}, ""},
+		{"part count", &OpenOptions{MaxParts: 2}, "part count"},
+		{"part size", &OpenOptions{MaxPartSize: 1 << 20}, "part size"},
+		{"total size", &OpenOptions{MaxTotalSize: 1 << 20}, "total size"},
+		{"compression ratio", &OpenOptions{MaxCompressionRatio: 100}, "compression ratio"},
+		{"xml depth", &OpenOptions{MaxXMLDepth: 1}, "xml depth"},
+		{"xml tokens", &OpenOptions{MaxXMLTokens: 3}, "xml tokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, err := OpenReaderWithOptions(bytes.NewReader(data), int64(len(data)), tt.opts)
+			if tt.limit == "" {
+				if err != nil {
+					t.Fatalf("OpenReaderWithOptions() error = %v", err)
+				}
+				opened.Close()
+				return
+			}
+			var limitErr *utils.LimitError
+			if !errors.As(err, &limitErr) || !errors.Is(err, utils.ErrLimitExceeded) {
+				t.Fatalf("OpenReaderWithOptions() error = %v, want *utils.LimitError", err)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("Limit = %q, want %q", limitErr.Limit, tt.limit)
+			}
+		})
+	}
+}
+
 func TestPackage_ClosedOperations(t *testing.T) {
 	pkg := New()
 	pkg.Close()
@@ -266,6 +317,103 @@ func TestPackage_WriteTo(t *testing.T) {
 	}
 }
 
+func TestPackage_WriterTo(t *testing.T) {
+	pkg := New()
+	_, _ = pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<document/>`))
+	pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+
+	var _ io.WriterTo = pkg.WriterTo()
+	var buf bytes.Buffer
+	n, err := pkg.WriterTo().WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo() = %d, %v; wrote %d bytes", n, err, buf.Len())
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("PK")) {
+		t.Error("output is not a valid ZIP file")
+	}
+
+	// With a password the output is encrypted like SaveAs.
+	pkg.SetPassword("writer")
+	buf.Reset()
+	if _, err := pkg.WriterTo().WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if _, err := OpenBytes(buf.Bytes()); !errors.Is(err, utils.ErrPasswordRequired) {
+		t.Fatalf("OpenBytes() error = %v, want ErrPasswordRequired", err)
+	}
+	reopened, err := OpenReaderWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), &OpenOptions{Password: "writer"})
+	if err != nil {
+		t.Fatalf("OpenReaderWithOptions() error = %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.GetPart("word/document.xml"); err != nil {
+		t.Errorf("GetPart() error = %v", err)
+	}
+}
+
+func TestPackage_WriteToDeterministic(t *testing.T) {
+	build := func() []byte {
+		pkg := New()
+		_, _ = pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<document/>`))
+		_, _ = pkg.AddPart("word/styles.xml", ContentTypeStyles, []byte(`<styles/>`))
+		_, _ = pkg.AddPart("docProps/core.xml", ContentTypeCoreProps, []byte(`<coreProperties/>`))
+		pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+		pkg.AddRelationship("", "docProps/core.xml", RelTypeCoreProps)
+		pkg.AddRelationship("word/document.xml", "styles.xml", RelTypeStyles)
+
+		var buf bytes.Buffer
+		if err := pkg.WriteToWithOptions(&buf, &SaveOptions{Deterministic: true}); err != nil {
+			t.Fatalf("WriteToWithOptions() error = %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	first := build()
+	for i := 0; i < 5; i++ {
+		if !bytes.Equal(first, build()) {
+			t.Fatal("deterministic output differs between runs")
+		}
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		ContentTypesPath,
+		PackageRelsPath,
+		"docProps/core.xml",
+		"word/_rels/document.xml.rels",
+		"word/document.xml",
+		"word/styles.xml",
+	}
+	if len(zr.File) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(zr.File), len(want))
+	}
+	for i, f := range zr.File {
+		if f.Name != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, f.Name, want[i])
+		}
+		if !f.Modified.Equal(DeterministicModTime) {
+			t.Errorf("entry %q modified = %v, want %v", f.Name, f.Modified, DeterministicModTime)
+		}
+	}
+
+	// Reopened packages recompress lazily loaded parts to the same bytes.
+	reopened, err := OpenBytes(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	var buf bytes.Buffer
+	if err := reopened.WriteToWithOptions(&buf, &SaveOptions{Deterministic: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, buf.Bytes()) {
+		t.Error("re-saving a deterministic package changed its bytes")
+	}
+}
+
 func TestPart_Stream(t *testing.T) {
 	pkg := New()
 	content := []byte("test content for streaming")
@@ -405,6 +553,182 @@ func TestPackage_CorePropertiesRoundTrip(t *testing.T) {
 	}
 }
 
+func TestPackage_ExtendedPropertiesRoundTrip(t *testing.T) {
+	pkg := New()
+	appXML := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"><Application>Microsoft Excel</Application><HeadingPairs><vt:vector size="2" baseType="variant"><vt:variant><vt:lpstr>Worksheets</vt:lpstr></vt:variant><vt:variant><vt:i4>1</vt:i4></vt:variant></vt:vector></HeadingPairs><TitlesOfParts><vt:vector size="1" baseType="lpstr"><vt:lpstr>Sheet1</vt:lpstr></vt:vector></TitlesOfParts><HLinks><vt:vector size="1" baseType="variant"><vt:variant><vt:lpwstr>http://example.com</vt:lpwstr></vt:variant></vt:vector></HLinks></Properties>`
+	if _, err := pkg.AddPart(AppPropertiesPath, ContentTypeExtendedProps, []byte(appXML)); err != nil {
+		t.Fatal(err)
+	}
+	pkg.AddRelationship("", AppPropertiesPath, RelTypeExtendedProps)
+
+	props, err := pkg.ExtendedProperties()
+	if err != nil {
+		t.Fatalf("ExtendedProperties() error = %v", err)
+	}
+	if props.Application != "Microsoft Excel" {
+		t.Errorf("Application = %q", props.Application)
+	}
+	if titles := props.PartTitles("Worksheets"); len(titles) != 1 || titles[0] != "Sheet1" {
+		t.Errorf("PartTitles(Worksheets) = %v", titles)
+	}
+	props.Company = "ACME"
+	props.Manager = "Road Runner"
+	props.HyperlinkBase = "https://acme.example/"
+	props.TotalTime = 42
+	props.SetPartTitles("Named Ranges", []string{"Budget"})
+	if err := pkg.SetExtendedProperties(props); err != nil {
+		t.Fatalf("SetExtendedProperties() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	reopened, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer reopened.Close()
+	got, err := reopened.ExtendedProperties()
+	if err != nil {
+		t.Fatalf("ExtendedProperties() error = %v", err)
+	}
+	if got.Company != "ACME" || got.Manager != "Road Runner" || got.HyperlinkBase != "https://acme.example/" || got.TotalTime != 42 {
+		t.Errorf("round trip lost fields: %+v", got)
+	}
+	if titles := got.PartTitles("Worksheets"); len(titles) != 1 || titles[0] != "Sheet1" {
+		t.Errorf("PartTitles(Worksheets) = %v", titles)
+	}
+	if titles := got.PartTitles("Named Ranges"); len(titles) != 1 || titles[0] != "Budget" {
+		t.Errorf("PartTitles(Named Ranges) = %v", titles)
+	}
+	if len(got.Other) != 1 || got.Other[0].XMLName.Local != "HLinks" {
+		t.Errorf("unmodelled HLinks element not preserved: %+v", got.Other)
+	}
+}
+
+func TestPackage_CustomPropertiesRoundTrip(t *testing.T) {
+	pkg := New()
+	reviewed := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	values := map[string]interface{}{
+		"ClientID":   "ACME-042",
+		"Retention":  7,
+		"Score":      0.75,
+		"Classified": true,
+		"ReviewedOn": reviewed,
+	}
+	for _, name := range []string{"ClientID", "Retention", "Score", "Classified", "ReviewedOn"} {
+		if err := pkg.SetCustomProperty(name, values[name]); err != nil {
+			t.Fatalf("SetCustomProperty(%s) error = %v", name, err)
+		}
+	}
+	if err := pkg.SetCustomProperty("TooBig", int64(1)<<40); err == nil {
+		t.Error("SetCustomProperty() should reject integers outside vt:i4")
+	}
+
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	reopened, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer reopened.Close()
+
+	if got := reopened.GetContentType(CustomPropertiesPath); got != ContentTypeCustomProps {
+		t.Errorf("content type = %q, want %q", got, ContentTypeCustomProps)
+	}
+	if rel := reopened.GetRelationships("").FirstByType(RelTypeCustomProps); rel == nil {
+		t.Fatal("custom properties relationship missing")
+	}
+
+	props, err := reopened.CustomProperties()
+	if err != nil {
+		t.Fatalf("CustomProperties() error = %v", err)
+	}
+	if names := props.Names(); len(names) != 5 || names[0] != "ClientID" {
+		t.Errorf("Names() = %v", names)
+	}
+	for i, prop := range props.Properties {
+		if prop.PID != i+2 || prop.FmtID != common.CustomPropertyFmtID {
+			t.Errorf("property %s has pid %d fmtid %s", prop.Name, prop.PID, prop.FmtID)
+		}
+	}
+
+	prop, err := reopened.CustomProperty("clientid")
+	if err != nil || prop.Type() != common.VTString || prop.String() != "ACME-042" {
+		t.Errorf("ClientID = %+v, %v", prop, err)
+	}
+	prop, _ = reopened.CustomProperty("Retention")
+	if n, err := prop.Int(); err != nil || n != 7 || prop.Type() != common.VTInt {
+		t.Errorf("Retention = %d (%s), %v", n, prop.Type(), err)
+	}
+	prop, _ = reopened.CustomProperty("Score")
+	if f, err := prop.Float(); err != nil || f != 0.75 {
+		t.Errorf("Score = %v, %v", f, err)
+	}
+	prop, _ = reopened.CustomProperty("Classified")
+	if b, err := prop.Bool(); err != nil || !b {
+		t.Errorf("Classified = %v, %v", b, err)
+	}
+	prop, _ = reopened.CustomProperty("ReviewedOn")
+	if tm, err := prop.Time(); err != nil || !tm.Equal(reviewed) {
+		t.Errorf("ReviewedOn = %v, %v", tm, err)
+	}
+	if _, err := prop.Int(); err == nil {
+		t.Error("Int() on a filetime property should fail")
+	}
+
+	if err := reopened.DeleteCustomProperty("Score"); err != nil {
+		t.Fatalf("DeleteCustomProperty() error = %v", err)
+	}
+	if _, err := reopened.CustomProperty("Score"); !errors.Is(err, utils.ErrPropertyNotFound) {
+		t.Errorf("CustomProperty(Score) error = %v, want ErrPropertyNotFound", err)
+	}
+	if err := reopened.DeleteCustomProperty("Score"); !errors.Is(err, utils.ErrPropertyNotFound) {
+		t.Errorf("DeleteCustomProperty(Score) error = %v, want ErrPropertyNotFound", err)
+	}
+
+	// Removing every property removes the part and its relationship.
+	if err := reopened.SetCustomProperties(common.NewCustomProperties()); err != nil {
+		t.Fatalf("SetCustomProperties() error = %v", err)
+	}
+	if reopened.PartExists(CustomPropertiesPath) {
+		t.Error("custom properties part kept after clearing")
+	}
+	if rel := reopened.GetRelationships("").FirstByType(RelTypeCustomProps); rel != nil {
+		t.Error("custom properties relationship kept after clearing")
+	}
+}
+
+func TestPackage_CustomPropertiesPreservesUnknownTypes(t *testing.T) {
+	pkg := New()
+	xmlData := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"><property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="2" name="Legacy"><vt:lpstr>old &amp; ansi</vt:lpstr></property></Properties>`
+	if _, err := pkg.AddPart(CustomPropertiesPath, ContentTypeCustomProps, []byte(xmlData)); err != nil {
+		t.Fatal(err)
+	}
+	pkg.AddRelationship("", CustomPropertiesPath, RelTypeCustomProps)
+
+	if err := pkg.SetCustomProperty("Added", "new"); err != nil {
+		t.Fatalf("SetCustomProperty() error = %v", err)
+	}
+	legacy, err := pkg.CustomProperty("Legacy")
+	if err != nil {
+		t.Fatalf("CustomProperty() error = %v", err)
+	}
+	if legacy.Type() != "lpstr" || legacy.String() != "old & ansi" {
+		t.Errorf("Legacy = %s %q", legacy.Type(), legacy.String())
+	}
+	added, _ := pkg.CustomProperty("Added")
+	if added.PID != 3 {
+		t.Errorf("Added pid = %d, want 3", added.PID)
+	}
+}
+
 func TestPackage_CoreProperties_Default(t *testing.T) {
 	pkg := New()
 
@@ -419,3 +743,167 @@ func TestPackage_CoreProperties_Default(t *testing.T) {
 		t.Errorf("expected empty default core properties, got title=%q creator=%q", props.Title, props.Creator)
 	}
 }
+
+func TestPackage_LazyPartLoading(t *testing.T) {
+	media := bytes.Repeat([]byte("image-bytes-"), 1024)
+
+	src := New()
+	_, _ = src.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<document/>`))
+	_, _ = src.AddPart("word/media/image1.png", ContentTypePNG, media)
+	src.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	var buf bytes.Buffer
+	if err := src.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	pkg, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer pkg.Close()
+
+	image, err := pkg.GetPart("word/media/image1.png")
+	if err != nil {
+		t.Fatalf("GetPart() error = %v", err)
+	}
+	if image.IsLoaded() {
+		t.Error("part should not be loaded before Content() is called")
+	}
+	if image.Size() != len(media) {
+		t.Errorf("Size() = %d, want %d", image.Size(), len(media))
+	}
+
+	stream, err := image.Stream()
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	streamed, _ := io.ReadAll(stream)
+	stream.Close()
+	if !bytes.Equal(streamed, media) {
+		t.Error("streamed content mismatch")
+	}
+	if image.IsLoaded() {
+		t.Error("Stream() should not load the part into memory")
+	}
+
+	doc, _ := pkg.GetPart("word/document.xml")
+	if _, err := doc.Content(); err != nil {
+		t.Fatalf("Content() error = %v", err)
+	}
+	if !doc.IsLoaded() {
+		t.Error("part should be loaded after Content()")
+	}
+	_ = doc.SetContent([]byte(`<document><body/></document>`))
+
+	var out bytes.Buffer
+	if err := pkg.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if image.IsLoaded() {
+		t.Error("untouched part should be copied without loading")
+	}
+
+	reopened, err := OpenBytes(out.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer reopened.Close()
+	for uri, want := range map[string][]byte{
+		"word/media/image1.png": media,
+		"word/document.xml":     []byte(`<document><body/></document>`),
+	} {
+		part, err := reopened.GetPart(uri)
+		if err != nil {
+			t.Fatalf("GetPart(%q) error = %v", uri, err)
+		}
+		got, _ := part.Content()
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s content mismatch after round-trip", uri)
+		}
+	}
+}
+
+func TestPackage_SaveInPlaceWithLazyParts(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.docx")
+
+	src := New()
+	_, _ = src.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<document/>`))
+	_, _ = src.AddPart("word/media/image1.png", ContentTypePNG, []byte("png-data"))
+	src.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	if err := src.SaveAs(tmpFile); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+
+	pkg, err := Open(tmpFile)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := pkg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	pkg.Close()
+
+	reopened, err := Open(tmpFile)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reopened.Close()
+	part, err := reopened.GetPart("word/media/image1.png")
+	if err != nil {
+		t.Fatalf("GetPart() error = %v", err)
+	}
+	if got, _ := part.Content(); string(got) != "png-data" {
+		t.Errorf("Content() = %q, want %q", got, "png-data")
+	}
+}
+
+func TestPackage_Thumbnail(t *testing.T) {
+	pkg := New()
+
+	if _, _, err := pkg.Thumbnail(); !errors.Is(err, utils.ErrPartNotFound) {
+		t.Fatalf("Thumbnail() on empty package error = %v, want ErrPartNotFound", err)
+	}
+	if err := pkg.SetThumbnail("image/svg+xml", []byte("<svg/>")); err == nil {
+		t.Error("SetThumbnail() accepted unsupported content type")
+	}
+
+	png := []byte{0x89, 0x50, 0x4E, 0x47}
+	if err := pkg.SetThumbnail(ContentTypePNG, png); err != nil {
+		t.Fatalf("SetThumbnail() error = %v", err)
+	}
+	jpeg := []byte{0xFF, 0xD8, 0xFF}
+	if err := pkg.SetThumbnail(ContentTypeJPEG, jpeg); err != nil {
+		t.Fatalf("SetThumbnail() replace error = %v", err)
+	}
+	if pkg.PartExists("docProps/thumbnail.png") {
+		t.Error("previous thumbnail part was not removed")
+	}
+	if rels := pkg.GetRelationshipsByType("", RelTypeThumbnail); len(rels) != 1 || rels[0].Target != "docProps/thumbnail.jpeg" {
+		t.Errorf("thumbnail relationships = %v", rels)
+	}
+
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	reopened, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer reopened.Close()
+
+	contentType, data, err := reopened.Thumbnail()
+	if err != nil {
+		t.Fatalf("Thumbnail() error = %v", err)
+	}
+	if contentType != ContentTypeJPEG || !bytes.Equal(data, jpeg) {
+		t.Errorf("Thumbnail() = %q %v, want %q %v", contentType, data, ContentTypeJPEG, jpeg)
+	}
+
+	if err := reopened.RemoveThumbnail(); err != nil {
+		t.Fatalf("RemoveThumbnail() error = %v", err)
+	}
+	if reopened.PartExists("docProps/thumbnail.jpeg") || len(reopened.GetRelationshipsByType("", RelTypeThumbnail)) != 0 {
+		t.Error("RemoveThumbnail() left the part or relationship behind")
+	}
+}
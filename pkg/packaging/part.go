@@ -1,15 +1,21 @@
 package packaging
 
 import (
+	"archive/zip"
 	"bytes"
 	"io"
 )
 
 // Part represents a part within an OPC package.
+//
+// Parts read from an existing package are backed by their ZIP entry and are
+// only decompressed when Content or Stream is called.
 type Part struct {
 	uri         string
 	contentType string
 	content     []byte
+	zipFile     *zip.File
+	loaded      bool
 	pkg         *Package
 	modified    bool
 }
@@ -25,24 +31,40 @@ func (p *Part) ContentType() string {
 }
 
 // Content returns the part's content as a byte slice.
+// Content backed by a ZIP entry is decompressed on first access and cached.
 func (p *Part) Content() ([]byte, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
 	return p.content, nil
 }
 
 // SetContent sets the part's content.
 func (p *Part) SetContent(content []byte) error {
 	p.content = content
+	p.loaded = true
 	p.modified = true
 	return nil
 }
 
 // Stream returns the part's content as an io.ReadCloser.
+// Parts that have not been loaded are streamed directly from the ZIP entry,
+// except Strict XML parts, which are loaded to map their namespaces.
 func (p *Part) Stream() (io.ReadCloser, error) {
+	if !p.loaded && p.zipFile != nil && !p.needsStrictConversion() {
+		return p.zipFile.Open()
+	}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
 	return io.NopCloser(bytes.NewReader(p.content)), nil
 }
 
 // Size returns the size of the part's content.
 func (p *Part) Size() int {
+	if !p.loaded && p.zipFile != nil {
+		return int(p.zipFile.UncompressedSize64)
+	}
 	return len(p.content)
 }
 
@@ -51,6 +73,38 @@ func (p *Part) IsModified() bool {
 	return p.modified
 }
 
+// IsLoaded reports whether the part's content is held in memory.
+func (p *Part) IsLoaded() bool {
+	return p.loaded
+}
+
+// load decompresses the backing ZIP entry if it has not been read yet.
+func (p *Part) load() error {
+	if p.loaded {
+		return nil
+	}
+	if p.zipFile == nil {
+		p.loaded = true
+		return nil
+	}
+	content, err := readZipFile(p.zipFile)
+	if err != nil {
+		return err
+	}
+	if p.needsStrictConversion() {
+		content = fromStrict(content)
+	}
+	p.content = content
+	p.loaded = true
+	return nil
+}
+
+// needsStrictConversion reports whether the part content read from a Strict
+// package must be mapped to Transitional names.
+func (p *Part) needsStrictConversion() bool {
+	return p.pkg != nil && p.pkg.strict && hasStrictNames(p.contentType)
+}
+
 // newPart creates a new part.
 func newPart(uri, contentType string, content []byte, pkg *Package) *Part {
 	if content == nil {
@@ -60,7 +114,17 @@ func newPart(uri, contentType string, content []byte, pkg *Package) *Part {
 		uri:         uri,
 		contentType: contentType,
 		content:     content,
+		loaded:      true,
 		pkg:         pkg,
 		modified:    false,
 	}
 }
+
+// newZipPart creates a part backed by a ZIP entry that is read on demand.
+func newZipPart(uri string, f *zip.File, pkg *Package) *Part {
+	return &Part{
+		uri:     uri,
+		zipFile: f,
+		pkg:     pkg,
+	}
+}
@@ -160,6 +160,21 @@ func RelationshipsPathForPart(partURI string) string {
 	return dir + "/_rels/" + base + ".rels"
 }
 
+// sourceURIForRelationshipsPath returns the source part URI for a .rels path,
+// e.g. "word/_rels/document.xml.rels" -> "word/document.xml".
+// The package relationships part maps to the package root.
+func sourceURIForRelationshipsPath(relsPath string) string {
+	if relsPath == PackageRelsPath {
+		return normalizePath("")
+	}
+	dir := path.Dir(path.Dir(relsPath))
+	base := strings.TrimSuffix(path.Base(relsPath), ".rels")
+	if dir == "." {
+		return normalizePath(base)
+	}
+	return normalizePath(dir + "/" + base)
+}
+
 // ResolveRelationshipTarget resolves a relationship target relative to a source part.
 func ResolveRelationshipTarget(sourcePart, target string) string {
 	if strings.HasPrefix(target, "/") {
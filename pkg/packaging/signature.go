@@ -0,0 +1,910 @@
+package packaging
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1" // registers SHA-1 for legacy signatures
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// Digest and signature algorithm identifiers (XML-DSig / RFC 6931).
+const (
+	AlgorithmSHA1        = "http://www.w3.org/2000/09/xmldsig#sha1"
+	AlgorithmSHA256      = "http://www.w3.org/2001/04/xmlenc#sha256"
+	AlgorithmSHA384      = "http://www.w3.org/2001/04/xmldsig-more#sha384"
+	AlgorithmSHA512      = "http://www.w3.org/2001/04/xmlenc#sha512"
+	AlgorithmRSASHA1     = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	AlgorithmRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	AlgorithmRSASHA384   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha384"
+	AlgorithmRSASHA512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	AlgorithmECDSASHA1   = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha1"
+	AlgorithmECDSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	AlgorithmECDSASHA384 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha384"
+	AlgorithmECDSASHA512 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512"
+)
+
+const (
+	packageObjectID     = "idPackageObject"
+	packageSignatureID  = "idPackageSignature"
+	signatureTimeFormat = "YYYY-MM-DDThh:mm:ssTZD"
+	signatureDir        = "_xmlsignatures"
+)
+
+// signatureTimeLayouts maps the OPC SignatureTime formats (§10.5.15) to Go layouts.
+var signatureTimeLayouts = map[string]string{
+	"YYYY-MM-DDThh:mm:ss.sTZD": "2006-01-02T15:04:05.999999999Z07:00",
+	"YYYY-MM-DDThh:mm:ssTZD":   "2006-01-02T15:04:05Z07:00",
+	"YYYY-MM-DDThh:mmTZD":      "2006-01-02T15:04Z07:00",
+	"YYYY-MM-DD":               "2006-01-02",
+	"YYYY-MM":                  "2006-01",
+	"YYYY":                     "2006",
+}
+
+// Signature is an XML digital signature stored in the package (ECMA-376 Part 2 §10).
+type Signature struct {
+	// PartURI is the URI of the XML signature part.
+	PartURI string
+	// SignatureTime is the signing time recorded in the package object.
+	SignatureTime time.Time
+	// Certificate is the signing certificate.
+	Certificate *x509.Certificate
+	// Certificates is the certificate chain carried by the signature.
+	Certificates []*x509.Certificate
+	// SignedParts lists the part URIs referenced by the signature manifest.
+	SignedParts []string
+
+	pkg  *Package
+	root *xmlNode
+}
+
+// SignOptions configures Package.Sign.
+type SignOptions struct {
+	// Hash selects the digest algorithm (SHA-256 when zero).
+	Hash crypto.Hash
+	// Time is recorded as the SignatureTime (current time when zero).
+	Time time.Time
+}
+
+// Signatures returns the digital signatures stored in the package.
+func (p *Package) Signatures() ([]*Signature, error) {
+	if p.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+	originPath := p.signatureOriginPath()
+	if originPath == "" {
+		return nil, nil
+	}
+	var sigs []*Signature
+	for _, rel := range p.GetRelationshipsByType(originPath, RelTypeDigitalSignature) {
+		sig, err := p.readSignature(ResolveRelationshipTarget(originPath, rel.Target))
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs, nil
+}
+
+// VerifySignatures verifies every signature in the package, including that
+// each signature covers every part and relationship (see Signature.Verify).
+// It returns utils.ErrSignatureNotFound when the package is unsigned.
+func (p *Package) VerifySignatures() error {
+	sigs, err := p.Signatures()
+	if err != nil {
+		return err
+	}
+	if len(sigs) == 0 {
+		return utils.ErrSignatureNotFound
+	}
+	for _, sig := range sigs {
+		if err := sig.Verify(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sign adds a new XML digital signature covering every part and relationship
+// in the package. chain[0] must be the certificate matching signer.
+func (p *Package) Sign(signer crypto.Signer, chain []*x509.Certificate, opts *SignOptions) (*Signature, error) {
+	if p.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+	if signer == nil {
+		return nil, utils.NewValidationError("signer", "cannot be nil", nil)
+	}
+	if len(chain) == 0 || chain[0] == nil {
+		return nil, utils.NewValidationError("chain", "must contain the signing certificate", nil)
+	}
+
+	hash := crypto.SHA256
+	signingTime := time.Now()
+	if opts != nil {
+		if opts.Hash != 0 {
+			hash = opts.Hash
+		}
+		if !opts.Time.IsZero() {
+			signingTime = opts.Time
+		}
+	}
+	digestAlg, err := digestAlgorithm(hash)
+	if err != nil {
+		return nil, err
+	}
+	signatureAlg, err := signatureAlgorithm(signer.Public(), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	originPath, err := p.ensureSignatureOrigin()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := p.signatureManifest(hash, digestAlg)
+	if err != nil {
+		return nil, err
+	}
+
+	var object strings.Builder
+	object.WriteString(`<Object Id="` + packageObjectID + `"><Manifest>`)
+	object.WriteString(manifest)
+	object.WriteString(`</Manifest><SignatureProperties><SignatureProperty Id="idSignatureTime" Target="#` + packageSignatureID + `">`)
+	object.WriteString(`<mdssi:SignatureTime xmlns:mdssi="` + NSDigitalSignature + `"><mdssi:Format>` + signatureTimeFormat + `</mdssi:Format>`)
+	object.WriteString(`<mdssi:Value>` + signingTime.UTC().Format("2006-01-02T15:04:05Z") + `</mdssi:Value></mdssi:SignatureTime>`)
+	object.WriteString(`</SignatureProperty></SignatureProperties></Object>`)
+
+	objectDigest, err := canonicalDigest(object.String(), packageObjectID, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	signedInfo := `<SignedInfo><CanonicalizationMethod Algorithm="` + AlgorithmC14N + `"/>` +
+		`<SignatureMethod Algorithm="` + signatureAlg + `"/>` +
+		`<Reference Type="http://www.w3.org/2000/09/xmldsig#Object" URI="#` + packageObjectID + `">` +
+		`<DigestMethod Algorithm="` + digestAlg + `"/><DigestValue>` + objectDigest + `</DigestValue></Reference></SignedInfo>`
+
+	canonicalInfo, err := canonicalSubset(signedInfo, "SignedInfo")
+	if err != nil {
+		return nil, err
+	}
+	signatureValue, err := signData(signer, hash, canonicalInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc strings.Builder
+	doc.WriteString(utils.XMLHeader)
+	doc.WriteString(`<Signature xmlns="` + NSXMLDSig + `" Id="` + packageSignatureID + `">`)
+	doc.WriteString(signedInfo)
+	doc.WriteString(`<SignatureValue>` + base64.StdEncoding.EncodeToString(signatureValue) + `</SignatureValue>`)
+	doc.WriteString(`<KeyInfo><X509Data>`)
+	for _, cert := range chain {
+		doc.WriteString(`<X509Certificate>` + base64.StdEncoding.EncodeToString(cert.Raw) + `</X509Certificate>`)
+	}
+	doc.WriteString(`</X509Data></KeyInfo>`)
+	doc.WriteString(object.String())
+	doc.WriteString(`</Signature>`)
+
+	sigPath := p.nextSignaturePath()
+	if _, err := p.AddPart(sigPath, ContentTypeDigitalSignatureXML, []byte(doc.String())); err != nil {
+		return nil, err
+	}
+	p.AddRelationship(originPath, path.Base(sigPath), RelTypeDigitalSignature)
+
+	return p.readSignature(sigPath)
+}
+
+// Verify checks the signature value, the package object digest, every
+// manifest reference and the SignatureTime, and fails when the package has
+// parts or relationships the signature does not cover (see UnsignedParts).
+// It does not validate the certificate chain against trust roots; use
+// Certificate.Verify for that.
+func (s *Signature) Verify() error {
+	if s == nil || s.root == nil {
+		return utils.ErrSignatureNotFound
+	}
+	if s.Certificate == nil {
+		return fmt.Errorf("%w: %s has no signing certificate", utils.ErrSignatureInvalid, s.PartURI)
+	}
+	signedInfo := s.root.findChild("SignedInfo")
+	if signedInfo == nil {
+		return fmt.Errorf("%w: %s has no SignedInfo", utils.ErrSignatureInvalid, s.PartURI)
+	}
+
+	// Signature value over the canonical SignedInfo
+	c14nMethod := signedInfo.findChild("CanonicalizationMethod")
+	if c14nMethod == nil {
+		return fmt.Errorf("%w: %s has no CanonicalizationMethod", utils.ErrSignatureInvalid, s.PartURI)
+	}
+	canonicalInfo, err := canonicalize(signedInfo, c14nMethod.attr("Algorithm"), inclusivePrefixes(c14nMethod))
+	if err != nil {
+		return err
+	}
+	method := signedInfo.findChild("SignatureMethod")
+	if method == nil {
+		return fmt.Errorf("%w: %s has no SignatureMethod", utils.ErrSignatureInvalid, s.PartURI)
+	}
+	value, err := decodeBase64(s.root.findChild("SignatureValue").text())
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", utils.ErrSignatureInvalid, s.PartURI, err)
+	}
+	if err := verifySignatureValue(s.Certificate.PublicKey, method.attr("Algorithm"), canonicalInfo, value); err != nil {
+		return fmt.Errorf("%w: %s: %v", utils.ErrSignatureInvalid, s.PartURI, err)
+	}
+
+	// References in SignedInfo, which must include the package object
+	objectSigned := false
+	for _, ref := range signedInfo.childrenNamed("Reference") {
+		if err := s.verifyReference(ref); err != nil {
+			return err
+		}
+		if ref.attr("URI") == "#"+packageObjectID {
+			objectSigned = true
+		}
+	}
+	if !objectSigned {
+		return fmt.Errorf("%w: %s does not sign the package object", utils.ErrSignatureInvalid, s.PartURI)
+	}
+
+	// Manifest references to package parts
+	object := s.root.findByID(packageObjectID)
+	if manifest := object.findChild("Manifest"); manifest != nil {
+		for _, ref := range manifest.childrenNamed("Reference") {
+			if err := s.verifyReference(ref); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.SignatureTime.IsZero() {
+		return fmt.Errorf("%w: %s has no valid SignatureTime", utils.ErrSignatureInvalid, s.PartURI)
+	}
+	if unsigned := s.UnsignedParts(); len(unsigned) > 0 {
+		return fmt.Errorf("%w: %s does not cover %s", utils.ErrSignatureInvalid, s.PartURI, strings.Join(unsigned, ", "))
+	}
+	return nil
+}
+
+// UnsignedParts returns the URIs of the parts, and of the relationship
+// parts holding relationships, that the signature manifest does not cover,
+// such as parts added after signing. Signature parts and the signature
+// origin relationship are never signed and are not reported.
+func (s *Signature) UnsignedParts() []string {
+	if s == nil || s.pkg == nil {
+		return nil
+	}
+	signedParts := make(map[string]bool)
+	signedRels := make(map[string]func(Relationship) bool) // source part -> covered relationships
+	if object := s.root.findByID(packageObjectID); object != nil {
+		if manifest := object.findChild("Manifest"); manifest != nil {
+			for _, ref := range manifest.childrenNamed("Reference") {
+				partURI, _ := parsePartReference(ref.attr("URI"))
+				source, isRels := relationshipsSource(partURI)
+				if !isRels {
+					signedParts[partURI] = true
+					continue
+				}
+				covered := func(Relationship) bool { return true }
+				for _, transform := range transformsOf(ref) {
+					if transform.attr("Algorithm") == AlgorithmRelationshipXfrm {
+						covered = relationshipSelector(transform)
+					}
+				}
+				signedRels[source] = covered
+			}
+		}
+	}
+
+	var unsigned []string
+	for uri, part := range s.pkg.parts {
+		if uri == ContentTypesPath || strings.HasSuffix(uri, ".rels") || strings.HasSuffix(uri, "/") ||
+			isSignaturePart(uri, part.contentType) || signedParts[uri] {
+			continue
+		}
+		unsigned = append(unsigned, uri)
+	}
+	for source, rels := range s.pkg.relationships {
+		source = normalizePath(source)
+		relsPath := PackageRelsPath
+		if source != "." {
+			if part, ok := s.pkg.parts[source]; !ok || isSignaturePart(source, part.contentType) {
+				continue
+			}
+			relsPath = RelationshipsPathForPart(source)
+		}
+		covered := signedRels[source]
+		for _, rel := range rels.Relationships {
+			if rel.Type == RelTypeDigitalSignatureOrigin {
+				continue
+			}
+			if covered == nil || !covered(rel) {
+				unsigned = append(unsigned, relsPath)
+				break
+			}
+		}
+	}
+	sort.Strings(unsigned)
+	return unsigned
+}
+
+// verifyReference recomputes and compares the digest of a Reference element.
+func (s *Signature) verifyReference(ref *xmlNode) error {
+	uri := ref.attr("URI")
+	digestMethod := ref.findChild("DigestMethod")
+	if digestMethod == nil {
+		return fmt.Errorf("%w: reference %q has no DigestMethod", utils.ErrSignatureInvalid, uri)
+	}
+	hash, err := digestHash(digestMethod.attr("Algorithm"))
+	if err != nil {
+		return err
+	}
+	expected, err := decodeBase64(ref.findChild("DigestValue").text())
+	if err != nil {
+		return fmt.Errorf("%w: reference %q: %v", utils.ErrSignatureInvalid, uri, err)
+	}
+
+	var data []byte
+	if strings.HasPrefix(uri, "#") {
+		data, err = s.sameDocumentData(uri[1:], ref)
+	} else {
+		data, err = s.pkg.referenceData(uri, ref)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: reference %q: %v", utils.ErrSignatureInvalid, uri, err)
+	}
+
+	h := hash.New()
+	h.Write(data)
+	if !bytes.Equal(h.Sum(nil), expected) {
+		return fmt.Errorf("%w: digest mismatch for %q", utils.ErrSignatureInvalid, uri)
+	}
+	return nil
+}
+
+// sameDocumentData returns the canonical form of an element in the signature part.
+func (s *Signature) sameDocumentData(id string, ref *xmlNode) ([]byte, error) {
+	node := s.root.findByID(id)
+	if node == nil {
+		return nil, fmt.Errorf("element %q not found", id)
+	}
+	algorithm := AlgorithmC14N
+	var prefixes []string
+	for _, transform := range transformsOf(ref) {
+		algorithm = transform.attr("Algorithm")
+		prefixes = inclusivePrefixes(transform)
+	}
+	return canonicalize(node, algorithm, prefixes)
+}
+
+// referenceData returns the transformed bytes of a package part reference.
+func (p *Package) referenceData(uri string, ref *xmlNode) ([]byte, error) {
+	partURI, contentType := parsePartReference(uri)
+	relsSource, isRels := relationshipsSource(partURI)
+
+	actualType := p.GetContentType(partURI)
+	if isRels {
+		actualType = ContentTypeRelationships
+	}
+	if contentType != "" && !strings.EqualFold(contentType, actualType) {
+		return nil, fmt.Errorf("content type %q does not match %q", contentType, actualType)
+	}
+
+	transforms := transformsOf(ref)
+	if isRels {
+		for _, transform := range transforms {
+			if transform.attr("Algorithm") == AlgorithmRelationshipXfrm {
+				return relationshipTransform(p.relationships[relsSource], transform), nil
+			}
+		}
+	}
+
+	var data []byte
+	if part, ok := p.parts[partURI]; ok {
+		content, err := part.Content()
+		if err != nil {
+			return nil, err
+		}
+		data = content
+	} else if rels, ok := p.relationships[relsSource]; isRels && ok {
+		marshaled, err := utils.MarshalXMLWithHeader(rels)
+		if err != nil {
+			return nil, err
+		}
+		data = marshaled
+	} else {
+		return nil, utils.ErrPartNotFound
+	}
+
+	for _, transform := range transforms {
+		root, err := parseXMLTree(data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = canonicalize(root, transform.attr("Algorithm"), inclusivePrefixes(transform)); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// readSignature parses a signature part.
+func (p *Package) readSignature(uri string) (*Signature, error) {
+	part, err := p.GetPart(uri)
+	if err != nil {
+		return nil, err
+	}
+	content, err := part.Content()
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseXMLTree(content)
+	if err != nil {
+		return nil, err
+	}
+	if root.name.Local != "Signature" {
+		return nil, fmt.Errorf("%w: %s is not an XML signature", utils.ErrSignatureInvalid, uri)
+	}
+
+	sig := &Signature{PartURI: part.URI(), pkg: p, root: root}
+
+	if keyInfo := root.findChild("KeyInfo"); keyInfo != nil {
+		for _, data := range keyInfo.childrenNamed("X509Data") {
+			for _, certNode := range data.childrenNamed("X509Certificate") {
+				der, err := decodeBase64(certNode.text())
+				if err != nil {
+					return nil, err
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, err
+				}
+				sig.Certificates = append(sig.Certificates, cert)
+			}
+		}
+	}
+	if len(sig.Certificates) == 0 {
+		for _, rel := range p.GetRelationshipsByType(uri, RelTypeDigitalSignatureCertificate) {
+			certPart, err := p.GetPart(ResolveRelationshipTarget(uri, rel.Target))
+			if err != nil {
+				continue
+			}
+			der, err := certPart.Content()
+			if err != nil {
+				return nil, err
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, err
+			}
+			sig.Certificates = append(sig.Certificates, cert)
+		}
+	}
+	if len(sig.Certificates) > 0 {
+		sig.Certificate = sig.Certificates[0]
+	}
+
+	if object := root.findByID(packageObjectID); object != nil {
+		if manifest := object.findChild("Manifest"); manifest != nil {
+			for _, ref := range manifest.childrenNamed("Reference") {
+				partURI, _ := parsePartReference(ref.attr("URI"))
+				sig.SignedParts = append(sig.SignedParts, partURI)
+			}
+		}
+		if props := object.findChild("SignatureProperties"); props != nil {
+			for _, prop := range props.childrenNamed("SignatureProperty") {
+				if st := prop.findChild("SignatureTime"); st != nil {
+					sig.SignatureTime = parseSignatureTime(st.findChild("Format").text(), st.findChild("Value").text())
+				}
+			}
+		}
+	}
+	return sig, nil
+}
+
+// signatureManifest builds Reference elements for every signable part and relationship.
+func (p *Package) signatureManifest(hash crypto.Hash, digestAlg string) (string, error) {
+	var uris []string
+	for uri, part := range p.parts {
+		if uri == ContentTypesPath || strings.HasSuffix(uri, ".rels") || strings.HasSuffix(uri, "/") {
+			continue
+		}
+		if isSignaturePart(uri, part.contentType) {
+			continue
+		}
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	var buf strings.Builder
+	for _, uri := range uris {
+		part := p.parts[uri]
+		content, err := part.Content()
+		if err != nil {
+			return "", err
+		}
+		h := hash.New()
+		h.Write(content)
+		buf.WriteString(`<Reference URI="` + escapeC14NAttr("/"+uri+"?ContentType="+p.GetContentType(uri)) + `">`)
+		buf.WriteString(`<DigestMethod Algorithm="` + digestAlg + `"/>`)
+		buf.WriteString(`<DigestValue>` + base64.StdEncoding.EncodeToString(h.Sum(nil)) + `</DigestValue></Reference>`)
+	}
+
+	var sources []string
+	for source := range p.relationships {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		if source != "" && source != "." {
+			if part, ok := p.parts[source]; !ok || isSignaturePart(source, part.contentType) {
+				continue
+			}
+		}
+		rels := p.relationships[source]
+		var ids []string
+		for _, rel := range rels.Relationships {
+			if rel.Type == RelTypeDigitalSignatureOrigin {
+				continue
+			}
+			ids = append(ids, rel.ID)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		sort.Strings(ids)
+
+		var transform strings.Builder
+		transform.WriteString(`<Transform Algorithm="` + AlgorithmRelationshipXfrm + `">`)
+		for _, id := range ids {
+			transform.WriteString(`<mdssi:RelationshipReference xmlns:mdssi="` + NSDigitalSignature + `" SourceId="` + escapeC14NAttr(id) + `"/>`)
+		}
+		transform.WriteString(`</Transform>`)
+
+		// Digest the transform output exactly as a verifier will.
+		root, err := parseXMLTree([]byte(transform.String()))
+		if err != nil {
+			return "", err
+		}
+		h := hash.New()
+		h.Write(relationshipTransform(rels, root))
+
+		relsPath := PackageRelsPath
+		if source != "" && source != "." {
+			relsPath = RelationshipsPathForPart(source)
+		}
+		buf.WriteString(`<Reference URI="` + escapeC14NAttr("/"+relsPath+"?ContentType="+ContentTypeRelationships) + `"><Transforms>`)
+		buf.WriteString(transform.String())
+		buf.WriteString(`<Transform Algorithm="` + AlgorithmC14N + `"/></Transforms>`)
+		buf.WriteString(`<DigestMethod Algorithm="` + digestAlg + `"/>`)
+		buf.WriteString(`<DigestValue>` + base64.StdEncoding.EncodeToString(h.Sum(nil)) + `</DigestValue></Reference>`)
+	}
+	return buf.String(), nil
+}
+
+// ensureSignatureOrigin returns the signature origin part, creating it if needed.
+func (p *Package) ensureSignatureOrigin() (string, error) {
+	if origin := p.signatureOriginPath(); origin != "" {
+		if !p.PartExists(origin) {
+			if _, err := p.AddPart(origin, ContentTypeDigitalSignatureOrigin, nil); err != nil {
+				return "", err
+			}
+		}
+		return origin, nil
+	}
+	if _, err := p.AddPart(SignatureOriginPath, ContentTypeDigitalSignatureOrigin, nil); err != nil {
+		return "", err
+	}
+	p.AddRelationship("", SignatureOriginPath, RelTypeDigitalSignatureOrigin)
+	return SignatureOriginPath, nil
+}
+
+func (p *Package) signatureOriginPath() string {
+	rel := p.GetRelationships("").FirstByType(RelTypeDigitalSignatureOrigin)
+	if rel == nil {
+		return ""
+	}
+	return ResolveRelationshipTarget("", rel.Target)
+}
+
+func (p *Package) nextSignaturePath() string {
+	for i := 1; ; i++ {
+		uri := fmt.Sprintf("%s/sig%d.xml", signatureDir, i)
+		if !p.PartExists(uri) {
+			return uri
+		}
+	}
+}
+
+// relationshipTransform applies the OPC Relationships Transform (§10.5.9) and
+// returns its canonical serialization.
+func relationshipTransform(rels *Relationships, transform *xmlNode) []byte {
+	selects := relationshipSelector(transform)
+	var selected []Relationship
+	if rels != nil {
+		for _, rel := range rels.Relationships {
+			if selects(rel) {
+				selected = append(selected, rel)
+			}
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].ID < selected[j].ID })
+
+	var buf bytes.Buffer
+	buf.WriteString(`<Relationships xmlns="` + NSRelationships + `">`)
+	for _, rel := range selected {
+		mode := "Internal"
+		if rel.TargetMode == TargetModeExternal {
+			mode = "External"
+		}
+		buf.WriteString(`<Relationship Id="` + escapeC14NAttr(rel.ID) + `" Target="` + escapeC14NAttr(rel.Target) +
+			`" TargetMode="` + mode + `" Type="` + escapeC14NAttr(rel.Type) + `"></Relationship>`)
+	}
+	buf.WriteString(`</Relationships>`)
+	return buf.Bytes()
+}
+
+// relationshipSelector reports which relationships a relationship transform
+// selects, by Id or by type.
+func relationshipSelector(transform *xmlNode) func(Relationship) bool {
+	ids := make(map[string]bool)
+	types := make(map[string]bool)
+	for _, child := range transform.childrenNamed("RelationshipReference") {
+		ids[child.attr("SourceId")] = true
+	}
+	for _, child := range transform.childrenNamed("RelationshipsGroupReference") {
+		types[child.attr("SourceType")] = true
+	}
+	return func(rel Relationship) bool { return ids[rel.ID] || types[rel.Type] }
+}
+
+// canonicalDigest digests the element with the given Id inside a Signature wrapper.
+func canonicalDigest(fragment, id string, hash crypto.Hash) (string, error) {
+	root, err := parseXMLTree([]byte(`<Signature xmlns="` + NSXMLDSig + `">` + fragment + `</Signature>`))
+	if err != nil {
+		return "", err
+	}
+	node := root.findByID(id)
+	if node == nil {
+		return "", fmt.Errorf("element %q not found", id)
+	}
+	data, err := canonicalize(node, AlgorithmC14N, nil)
+	if err != nil {
+		return "", err
+	}
+	h := hash.New()
+	h.Write(data)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalSubset canonicalizes the named child inside a Signature wrapper.
+func canonicalSubset(fragment, local string) ([]byte, error) {
+	root, err := parseXMLTree([]byte(`<Signature xmlns="` + NSXMLDSig + `">` + fragment + `</Signature>`))
+	if err != nil {
+		return nil, err
+	}
+	node := root.findChild(local)
+	if node == nil {
+		return nil, fmt.Errorf("element %q not found", local)
+	}
+	return canonicalize(node, AlgorithmC14N, nil)
+}
+
+func signData(signer crypto.Signer, hash crypto.Hash, data []byte) ([]byte, error) {
+	h := hash.New()
+	h.Write(data)
+	sig, err := signer.Sign(rand.Reader, h.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := signer.Public().(*ecdsa.PublicKey); ok {
+		// XML-DSig carries ECDSA signatures as raw r||s, not ASN.1.
+		var parsed struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(sig, &parsed); err != nil {
+			return nil, err
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		raw := make([]byte, 2*size)
+		parsed.R.FillBytes(raw[:size])
+		parsed.S.FillBytes(raw[size:])
+		return raw, nil
+	}
+	return sig, nil
+}
+
+func verifySignatureValue(pub crypto.PublicKey, algorithm string, data, value []byte) error {
+	hash, keyType, err := signatureHash(algorithm)
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if keyType != "rsa" {
+			return fmt.Errorf("signature method %q does not match RSA key", algorithm)
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, value)
+	case *ecdsa.PublicKey:
+		if keyType != "ecdsa" {
+			return fmt.Errorf("signature method %q does not match ECDSA key", algorithm)
+		}
+		if len(value)%2 != 0 {
+			return errors.New("malformed ECDSA signature value")
+		}
+		half := len(value) / 2
+		r := new(big.Int).SetBytes(value[:half])
+		s := new(big.Int).SetBytes(value[half:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("ECDSA verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+func digestAlgorithm(hash crypto.Hash) (string, error) {
+	switch hash {
+	case crypto.SHA1:
+		return AlgorithmSHA1, nil
+	case crypto.SHA256:
+		return AlgorithmSHA256, nil
+	case crypto.SHA384:
+		return AlgorithmSHA384, nil
+	case crypto.SHA512:
+		return AlgorithmSHA512, nil
+	}
+	return "", utils.NewValidationError("hash", "unsupported digest algorithm", hash)
+}
+
+func digestHash(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case AlgorithmSHA1:
+		return crypto.SHA1, nil
+	case AlgorithmSHA256:
+		return crypto.SHA256, nil
+	case AlgorithmSHA384:
+		return crypto.SHA384, nil
+	case AlgorithmSHA512:
+		return crypto.SHA512, nil
+	}
+	return 0, errUnsupportedAlgorithm(algorithm)
+}
+
+func signatureAlgorithm(pub crypto.PublicKey, hash crypto.Hash) (string, error) {
+	var table map[crypto.Hash]string
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		table = map[crypto.Hash]string{
+			crypto.SHA1: AlgorithmRSASHA1, crypto.SHA256: AlgorithmRSASHA256,
+			crypto.SHA384: AlgorithmRSASHA384, crypto.SHA512: AlgorithmRSASHA512,
+		}
+	case *ecdsa.PublicKey:
+		table = map[crypto.Hash]string{
+			crypto.SHA1: AlgorithmECDSASHA1, crypto.SHA256: AlgorithmECDSASHA256,
+			crypto.SHA384: AlgorithmECDSASHA384, crypto.SHA512: AlgorithmECDSASHA512,
+		}
+	default:
+		return "", utils.NewValidationError("signer", "unsupported key type", fmt.Sprintf("%T", pub))
+	}
+	if alg, ok := table[hash]; ok {
+		return alg, nil
+	}
+	return "", utils.NewValidationError("hash", "unsupported digest algorithm", hash)
+}
+
+func signatureHash(algorithm string) (crypto.Hash, string, error) {
+	switch algorithm {
+	case AlgorithmRSASHA1:
+		return crypto.SHA1, "rsa", nil
+	case AlgorithmRSASHA256:
+		return crypto.SHA256, "rsa", nil
+	case AlgorithmRSASHA384:
+		return crypto.SHA384, "rsa", nil
+	case AlgorithmRSASHA512:
+		return crypto.SHA512, "rsa", nil
+	case AlgorithmECDSASHA1:
+		return crypto.SHA1, "ecdsa", nil
+	case AlgorithmECDSASHA256:
+		return crypto.SHA256, "ecdsa", nil
+	case AlgorithmECDSASHA384:
+		return crypto.SHA384, "ecdsa", nil
+	case AlgorithmECDSASHA512:
+		return crypto.SHA512, "ecdsa", nil
+	}
+	return 0, "", errUnsupportedAlgorithm(algorithm)
+}
+
+func errUnsupportedAlgorithm(algorithm string) error {
+	return fmt.Errorf("%w: unsupported algorithm %q", utils.ErrSignatureInvalid, algorithm)
+}
+
+// parsePartReference splits "/word/document.xml?ContentType=..." into part URI and content type.
+func parsePartReference(uri string) (partURI, contentType string) {
+	partURI = uri
+	if idx := strings.Index(uri, "?"); idx >= 0 {
+		partURI = uri[:idx]
+		if query, err := url.ParseQuery(uri[idx+1:]); err == nil {
+			contentType = query.Get("ContentType")
+		} else {
+			contentType = strings.TrimPrefix(uri[idx+1:], "ContentType=")
+		}
+		// ParseQuery turns '+' into a space; content types keep it literally.
+		contentType = strings.ReplaceAll(contentType, " ", "+")
+	}
+	if unescaped, err := url.PathUnescape(partURI); err == nil {
+		partURI = unescaped
+	}
+	return normalizePath(partURI), contentType
+}
+
+// relationshipsSource returns the source part for a relationships part URI.
+func relationshipsSource(uri string) (string, bool) {
+	if !strings.HasSuffix(uri, ".rels") {
+		return "", false
+	}
+	return sourceURIForRelationshipsPath(uri), true
+}
+
+func isSignaturePart(uri, contentType string) bool {
+	switch contentType {
+	case ContentTypeDigitalSignatureOrigin, ContentTypeDigitalSignatureXML, ContentTypeDigitalSignatureCertificate:
+		return true
+	}
+	return strings.HasPrefix(uri, signatureDir+"/")
+}
+
+func transformsOf(ref *xmlNode) []*xmlNode {
+	if transforms := ref.findChild("Transforms"); transforms != nil {
+		return transforms.childrenNamed("Transform")
+	}
+	return nil
+}
+
+func inclusivePrefixes(n *xmlNode) []string {
+	if n == nil {
+		return nil
+	}
+	if inc := n.findChild("InclusiveNamespaces"); inc != nil {
+		return strings.Fields(inc.attr("PrefixList"))
+	}
+	return nil
+}
+
+func parseSignatureTime(format, value string) time.Time {
+	value = strings.TrimSpace(value)
+	if layout, ok := signatureTimeLayouts[strings.TrimSpace(format)]; ok {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	for _, layout := range signatureTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,246 @@
+package packaging
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+func newSignedTestPackage(t *testing.T) *Package {
+	t.Helper()
+	pkg := New()
+	if _, err := pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<?xml version="1.0"?><w:document xmlns:w="urn:test"><w:body/></w:document>`)); err != nil {
+		t.Fatalf("AddPart() error = %v", err)
+	}
+	if _, err := pkg.AddPart("word/media/image1.png", ContentTypePNG, []byte{0x89, 0x50, 0x4E, 0x47}); err != nil {
+		t.Fatalf("AddPart() error = %v", err)
+	}
+	pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	pkg.AddRelationship("word/document.xml", "media/image1.png", RelTypeImage)
+	return pkg
+}
+
+func selfSignedCertificate(t *testing.T, signer crypto.Signer) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-ooxml test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return cert
+}
+
+func TestPackage_SignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signer crypto.Signer
+		hash   crypto.Hash
+	}{
+		{"RSA SHA-256", rsaKey, crypto.SHA256},
+		{"RSA SHA-1", rsaKey, crypto.SHA1},
+		{"ECDSA SHA-256", ecKey, crypto.SHA256},
+		{"ECDSA SHA-384", ecKey, crypto.SHA384},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := newSignedTestPackage(t)
+			cert := selfSignedCertificate(t, tt.signer)
+			signedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+			sig, err := pkg.Sign(tt.signer, []*x509.Certificate{cert}, &SignOptions{Hash: tt.hash, Time: signedAt})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if err := sig.Verify(); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !sig.SignatureTime.Equal(signedAt) {
+				t.Errorf("SignatureTime = %v, want %v", sig.SignatureTime, signedAt)
+			}
+
+			var buf bytes.Buffer
+			if err := pkg.WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			reopened, err := OpenBytes(buf.Bytes())
+			if err != nil {
+				t.Fatalf("OpenBytes() error = %v", err)
+			}
+			defer reopened.Close()
+
+			sigs, err := reopened.Signatures()
+			if err != nil {
+				t.Fatalf("Signatures() error = %v", err)
+			}
+			if len(sigs) != 1 {
+				t.Fatalf("Signatures() returned %d, want 1", len(sigs))
+			}
+			if !sigs[0].Certificate.Equal(cert) {
+				t.Error("signing certificate not preserved")
+			}
+			if len(sigs[0].SignedParts) != 4 {
+				t.Errorf("SignedParts = %v, want 2 parts and 2 relationship parts", sigs[0].SignedParts)
+			}
+			if err := reopened.VerifySignatures(); err != nil {
+				t.Fatalf("VerifySignatures() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestPackage_VerifySignatures_Tampered(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := selfSignedCertificate(t, key)
+
+	tests := []struct {
+		name   string
+		tamper func(pkg *Package)
+	}{
+		{"part content", func(pkg *Package) {
+			part, _ := pkg.GetPart("word/document.xml")
+			_ = part.SetContent([]byte(`<w:document xmlns:w="urn:test"><w:body>changed</w:body></w:document>`))
+		}},
+		{"relationship", func(pkg *Package) {
+			rels := pkg.GetRelationships("word/document.xml")
+			rels.Relationships[0].Target = "media/image2.png"
+		}},
+		{"content type", func(pkg *Package) {
+			pkg.ContentTypes().AddOverride("/word/media/image1.png", ContentTypeJPEG)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := newSignedTestPackage(t)
+			if _, err := pkg.Sign(key, []*x509.Certificate{cert}, nil); err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			tt.tamper(pkg)
+			if err := pkg.VerifySignatures(); !errors.Is(err, utils.ErrSignatureInvalid) {
+				t.Errorf("VerifySignatures() error = %v, want ErrSignatureInvalid", err)
+			}
+		})
+	}
+}
+
+func TestPackage_VerifySignatures_AddedContent(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := selfSignedCertificate(t, key)
+
+	tests := []struct {
+		name     string
+		add      func(pkg *Package)
+		unsigned []string
+	}{
+		{"part and relationship", func(pkg *Package) {
+			_, _ = pkg.AddPart("word/evil.xml", ContentTypeXML, []byte(`<evil/>`))
+			pkg.AddRelationship("word/document.xml", "evil.xml", RelTypeCustomXML)
+		}, []string{"word/_rels/document.xml.rels", "word/evil.xml"}},
+		{"part only", func(pkg *Package) {
+			_, _ = pkg.AddPart("word/evil.xml", ContentTypeXML, []byte(`<evil/>`))
+		}, []string{"word/evil.xml"}},
+		{"relationship only", func(pkg *Package) {
+			pkg.AddRelationshipWithTargetMode("word/document.xml", "https://example.com/", RelTypeHyperlink, TargetModeExternal)
+		}, []string{"word/_rels/document.xml.rels"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := newSignedTestPackage(t)
+			sig, err := pkg.Sign(key, []*x509.Certificate{cert}, nil)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if unsigned := sig.UnsignedParts(); len(unsigned) != 0 {
+				t.Fatalf("UnsignedParts() after signing = %v", unsigned)
+			}
+			tt.add(pkg)
+			if got := sig.UnsignedParts(); strings.Join(got, ",") != strings.Join(tt.unsigned, ",") {
+				t.Errorf("UnsignedParts() = %v, want %v", got, tt.unsigned)
+			}
+			if err := pkg.VerifySignatures(); !errors.Is(err, utils.ErrSignatureInvalid) {
+				t.Errorf("VerifySignatures() error = %v, want ErrSignatureInvalid", err)
+			}
+		})
+	}
+}
+
+func TestPackage_VerifySignatures_Unsigned(t *testing.T) {
+	pkg := newSignedTestPackage(t)
+	if err := pkg.VerifySignatures(); !errors.Is(err, utils.ErrSignatureNotFound) {
+		t.Errorf("VerifySignatures() error = %v, want ErrSignatureNotFound", err)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<a:root xmlns:a="urn:a" xmlns:b="urn:b" xmlns="urn:default"><a:child z="1" b:y="2" a="3"><!-- note --><empty/>x &amp; y</a:child></a:root>`
+	root, err := parseXMLTree([]byte(input))
+	if err != nil {
+		t.Fatalf("parseXMLTree() error = %v", err)
+	}
+	child := root.findChild("child")
+
+	tests := []struct {
+		name      string
+		algorithm string
+		want      string
+	}{
+		{"inclusive", AlgorithmC14N,
+			`<a:child xmlns="urn:default" xmlns:a="urn:a" xmlns:b="urn:b" a="3" z="1" b:y="2"><empty></empty>x &amp; y</a:child>`},
+		{"inclusive with comments", AlgorithmC14NComments,
+			`<a:child xmlns="urn:default" xmlns:a="urn:a" xmlns:b="urn:b" a="3" z="1" b:y="2"><!-- note --><empty></empty>x &amp; y</a:child>`},
+		{"exclusive", AlgorithmExcC14N,
+			`<a:child xmlns:a="urn:a" xmlns:b="urn:b" a="3" z="1" b:y="2"><empty xmlns="urn:default"></empty>x &amp; y</a:child>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalize(child, tt.algorithm, nil)
+			if err != nil {
+				t.Fatalf("canonicalize() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("canonicalize() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
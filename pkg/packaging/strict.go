@@ -0,0 +1,86 @@
+package packaging
+
+import "strings"
+
+// NSStrictPrefix is the common prefix of ISO/IEC 29500 Strict namespaces
+// and relationship types.
+const NSStrictPrefix = "http://purl.oclc.org/ooxml/"
+
+// RelTypeStrictOfficeDocument is the officeDocument relationship type of a
+// Strict package.
+const RelTypeStrictOfficeDocument = NSStrictPrefix + "officeDocument/relationships/officeDocument"
+
+// strictNames pairs Transitional names with their Strict equivalents. More
+// specific names come before the prefixes they start with, because the
+// replacers compare in argument order.
+var strictNames = [][2]string{
+	{RelTypeExtendedProps, NSStrictPrefix + "officeDocument/relationships/extendedProperties"},
+	{RelTypeCustomProps, NSStrictPrefix + "officeDocument/relationships/customProperties"},
+	{NSOfficeDocRels, NSStrictPrefix + "officeDocument/relationships"},
+	{NSExtendedProperties, NSStrictPrefix + "officeDocument/extendedProperties"},
+	{"http://schemas.openxmlformats.org/officeDocument/2006/custom-properties", NSStrictPrefix + "officeDocument/customProperties"},
+	{"http://schemas.openxmlformats.org/officeDocument/2006/", NSStrictPrefix + "officeDocument/"},
+	{NSWordprocessingML, NSStrictPrefix + "wordprocessingml/main"},
+	{NSSpreadsheetML, NSStrictPrefix + "spreadsheetml/main"},
+	{NSPresentationML, NSStrictPrefix + "presentationml/main"},
+	{"http://schemas.openxmlformats.org/drawingml/2006/", NSStrictPrefix + "drawingml/"},
+	{"http://schemas.openxmlformats.org/schemaLibrary/2006/main", NSStrictPrefix + "schemaLibrary/main"},
+}
+
+var (
+	strictToTransitional = newStrictReplacer(true)
+	transitionalToStrict = newStrictReplacer(false)
+)
+
+func newStrictReplacer(fromStrict bool) *strings.Replacer {
+	pairs := make([]string, 0, 2*len(strictNames))
+	for _, names := range strictNames {
+		if fromStrict {
+			pairs = append(pairs, names[1], names[0])
+		} else {
+			pairs = append(pairs, names[0], names[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// IsStrict reports whether the package was read from an ISO/IEC 29500
+// Strict file. Its parts and relationships are presented with Transitional
+// names; save with SaveOptions.Strict to write Strict again.
+func (p *Package) IsStrict() bool {
+	return p.strict
+}
+
+// convertStrictRelationships detects a Strict package from its
+// officeDocument relationship and maps all relationship types to
+// Transitional.
+func (p *Package) convertStrictRelationships() {
+	if p.GetRelationships("").FirstByType(RelTypeStrictOfficeDocument) == nil {
+		return
+	}
+	p.strict = true
+	for _, rels := range p.relationships {
+		for i := range rels.Relationships {
+			rels.Relationships[i].Type = strictToTransitional.Replace(rels.Relationships[i].Type)
+		}
+	}
+}
+
+// hasStrictNames reports whether parts of contentType carry namespaces that
+// differ between Strict and Transitional.
+func hasStrictNames(contentType string) bool {
+	return isXMLContentType(contentType) || contentType == ContentTypeVML
+}
+
+// fromStrict maps the Strict namespaces in XML part content to Transitional.
+func fromStrict(data []byte) []byte {
+	if !strings.Contains(string(data), NSStrictPrefix) {
+		return data
+	}
+	return []byte(strictToTransitional.Replace(string(data)))
+}
+
+// toStrict maps the Transitional namespaces in XML part content to Strict.
+func toStrict(data []byte) []byte {
+	return []byte(transitionalToStrict.Replace(string(data)))
+}
@@ -0,0 +1,156 @@
+package packaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// strictTestPackage returns a minimal Strict package as written by Word's
+// "Strict Open XML Document".
+func strictTestPackage(t *testing.T) []byte {
+	t.Helper()
+	entries := map[string]string{
+		ContentTypesPath: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/><Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/></Types>`,
+		PackageRelsPath: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://purl.oclc.org/ooxml/officeDocument/relationships/officeDocument" Target="word/document.xml"/><Relationship Id="rId2" Type="http://purl.oclc.org/ooxml/officeDocument/relationships/extendedProperties" Target="docProps/app.xml"/></Relationships>`,
+		"word/_rels/document.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://purl.oclc.org/ooxml/officeDocument/relationships/hyperlink" Target="https://example.com/" TargetMode="External"/></Relationships>`,
+		WordDocumentPath: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:r="http://purl.oclc.org/ooxml/officeDocument/relationships" xmlns:w="http://purl.oclc.org/ooxml/wordprocessingml/main" w:conformance="strict"><w:body><w:p><w:hyperlink r:id="rId1"><w:r><w:t>Strict</w:t></w:r></w:hyperlink></w:p></w:body></w:document>`,
+		AppPropertiesPath: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://purl.oclc.org/ooxml/officeDocument/extendedProperties" xmlns:vt="http://purl.oclc.org/ooxml/officeDocument/docPropsVTypes"><Application>Microsoft Office Word</Application></Properties>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		if err := writeZipEntry(zw, &zip.FileHeader{Name: name, Method: zip.Deflate}, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPackage_StrictConformance(t *testing.T) {
+	pkg, err := OpenBytes(strictTestPackage(t))
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer pkg.Close()
+	if !pkg.IsStrict() {
+		t.Fatal("IsStrict() = false, want true")
+	}
+	if pkg.MainPartPath() != WordDocumentPath {
+		t.Errorf("MainPartPath() = %q", pkg.MainPartPath())
+	}
+	if rel := pkg.GetRelationships(WordDocumentPath).FirstByType(RelTypeHyperlink); rel == nil {
+		t.Error("hyperlink relationship type not mapped to Transitional")
+	}
+	part, err := pkg.GetPart(WordDocumentPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := part.Content()
+	for _, want := range []string{`xmlns:w="` + NSWordprocessingML + `"`, `xmlns:r="` + NSOfficeDocRels + `"`} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("document.xml missing %s", want)
+		}
+	}
+	props, err := pkg.ExtendedProperties()
+	if err != nil {
+		t.Fatalf("ExtendedProperties() error = %v", err)
+	}
+	if props.Application != "Microsoft Office Word" {
+		t.Errorf("Application = %q", props.Application)
+	}
+
+	read := func(data []byte, name string) string {
+		t.Helper()
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range zr.File {
+			if f.Name == name {
+				content, _ := readZipFile(f)
+				return string(content)
+			}
+		}
+		t.Fatalf("%s not written", name)
+		return ""
+	}
+
+	// Saved as Transitional by default.
+	var transitional bytes.Buffer
+	if err := pkg.WriteTo(&transitional); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{PackageRelsPath, WordDocumentPath, AppPropertiesPath} {
+		if content := read(transitional.Bytes(), name); strings.Contains(content, NSStrictPrefix) {
+			t.Errorf("%s still uses Strict names: %s", name, content)
+		}
+	}
+
+	// SaveOptions.Strict writes Strict names again.
+	var strict bytes.Buffer
+	if err := pkg.WriteToWithOptions(&strict, &SaveOptions{Strict: true}); err != nil {
+		t.Fatal(err)
+	}
+	if rels := read(strict.Bytes(), PackageRelsPath); !strings.Contains(rels, RelTypeStrictOfficeDocument) ||
+		!strings.Contains(rels, NSStrictPrefix+"officeDocument/relationships/extendedProperties") {
+		t.Errorf("_rels/.rels = %s", rels)
+	}
+	if doc := read(strict.Bytes(), WordDocumentPath); !strings.Contains(doc, `xmlns:w="`+NSStrictPrefix+`wordprocessingml/main"`) {
+		t.Errorf("document.xml = %s", doc)
+	}
+	if app := read(strict.Bytes(), AppPropertiesPath); !strings.Contains(app, `xmlns="`+NSStrictPrefix+`officeDocument/extendedProperties"`) {
+		t.Errorf("app.xml = %s", app)
+	}
+	reopened, err := OpenBytes(strict.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if !reopened.IsStrict() || reopened.MainPartPath() != WordDocumentPath {
+		t.Errorf("reopened IsStrict() = %v, MainPartPath() = %q", reopened.IsStrict(), reopened.MainPartPath())
+	}
+}
+
+func TestPart_StrictStream(t *testing.T) {
+	pkg, err := OpenBytes(strictTestPackage(t))
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer pkg.Close()
+	part, err := pkg.GetPart(WordDocumentPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Streaming an unloaded Strict part must yield the converted content.
+	rc, err := part.Stream()
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	streamed, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := part.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed) == 0 || !bytes.Equal(streamed, content) {
+		t.Errorf("Stream() returned %d bytes, Content() %d", len(streamed), len(content))
+	}
+	if !bytes.Contains(streamed, []byte(NSWordprocessingML)) {
+		t.Errorf("Stream() content not mapped to Transitional: %s", streamed)
+	}
+}
@@ -0,0 +1,116 @@
+package packaging
+
+import (
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// thumbnailExtensions maps supported thumbnail content types to file extensions.
+var thumbnailExtensions = map[string]string{
+	ContentTypePNG:  "png",
+	ContentTypeJPEG: "jpeg",
+	ContentTypeGIF:  "gif",
+	ContentTypeBMP:  "bmp",
+	ContentTypeTIFF: "tiff",
+	ContentTypeEMF:  "emf",
+	ContentTypeWMF:  "wmf",
+}
+
+// Thumbnail returns the package thumbnail image (ECMA-376 Part 2 §9) and its
+// content type. It returns utils.ErrPartNotFound when the package has none.
+func (p *Package) Thumbnail() (contentType string, data []byte, err error) {
+	if p.closed {
+		return "", nil, utils.ErrDocumentClosed
+	}
+	partPath := p.thumbnailPath()
+	if partPath == "" {
+		return "", nil, utils.ErrPartNotFound
+	}
+	part, err := p.GetPart(partPath)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err = part.Content()
+	if err != nil {
+		return "", nil, err
+	}
+	return p.GetContentType(partPath), data, nil
+}
+
+// SetThumbnail stores data as docProps/thumbnail.<ext> and points the
+// package-level thumbnail relationship at it, replacing any existing thumbnail.
+func (p *Package) SetThumbnail(contentType string, data []byte) error {
+	if p.closed {
+		return utils.ErrDocumentClosed
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	ext, ok := thumbnailExtensions[contentType]
+	if !ok {
+		return utils.NewValidationError("contentType", "unsupported thumbnail image type", contentType)
+	}
+	if len(data) == 0 {
+		return utils.NewValidationError("data", "cannot be empty", nil)
+	}
+
+	partPath := ThumbnailPathPrefix + "." + ext
+	if old := p.thumbnailPath(); old != "" && old != partPath && p.PartExists(old) {
+		if err := p.DeletePart(old); err != nil {
+			return err
+		}
+	}
+
+	if part, err := p.GetPart(partPath); err == nil {
+		if err := part.SetContent(data); err != nil {
+			return err
+		}
+		part.contentType = contentType
+		p.contentTypes.EnsureContentType(partPath, contentType)
+	} else if _, err := p.AddPart(partPath, contentType, data); err != nil {
+		return err
+	}
+
+	rels := p.GetRelationships("")
+	for i := range rels.Relationships {
+		if rels.Relationships[i].Type == RelTypeThumbnail {
+			rels.Relationships[i].Target = partPath
+			rels.Relationships[i].TargetMode = TargetModeInternal
+			p.modified = true
+			return nil
+		}
+	}
+	rels.Add(RelTypeThumbnail, partPath, TargetModeInternal)
+	p.modified = true
+	return nil
+}
+
+// RemoveThumbnail deletes the package thumbnail and its relationship.
+func (p *Package) RemoveThumbnail() error {
+	if p.closed {
+		return utils.ErrDocumentClosed
+	}
+	partPath := p.thumbnailPath()
+	if partPath == "" {
+		return utils.ErrPartNotFound
+	}
+	if p.PartExists(partPath) {
+		if err := p.DeletePart(partPath); err != nil {
+			return err
+		}
+	}
+	rels := p.GetRelationships("")
+	for rel := rels.FirstByType(RelTypeThumbnail); rel != nil; rel = rels.FirstByType(RelTypeThumbnail) {
+		rels.Remove(rel.ID)
+	}
+	p.modified = true
+	return nil
+}
+
+// thumbnailPath resolves the thumbnail part from the package relationship.
+func (p *Package) thumbnailPath() string {
+	rel := p.GetRelationships("").FirstByType(RelTypeThumbnail)
+	if rel == nil || rel.TargetMode == TargetModeExternal {
+		return ""
+	}
+	return ResolveRelationshipTarget("", rel.Target)
+}
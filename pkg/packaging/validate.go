@@ -0,0 +1,272 @@
+package packaging
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// IssueKind classifies a structural problem found by Package.Validate.
+type IssueKind string
+
+// Structural issue kinds reported by Package.Validate.
+const (
+	IssueMissingTarget           IssueKind = "missing-target"
+	IssueMissingContentType      IssueKind = "missing-content-type"
+	IssueOrphanPart              IssueKind = "orphan-part"
+	IssueDuplicateRelationshipID IssueKind = "duplicate-relationship-id"
+	IssueInvalidPartName         IssueKind = "invalid-part-name"
+	IssueExternalTargetInternal  IssueKind = "external-target-internal"
+)
+
+// ValidationIssue describes a single structural problem in a package.
+type ValidationIssue struct {
+	Kind IssueKind
+	// PartURI is the offending part, or the relationship source part
+	// ("" for package-level relationships).
+	PartURI string
+	// RelationshipID is set for relationship issues.
+	RelationshipID string
+	Message        string
+}
+
+// String returns a human-readable description of the issue.
+func (i ValidationIssue) String() string {
+	location := "/" + i.PartURI
+	if i.RelationshipID != "" {
+		location += "#" + i.RelationshipID
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Kind, location, i.Message)
+}
+
+// Validate checks the package structure against ECMA-376 Part 2 and returns
+// every problem found. An empty result means no issues were detected.
+//
+// It reports relationships with missing targets, duplicate relationship IDs,
+// external targets marked as internal, parts without a content type, parts
+// not reachable from the package relationships, and invalid part names (§6.2.2).
+func (p *Package) Validate() ([]ValidationIssue, error) {
+	if p.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+
+	var issues []ValidationIssue
+	add := func(kind IssueKind, partURI, relID, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{
+			Kind:           kind,
+			PartURI:        partURI,
+			RelationshipID: relID,
+			Message:        fmt.Sprintf(format, args...),
+		})
+	}
+
+	partNames := p.validationPartNames()
+
+	// Part names and content types
+	seen := make(map[string]string)
+	for _, uri := range partNames {
+		if msg := checkPartName(uri); msg != "" {
+			add(IssueInvalidPartName, uri, "", "%s", msg)
+		}
+		folded := strings.ToLower(uri)
+		if other, ok := seen[folded]; ok {
+			add(IssueInvalidPartName, uri, "", "equivalent to part name /%s", other)
+		} else {
+			seen[folded] = uri
+		}
+		if p.contentTypes.GetContentType(uri) == "" {
+			add(IssueMissingContentType, uri, "", "no Default or Override in %s", ContentTypesPath)
+		}
+	}
+
+	// Relationships
+	for _, source := range p.relationshipSources() {
+		rels := p.relationships[source]
+		sourceURI := source
+		if source == "." {
+			sourceURI = ""
+		}
+		if len(rels.Relationships) > 0 {
+			relsPath := PackageRelsPath
+			if sourceURI != "" {
+				relsPath = RelationshipsPathForPart(sourceURI)
+			}
+			if p.contentTypes.GetContentType(relsPath) == "" {
+				add(IssueMissingContentType, relsPath, "", "no Default or Override in %s", ContentTypesPath)
+			}
+		}
+
+		ids := make(map[string]bool)
+		for _, rel := range rels.Relationships {
+			if ids[rel.ID] {
+				add(IssueDuplicateRelationshipID, sourceURI, rel.ID, "relationship ID is used more than once")
+			}
+			ids[rel.ID] = true
+
+			if rel.TargetMode == TargetModeExternal {
+				continue
+			}
+			if isAbsoluteURI(rel.Target) {
+				add(IssueExternalTargetInternal, sourceURI, rel.ID, "target %q is an absolute URI but TargetMode is Internal", rel.Target)
+				continue
+			}
+			if target, ok := p.targetPart(sourceURI, rel.Target); !ok {
+				add(IssueMissingTarget, sourceURI, rel.ID, "target /%s does not exist", target)
+			}
+		}
+	}
+
+	// Reachability from the package root
+	reachable := p.reachableParts()
+	for _, uri := range partNames {
+		if !reachable[uri] {
+			add(IssueOrphanPart, uri, "", "no relationship targets this part")
+		}
+	}
+
+	return issues, nil
+}
+
+// validationPartNames returns the sorted names of all parts except the
+// content types stream, relationship parts and ZIP directory entries.
+func (p *Package) validationPartNames() []string {
+	var names []string
+	for uri := range p.parts {
+		if uri == ContentTypesPath || strings.HasSuffix(uri, ".rels") || strings.HasSuffix(uri, "/") {
+			continue
+		}
+		names = append(names, uri)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// relationshipSources returns the sorted relationship source keys.
+func (p *Package) relationshipSources() []string {
+	sources := make([]string, 0, len(p.relationships))
+	for source := range p.relationships {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// reachableParts walks internal relationships from the package root.
+func (p *Package) reachableParts() map[string]bool {
+	reachable := make(map[string]bool)
+	queue := []string{""}
+	for len(queue) > 0 {
+		source := queue[0]
+		queue = queue[1:]
+		rels, ok := p.relationships[normalizePath(source)]
+		if !ok {
+			continue
+		}
+		for _, rel := range rels.Relationships {
+			if rel.TargetMode == TargetModeExternal || isAbsoluteURI(rel.Target) {
+				continue
+			}
+			target, ok := p.targetPart(source, rel.Target)
+			if !ok || target == "" || reachable[target] {
+				continue
+			}
+			reachable[target] = true
+			queue = append(queue, target)
+		}
+	}
+	return reachable
+}
+
+// targetPart resolves an internal relationship target to a part name,
+// dropping any fragment. It reports whether the part exists; fragment-only
+// targets resolve to "" and are treated as present.
+func (p *Package) targetPart(sourceURI, target string) (string, bool) {
+	if idx := strings.Index(target, "#"); idx >= 0 {
+		target = target[:idx]
+	}
+	if target == "" {
+		return "", true
+	}
+	resolved := normalizePath(ResolveRelationshipTarget(sourceURI, target))
+	if p.PartExists(resolved) {
+		return resolved, true
+	}
+	if unescaped, err := url.PathUnescape(resolved); err == nil && p.PartExists(unescaped) {
+		return unescaped, true
+	}
+	return resolved, false
+}
+
+// isAbsoluteURI reports whether target carries a URI scheme (http:, mailto:, file:, ...).
+func isAbsoluteURI(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	// Single letters are Windows drive letters rather than schemes.
+	return len(u.Scheme) > 1
+}
+
+// checkPartName applies the part name grammar of ECMA-376 Part 2 §6.2.2 and
+// returns a description of the first violation, or "".
+func checkPartName(name string) string {
+	if strings.HasSuffix(name, "/") {
+		return "part name ends with a forward slash"
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" {
+			return "part name contains an empty segment"
+		}
+		if segment == "." || segment == ".." {
+			return "part name contains a dot segment"
+		}
+		if strings.HasSuffix(segment, ".") {
+			return fmt.Sprintf("segment %q ends with a dot", segment)
+		}
+		for i := 0; i < len(segment); i++ {
+			c := segment[i]
+			if c == '%' {
+				if i+2 >= len(segment) || !isHex(segment[i+1]) || !isHex(segment[i+2]) {
+					return fmt.Sprintf("segment %q has a malformed percent-encoding", segment)
+				}
+				decoded := unhex(segment[i+1])<<4 | unhex(segment[i+2])
+				if decoded == '/' || decoded == '\\' {
+					return fmt.Sprintf("segment %q percent-encodes a slash", segment)
+				}
+				if isUnreserved(decoded) {
+					return fmt.Sprintf("segment %q percent-encodes an unreserved character", segment)
+				}
+				i += 2
+				continue
+			}
+			if c >= 0x80 || isUnreserved(c) || strings.IndexByte("!$&'()*+,;=:@", c) >= 0 {
+				continue
+			}
+			return fmt.Sprintf("segment %q contains invalid character %q", segment, c)
+		}
+	}
+	return ""
+}
+
+func isUnreserved(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
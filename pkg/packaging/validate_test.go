@@ -0,0 +1,158 @@
+package packaging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPackage_Validate_Clean(t *testing.T) {
+	pkg := New()
+	pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<w:document/>`))
+	pkg.AddPart("word/media/image1.png", ContentTypePNG, []byte{0x89, 0x50, 0x4E, 0x47})
+	pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	pkg.AddRelationship("word/document.xml", "media/image1.png", RelTypeImage)
+	pkg.AddRelationshipWithTargetMode("word/document.xml", "https://example.com/", RelTypeHyperlink, TargetModeExternal)
+
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	reopened, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer reopened.Close()
+
+	issues, err := reopened.Validate()
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("unexpected issue: %s", issue)
+	}
+}
+
+func TestPackage_Validate_Problems(t *testing.T) {
+	pkg := New()
+	pkg.AddPart("word/document.xml", ContentTypeWordDocument, []byte(`<w:document/>`))
+	pkg.AddPart("word/orphan.xml", ContentTypeXML, []byte(`<orphan/>`))
+	pkg.AddPart("word/bad name.xml", ContentTypeXML, []byte(`<bad/>`))
+	pkg.AddPart("word/blob.bin", "", []byte{0x01})
+	pkg.AddRelationship("", "word/document.xml", RelTypeOfficeDocument)
+	pkg.AddRelationship("word/document.xml", "bad name.xml", RelTypeCustomXML)
+	pkg.AddRelationship("word/document.xml", "blob.bin", RelTypeCustomXML)
+	pkg.AddRelationship("word/document.xml", "media/missing.png", RelTypeImage)
+	pkg.AddRelationship("word/document.xml", "https://example.com/", RelTypeHyperlink)
+	rels := pkg.GetRelationships("word/document.xml")
+	rels.Relationships = append(rels.Relationships, Relationship{ID: "rId1", Type: RelTypeStyles, Target: "document.xml"})
+
+	issues, err := pkg.Validate()
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	want := map[IssueKind]string{
+		IssueOrphanPart:              "word/orphan.xml",
+		IssueInvalidPartName:         "word/bad name.xml",
+		IssueMissingContentType:      "word/blob.bin",
+		IssueMissingTarget:           "word/document.xml",
+		IssueExternalTargetInternal:  "word/document.xml",
+		IssueDuplicateRelationshipID: "word/document.xml",
+	}
+	got := make(map[IssueKind][]ValidationIssue)
+	for _, issue := range issues {
+		got[issue.Kind] = append(got[issue.Kind], issue)
+	}
+	for kind, partURI := range want {
+		found := false
+		for _, issue := range got[kind] {
+			if issue.PartURI == partURI {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing %s issue for %s; got %v", kind, partURI, issues)
+		}
+	}
+	if len(issues) != len(want) {
+		t.Errorf("Validate() returned %d issues, want %d: %v", len(issues), len(want), issues)
+	}
+}
+
+func TestCheckPartName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"word/document.xml", true},
+		{"word/media/image%20one.png", true},
+		{"xl/worksheets/sheet1.xml", true},
+		{"word/café.xml", true},
+		{"word/", false},
+		{"word//document.xml", false},
+		{"word/./document.xml", false},
+		{"word/document.", false},
+		{"word%2Fdocument.xml", false},
+		{"word/%41.xml", false},
+		{"word/%zz.xml", false},
+		{"word/doc ument.xml", false},
+		{"word/doc[1].xml", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := checkPartName(tt.name)
+			if (msg == "") != tt.valid {
+				t.Errorf("checkPartName(%q) = %q, want valid=%v", tt.name, msg, tt.valid)
+			}
+		})
+	}
+}
+
+func TestPackage_RemoveOrphanParts(t *testing.T) {
+	pkg := New()
+	pkg.AddPart("ppt/presentation.xml", ContentTypePresentation, []byte(`<p:presentation/>`))
+	pkg.AddPart("ppt/slides/slide1.xml", ContentTypeSlide, []byte(`<p:sld/>`))
+	pkg.AddPart("ppt/slides/slide2.xml", ContentTypeSlide, []byte(`<p:sld/>`))
+	pkg.AddPart("ppt/media/image1.png", ContentTypePNG, []byte{0x89})
+	pkg.AddPart("ppt/media/image2.png", ContentTypePNG, []byte{0x89})
+	pkg.AddRelationship("", "ppt/presentation.xml", RelTypeOfficeDocument)
+	pkg.AddRelationship("ppt/presentation.xml", "slides/slide1.xml", RelTypeSlide)
+	pkg.AddRelationship("ppt/slides/slide1.xml", "../media/image1.png", RelTypeImage)
+	// slide2 and its image are no longer referenced from the presentation
+	pkg.AddRelationship("ppt/slides/slide2.xml", "../media/image2.png", RelTypeImage)
+
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	reopened, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer reopened.Close()
+
+	removed, err := reopened.RemoveOrphanParts()
+	if err != nil {
+		t.Fatalf("RemoveOrphanParts() error = %v", err)
+	}
+	want := []string{"ppt/media/image2.png", "ppt/slides/slide2.xml"}
+	if len(removed) != len(want) || removed[0] != want[0] || removed[1] != want[1] {
+		t.Errorf("RemoveOrphanParts() = %v, want %v", removed, want)
+	}
+	if reopened.PartExists("ppt/slides/_rels/slide2.xml.rels") {
+		t.Error("relationships part of removed slide was kept")
+	}
+	if ct := reopened.ContentTypes().GetContentType("/ppt/slides/slide2.xml"); ct == ContentTypeSlide {
+		t.Error("content type override of removed slide was kept")
+	}
+	if !reopened.PartExists("ppt/media/image1.png") {
+		t.Error("reachable image was removed")
+	}
+	issues, err := reopened.Validate()
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("unexpected issue after removal: %s", issue)
+	}
+}
@@ -0,0 +1,233 @@
+package packaging
+
+import (
+	"sort"
+
+	"github.com/rcarmo/go-ooxml/pkg/utils"
+)
+
+// Variant identifies the regular, template and macro-enabled flavours of an
+// Office format. They differ only in the content type of the main part.
+type Variant int
+
+const (
+	// VariantDefault is a regular file (.docx, .xlsx, .pptx).
+	VariantDefault Variant = iota
+	// VariantTemplate is a template (.dotx, .xltx, .potx).
+	VariantTemplate
+	// VariantMacroEnabled is a macro-enabled file (.docm, .xlsm, .pptm).
+	VariantMacroEnabled
+	// VariantMacroEnabledTemplate is a macro-enabled template (.dotm, .xltm, .potm).
+	VariantMacroEnabledTemplate
+)
+
+// IsTemplate reports whether v is a template variant.
+func (v Variant) IsTemplate() bool {
+	return v == VariantTemplate || v == VariantMacroEnabledTemplate
+}
+
+// IsMacroEnabled reports whether v may carry a VBA project.
+func (v Variant) IsMacroEnabled() bool {
+	return v == VariantMacroEnabled || v == VariantMacroEnabledTemplate
+}
+
+// WithTemplate returns the variant with the template flag set as given.
+func (v Variant) WithTemplate(template bool) Variant {
+	return variantOf(template, v.IsMacroEnabled())
+}
+
+// WithMacros returns the variant with the macro-enabled flag set as given.
+func (v Variant) WithMacros(macroEnabled bool) Variant {
+	return variantOf(v.IsTemplate(), macroEnabled)
+}
+
+func variantOf(template, macroEnabled bool) Variant {
+	switch {
+	case template && macroEnabled:
+		return VariantMacroEnabledTemplate
+	case macroEnabled:
+		return VariantMacroEnabled
+	case template:
+		return VariantTemplate
+	}
+	return VariantDefault
+}
+
+// mainContentTypes lists each application's main part content types,
+// indexed by Variant.
+var mainContentTypes = [][4]string{
+	{ContentTypeWordDocument, ContentTypeWordTemplate, ContentTypeWordDocumentMacroEnabled, ContentTypeWordTemplateMacroEnabled},
+	{ContentTypeWorkbook, ContentTypeWorkbookTemplate, ContentTypeWorkbookMacroEnabled, ContentTypeWorkbookTemplateMacroEnabled},
+	{ContentTypePresentation, ContentTypePresentationTemplate, ContentTypePresentationMacroEnabled, ContentTypePresentationTemplateMacroEnabled},
+}
+
+// macroRelTypes are relationships to parts that only macro-enabled files may contain.
+var macroRelTypes = map[string]bool{
+	RelTypeVBAProject:           true,
+	RelTypeKeyMapCustomizations: true,
+	RelTypeAttachedToolbars:     true,
+}
+
+// MainPartPath returns the part targeted by the package officeDocument
+// relationship, or "" when there is none.
+func (p *Package) MainPartPath() string {
+	rel := p.GetRelationships("").FirstByType(RelTypeOfficeDocument)
+	if rel == nil || rel.TargetMode == TargetModeExternal {
+		return ""
+	}
+	return ResolveRelationshipTarget("", rel.Target)
+}
+
+// MainContentType returns the content type of the main part.
+func (p *Package) MainContentType() string {
+	if partPath := p.MainPartPath(); partPath != "" {
+		return p.GetContentType(partPath)
+	}
+	return ""
+}
+
+// Variant reports the flavour of the main part. Packages whose main content
+// type has no variants (e.g. slide shows) report VariantDefault.
+func (p *Package) Variant() Variant {
+	contentType := p.MainContentType()
+	for _, types := range mainContentTypes {
+		for v, ct := range types {
+			if ct == contentType {
+				return Variant(v)
+			}
+		}
+	}
+	return VariantDefault
+}
+
+// SetVariant switches the main part to the given flavour of the same
+// application. Like Office's "Save As .docx", choosing a variant that is not
+// macro-enabled removes the VBA project.
+func (p *Package) SetVariant(v Variant) error {
+	if p.closed {
+		return utils.ErrDocumentClosed
+	}
+	if v < VariantDefault || v > VariantMacroEnabledTemplate {
+		return utils.NewValidationError("variant", "unknown package variant", int(v))
+	}
+	partPath := p.MainPartPath()
+	contentType := p.GetContentType(partPath)
+	for _, types := range mainContentTypes {
+		for _, ct := range types {
+			if ct != contentType {
+				continue
+			}
+			if !v.IsMacroEnabled() {
+				if err := p.removeMacroParts(); err != nil {
+					return err
+				}
+			}
+			p.contentTypes.EnsureContentType(partPath, types[v])
+			if part, ok := p.parts[normalizePath(partPath)]; ok {
+				part.contentType = types[v]
+			}
+			p.modified = true
+			return nil
+		}
+	}
+	return utils.NewValidationError("contentType", "main part has no template or macro-enabled variants", contentType)
+}
+
+// HasMacros reports whether the package contains a VBA project.
+func (p *Package) HasMacros() bool {
+	for _, rels := range p.relationships {
+		for _, rel := range rels.Relationships {
+			if rel.Type == RelTypeVBAProject {
+				return true
+			}
+		}
+	}
+	for uri := range p.parts {
+		if p.GetContentType(uri) == ContentTypeVBAProject {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveMacros deletes the VBA project with the parts it owns (such as Word's
+// vbaData.xml) and switches a macro-enabled main part to its macro-free
+// variant. It returns the sorted URIs of the removed parts.
+func (p *Package) RemoveMacros() ([]string, error) {
+	if p.closed {
+		return nil, utils.ErrDocumentClosed
+	}
+	removed := p.macroParts()
+	if v := p.Variant(); v.IsMacroEnabled() {
+		// SetVariant drops the macro parts when leaving a macro-enabled variant.
+		return removed, p.SetVariant(v.WithMacros(false))
+	}
+	return removed, p.removeMacroParts()
+}
+
+// removeMacroParts deletes every macro part and the relationships to it.
+func (p *Package) removeMacroParts() error {
+	for _, uri := range p.macroParts() {
+		if err := p.DeletePart(uri); err != nil {
+			return err
+		}
+		p.removeRelationshipsOf(uri)
+	}
+	for _, rels := range p.relationships {
+		var ids []string
+		for _, rel := range rels.Relationships {
+			if macroRelTypes[rel.Type] {
+				ids = append(ids, rel.ID)
+			}
+		}
+		for _, id := range ids {
+			rels.Remove(id)
+			p.modified = true
+		}
+	}
+	return nil
+}
+
+// macroParts returns the sorted URIs of macro parts and the parts they own.
+func (p *Package) macroParts() []string {
+	found := make(map[string]bool)
+	var queue []string
+	add := func(uri string) {
+		uri = normalizePath(uri)
+		if !found[uri] && p.PartExists(uri) {
+			found[uri] = true
+			queue = append(queue, uri)
+		}
+	}
+	for source, rels := range p.relationships {
+		if source == "." {
+			source = ""
+		}
+		for _, rel := range rels.Relationships {
+			if macroRelTypes[rel.Type] && rel.TargetMode != TargetModeExternal {
+				add(ResolveRelationshipTarget(source, rel.Target))
+			}
+		}
+	}
+	for uri := range p.parts {
+		if p.GetContentType(uri) == ContentTypeVBAProject {
+			add(uri)
+		}
+	}
+	for len(queue) > 0 {
+		uri := queue[0]
+		queue = queue[1:]
+		for _, rel := range p.GetRelationships(uri).Relationships {
+			if rel.TargetMode != TargetModeExternal {
+				add(ResolveRelationshipTarget(uri, rel.Target))
+			}
+		}
+	}
+
+	parts := make([]string, 0, len(found))
+	for uri := range found {
+		parts = append(parts, uri)
+	}
+	sort.Strings(parts)
+	return parts
+}
@@ -0,0 +1,108 @@
+package packaging
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// newMacroDocument builds a minimal .docm with a VBA project and its vbaData part.
+func newMacroDocument(t *testing.T) *Package {
+	t.Helper()
+	pkg := New()
+	_, _ = pkg.AddPart(WordDocumentPath, ContentTypeWordDocumentMacroEnabled, []byte(`<w:document/>`))
+	_, _ = pkg.AddPart("word/vbaProject.bin", ContentTypeVBAProject, []byte{0xD0, 0xCF, 0x11, 0xE0})
+	_, _ = pkg.AddPart("word/vbaData.xml", ContentTypeWordVBAData, []byte(`<wne:vbaSuppData/>`))
+	pkg.AddRelationship("", WordDocumentPath, RelTypeOfficeDocument)
+	pkg.AddRelationship(WordDocumentPath, "vbaProject.bin", RelTypeVBAProject)
+	pkg.AddRelationship("word/vbaProject.bin", "vbaData.xml", RelTypeWordVBAData)
+	return pkg
+}
+
+func TestPackage_Variant(t *testing.T) {
+	pkg := newMacroDocument(t)
+	if v := pkg.Variant(); v != VariantMacroEnabled {
+		t.Fatalf("Variant() = %v, want VariantMacroEnabled", v)
+	}
+	if !pkg.HasMacros() {
+		t.Fatal("HasMacros() = false, want true")
+	}
+
+	if err := pkg.SetVariant(VariantMacroEnabledTemplate); err != nil {
+		t.Fatalf("SetVariant() error = %v", err)
+	}
+	if ct := pkg.MainContentType(); ct != ContentTypeWordTemplateMacroEnabled {
+		t.Errorf("MainContentType() = %q, want %q", ct, ContentTypeWordTemplateMacroEnabled)
+	}
+	if !pkg.HasMacros() {
+		t.Error("switching between macro-enabled variants must keep the VBA project")
+	}
+
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if v := reopened.Variant(); v != VariantMacroEnabledTemplate {
+		t.Errorf("reopened Variant() = %v, want VariantMacroEnabledTemplate", v)
+	}
+	if !reopened.PartExists("word/vbaProject.bin") || !reopened.HasMacros() {
+		t.Error("vbaProject.bin not preserved")
+	}
+
+	if err := reopened.SetVariant(VariantTemplate); err != nil {
+		t.Fatalf("SetVariant() error = %v", err)
+	}
+	if reopened.HasMacros() || reopened.PartExists("word/vbaProject.bin") {
+		t.Error("macro-free variant must not keep the VBA project")
+	}
+
+	plain := New()
+	_, _ = plain.AddPart("custom/main.xml", ContentTypeXML, []byte(`<main/>`))
+	plain.AddRelationship("", "custom/main.xml", RelTypeOfficeDocument)
+	if err := plain.SetVariant(VariantTemplate); err == nil {
+		t.Error("SetVariant() on a main part without variants should fail")
+	}
+}
+
+func TestPackage_RemoveMacros(t *testing.T) {
+	pkg := newMacroDocument(t)
+	removed, err := pkg.RemoveMacros()
+	if err != nil {
+		t.Fatalf("RemoveMacros() error = %v", err)
+	}
+	if want := []string{"word/vbaData.xml", "word/vbaProject.bin"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("RemoveMacros() = %v, want %v", removed, want)
+	}
+	if pkg.HasMacros() {
+		t.Error("HasMacros() = true after RemoveMacros")
+	}
+	if v := pkg.Variant(); v != VariantDefault {
+		t.Errorf("Variant() = %v, want VariantDefault", v)
+	}
+	if rels := pkg.GetRelationships(WordDocumentPath); rels.FirstByType(RelTypeVBAProject) != nil {
+		t.Error("vbaProject relationship not removed")
+	}
+
+	// StripMacros applies the same clean-up on save.
+	pkg = newMacroDocument(t)
+	var buf bytes.Buffer
+	if err := pkg.WriteToWithOptions(&buf, &SaveOptions{StripMacros: true}); err != nil {
+		t.Fatal(err)
+	}
+	clean, err := OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clean.Close()
+	if clean.HasMacros() || clean.Variant() != VariantDefault {
+		t.Errorf("StripMacros left macros = %v, variant = %v", clean.HasMacros(), clean.Variant())
+	}
+	if issues, err := clean.Validate(); err != nil || len(issues) != 0 {
+		t.Errorf("Validate() = %v, %v", issues, err)
+	}
+}
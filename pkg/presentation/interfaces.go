@@ -1,9 +1,13 @@
 package presentation
 
 import (
+	"context"
+	"io"
+
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/dml"
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/pml"
+	"github.com/rcarmo/go-ooxml/pkg/packaging"
 	"github.com/rcarmo/go-ooxml/pkg/utils"
 )
 
@@ -19,10 +23,60 @@ var (
 // PresentationProperties maps to core properties.
 type PresentationProperties = common.CoreProperties
 
+// SaveOptions configures how the presentation package is written.
+type SaveOptions = packaging.SaveOptions
+
+// OpenOptions configures how the presentation package is opened.
+type OpenOptions = packaging.OpenOptions
+
+// Variant identifies the regular, template and macro-enabled flavours of the presentation format.
+type Variant = packaging.Variant
+
+// Presentation variants.
+const (
+	VariantDefault              = packaging.VariantDefault
+	VariantTemplate             = packaging.VariantTemplate
+	VariantMacroEnabled         = packaging.VariantMacroEnabled
+	VariantMacroEnabledTemplate = packaging.VariantMacroEnabledTemplate
+)
+
+// EmbeddedObject describes an object embedded in the presentation, such as a chart's
+// data workbook.
+type EmbeddedObject = packaging.EmbeddedObject
+
+// Diagnostic describes a part that could not be loaded when opening the
+// presentation with OpenOptions.Recover, and what was done about it.
+type Diagnostic = packaging.Diagnostic
+
+// Severity ranks a Diagnostic.
+type Severity = packaging.Severity
+
+// Diagnostic severities.
+const (
+	SeverityInfo    = packaging.SeverityInfo
+	SeverityWarning = packaging.SeverityWarning
+	SeverityError   = packaging.SeverityError
+)
+
 // Presentation represents a PowerPoint presentation.
 type Presentation interface {
 	Save() error
 	SaveAs(path string) error
+	SaveWithOptions(opts *SaveOptions) error
+	SaveAsWithOptions(path string, opts *SaveOptions) error
+	WriteTo(w io.Writer) (int64, error)
+	SaveContext(ctx context.Context, opts *SaveOptions) error
+	SaveAsContext(ctx context.Context, path string, opts *SaveOptions) error
+	WriteToContext(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error)
+	SaveAsTemplate(path string) error
+	Variant() Variant
+	SetVariant(v Variant) error
+	HasMacros() bool
+	IsStrict() bool
+	Diagnostics() []Diagnostic
+	EmbeddedObjects() []*EmbeddedObject
+	WriteDir(dir string, pretty bool) error
+	WriteFlatOPC(w io.Writer) error
 	Close() error
 	Slides() []Slide
 	Slide(index int) (Slide, error)
@@ -35,6 +89,16 @@ type Presentation interface {
 	SlideCount() int
 	CoreProperties() (*common.CoreProperties, error)
 	SetCoreProperties(props *common.CoreProperties) error
+	CustomProperties() (*common.CustomProperties, error)
+	SetCustomProperties(props *common.CustomProperties) error
+	CustomProperty(name string) (*common.CustomProperty, error)
+	SetCustomProperty(name string, value interface{}) error
+	DeleteCustomProperty(name string) error
+	ExtendedProperties() (*common.ExtendedProperties, error)
+	SetExtendedProperties(props *common.ExtendedProperties) error
+	UpdateExtendedProperties() error
+	Thumbnail() (contentType string, data []byte, err error)
+	SetThumbnail(contentType string, data []byte) error
 	Masters() []SlideMaster
 	Layouts() []SlideLayout
 	Properties() PresentationProperties
@@ -3,10 +3,12 @@ package presentation
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
@@ -93,25 +95,102 @@ func NewWithSize(width, height int64) (Presentation, error) {
 
 // Open opens an existing presentation from a file path.
 func Open(path string) (Presentation, error) {
-	pkg, err := packaging.Open(path)
+	return OpenWithOptions(path, nil)
+}
+
+// OpenWithOptions opens an existing presentation using opts, e.g. to supply the
+// password of an encrypted file.
+func OpenWithOptions(path string, opts *OpenOptions) (Presentation, error) {
+	pkg, err := packaging.OpenWithOptions(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	p, err := openFromPackage(context.Background(), pkg)
+	if err != nil {
+		return nil, err
+	}
+	p.path = path
+	return p, nil
+}
+
+// OpenContext opens an existing presentation like OpenWithOptions, giving up
+// with ctx.Err() once ctx is done. Cancellation is checked between slides
+// and parts. opts may be nil.
+func OpenContext(ctx context.Context, path string, opts *OpenOptions) (Presentation, error) {
+	pkg, err := packaging.OpenContext(ctx, path, opts)
 	if err != nil {
 		return nil, err
 	}
-	p, err := openFromPackage(pkg)
+	p, err := openFromPackage(ctx, pkg)
+	if err == nil {
+		// Optional parts are parsed leniently; report a cancellation they hid.
+		err = ctx.Err()
+	}
 	if err != nil {
+		pkg.Close()
 		return nil, err
 	}
 	p.path = path
 	return p, nil
 }
 
+// OpenBytes opens a presentation held in memory.
+func OpenBytes(data []byte) (Presentation, error) {
+	return OpenReader(bytes.NewReader(data), int64(len(data)))
+}
+
 // OpenReader opens a presentation from an io.ReaderAt.
+//
+// Parts are read from r on demand, so r must remain readable until the
+// package is closed.
 func OpenReader(r io.ReaderAt, size int64) (Presentation, error) {
-	pkg, err := packaging.OpenReader(r, size)
+	return OpenReaderWithOptions(r, size, nil)
+}
+
+// OpenReaderWithOptions opens a presentation from an io.ReaderAt using opts.
+// Unless the package is encrypted, r must remain readable until it is
+// closed.
+func OpenReaderWithOptions(r io.ReaderAt, size int64, opts *OpenOptions) (Presentation, error) {
+	pkg, err := packaging.OpenReaderWithOptions(r, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	return openFromPackage(context.Background(), pkg)
+}
+
+// OpenEmbedded opens a presentation embedded in another package. Save writes
+// the changes back into the parent, which must then be saved itself.
+func OpenEmbedded(obj *EmbeddedObject) (Presentation, error) {
+	pkg, err := obj.Open()
+	if err != nil {
+		return nil, err
+	}
+	return openFromPackage(context.Background(), pkg)
+}
+
+// OpenFS opens a presentation from an unzipped directory tree, such as one written
+// by WriteDir or bundled with embed.FS.
+func OpenFS(fsys fs.FS) (Presentation, error) {
+	pkg, err := packaging.OpenFS(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return openFromPackage(context.Background(), pkg)
+}
+
+// OpenFlatOPC opens a presentation saved as a single Flat OPC XML file.
+func OpenFlatOPC(r io.Reader) (Presentation, error) {
+	return OpenFlatOPCWithOptions(r, nil)
+}
+
+// OpenFlatOPCWithOptions opens a presentation saved as a single Flat OPC XML file
+// using opts, e.g. to enforce resource limits on untrusted input.
+func OpenFlatOPCWithOptions(r io.Reader, opts *OpenOptions) (Presentation, error) {
+	pkg, err := packaging.OpenFlatOPCWithOptions(r, opts)
 	if err != nil {
 		return nil, err
 	}
-	return openFromPackage(pkg)
+	return openFromPackage(context.Background(), pkg)
 }
 
 func newFromTemplate() (*presentationImpl, error) {
@@ -122,7 +201,7 @@ func newFromTemplate() (*presentationImpl, error) {
 	if err != nil {
 		return nil, err
 	}
-	p, err := openFromPackage(pkg)
+	p, err := openFromPackage(context.Background(), pkg)
 	if err != nil {
 		return nil, err
 	}
@@ -192,7 +271,7 @@ func newEmptyPresentation(width, height int64) (*presentationImpl, error) {
 	return p, nil
 }
 
-func openFromPackage(pkg *packaging.Package) (*presentationImpl, error) {
+func openFromPackage(ctx context.Context, pkg *packaging.Package) (*presentationImpl, error) {
 	p := &presentationImpl{
 		pkg:         pkg,
 		slides:      make([]*slideImpl, 0),
@@ -207,18 +286,18 @@ func openFromPackage(pkg *packaging.Package) (*presentationImpl, error) {
 	}
 
 	// Parse presentation.xml
-	if err := p.parsePresentation(); err != nil {
+	if err := p.parsePresentation(ctx); err != nil {
 		return nil, err
 	}
 
 	// Parse slides
-	if err := p.parseSlides(); err != nil {
+	if err := p.parseSlides(ctx); err != nil {
 		return nil, err
 	}
 
 	p.parseMastersAndLayouts()
 	p.parseNotesMaster()
-	p.parseCommentAuthors()
+	p.parseCommentAuthors(ctx)
 	p.captureAdvancedParts()
 	p.captureSlideRelationships()
 
@@ -227,6 +306,13 @@ func openFromPackage(pkg *packaging.Package) (*presentationImpl, error) {
 
 // Save saves the presentation to its original path.
 func (p *presentationImpl) Save() error {
+	if p.path == "" && p.pkg.Embedded() != nil {
+		// Opened with OpenEmbedded: write back into the parent package.
+		if err := p.updatePackage(context.Background()); err != nil {
+			return err
+		}
+		return p.pkg.Save()
+	}
 	if p.path == "" {
 		return utils.ErrPathNotSet
 	}
@@ -236,12 +322,167 @@ func (p *presentationImpl) Save() error {
 // SaveAs saves the presentation to a new path.
 func (p *presentationImpl) SaveAs(path string) error {
 	p.path = filepath.Clean(path)
-	if err := p.updatePackage(); err != nil {
+	if err := p.updatePackage(context.Background()); err != nil {
 		return err
 	}
 	return p.pkg.SaveAs(path)
 }
 
+// SaveWithOptions saves the presentation to its original path using opts.
+func (p *presentationImpl) SaveWithOptions(opts *SaveOptions) error {
+	return p.saveWithOptions(context.Background(), opts)
+}
+
+func (p *presentationImpl) saveWithOptions(ctx context.Context, opts *SaveOptions) error {
+	if p.path == "" && p.pkg.Embedded() != nil {
+		// Opened with OpenEmbedded: write back into the parent package.
+		if err := p.prepareSave(ctx, opts); err != nil {
+			return err
+		}
+		if err := p.pkg.SaveContext(ctx, opts); err != nil {
+			return err
+		}
+		p.pruneRemovedParts()
+		return nil
+	}
+	if p.path == "" {
+		return utils.ErrPathNotSet
+	}
+	return p.saveAsWithOptions(ctx, p.path, opts)
+}
+
+// SaveAsWithOptions saves the presentation to a new path using opts.
+func (p *presentationImpl) SaveAsWithOptions(path string, opts *SaveOptions) error {
+	return p.saveAsWithOptions(context.Background(), path, opts)
+}
+
+func (p *presentationImpl) saveAsWithOptions(ctx context.Context, path string, opts *SaveOptions) error {
+	p.path = filepath.Clean(path)
+	if err := p.prepareSave(ctx, opts); err != nil {
+		return err
+	}
+	if err := p.pkg.SaveAsContext(ctx, path, opts); err != nil {
+		return err
+	}
+	p.pruneRemovedParts()
+	return nil
+}
+
+// WriteTo writes the presentation to w as a ZIP archive without touching
+// disk, encrypted like SaveAs when a password is set, and returns the number
+// of bytes written, implementing io.WriterTo.
+func (p *presentationImpl) WriteTo(w io.Writer) (int64, error) {
+	return p.writeTo(context.Background(), w, nil)
+}
+
+// SaveContext saves the presentation to its original path like
+// SaveWithOptions, stopping between slides, shapes and parts once ctx is
+// done. The target is only replaced once complete.
+func (p *presentationImpl) SaveContext(ctx context.Context, opts *SaveOptions) error {
+	return p.saveWithOptions(ctx, opts)
+}
+
+// SaveAsContext saves the presentation to a new path like SaveAsWithOptions,
+// stopping between slides, shapes and parts once ctx is done. The target is
+// only replaced once complete.
+func (p *presentationImpl) SaveAsContext(ctx context.Context, path string, opts *SaveOptions) error {
+	return p.saveAsWithOptions(ctx, path, opts)
+}
+
+// WriteToContext writes the presentation to w as a ZIP archive, stopping
+// once ctx is done, and returns the number of bytes written.
+func (p *presentationImpl) WriteToContext(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error) {
+	return p.writeTo(ctx, w, opts)
+}
+
+// writeTo runs the SaveAsWithOptions pipeline against w instead of a file.
+func (p *presentationImpl) writeTo(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error) {
+	if err := p.prepareSave(ctx, opts); err != nil {
+		return 0, err
+	}
+	n, err := p.pkg.WriteToContext(ctx, w, opts)
+	if err != nil {
+		return n, err
+	}
+	p.pruneRemovedParts()
+	return n, nil
+}
+
+// prepareSave writes the presentation state into the package before it is
+// saved with opts.
+func (p *presentationImpl) prepareSave(ctx context.Context, opts *SaveOptions) error {
+	if err := p.updatePackage(ctx); err != nil {
+		return err
+	}
+	if opts != nil && opts.RemoveOrphanParts {
+		// Without their relationships, deleted slides become orphans.
+		p.removeDeletedSlideRels()
+	}
+	if opts != nil && opts.UpdateExtendedProperties {
+		return p.UpdateExtendedProperties()
+	}
+	return nil
+}
+
+// SaveAsTemplate saves the presentation as a template (.potx or .potm, keeping
+// macros) to a new path. The presentation remains a template afterwards.
+func (p *presentationImpl) SaveAsTemplate(path string) error {
+	if err := p.SetVariant(p.Variant().WithTemplate(true)); err != nil {
+		return err
+	}
+	return p.SaveAs(path)
+}
+
+// Variant reports whether the presentation is a regular, template or macro-enabled file.
+func (p *presentationImpl) Variant() Variant {
+	return p.pkg.Variant()
+}
+
+// SetVariant converts the presentation to another variant. Converting to a variant
+// that is not macro-enabled removes the VBA project.
+func (p *presentationImpl) SetVariant(v Variant) error {
+	return p.pkg.SetVariant(v)
+}
+
+// HasMacros reports whether the presentation contains a VBA project.
+func (p *presentationImpl) HasMacros() bool {
+	return p.pkg.HasMacros()
+}
+
+// IsStrict reports whether the presentation was read from a Strict Open XML file.
+// Save with SaveOptions.Strict to keep that conformance class.
+func (p *presentationImpl) IsStrict() bool {
+	return p.pkg.IsStrict()
+}
+
+// Diagnostics returns the problems recovered from when the presentation was
+// opened with OpenOptions.Recover.
+func (p *presentationImpl) Diagnostics() []Diagnostic {
+	return p.pkg.Diagnostics()
+}
+
+// EmbeddedObjects lists the OLE objects and packages embedded in the presentation.
+func (p *presentationImpl) EmbeddedObjects() []*EmbeddedObject {
+	return p.pkg.EmbeddedObjects()
+}
+
+// WriteDir writes the presentation as an unzipped directory tree, optionally with
+// indented XML for review. OpenFS reads it back.
+func (p *presentationImpl) WriteDir(dir string, pretty bool) error {
+	if err := p.updatePackage(context.Background()); err != nil {
+		return err
+	}
+	return p.pkg.WriteDir(dir, pretty)
+}
+
+// WriteFlatOPC writes the presentation as a single Flat OPC XML file.
+func (p *presentationImpl) WriteFlatOPC(w io.Writer) error {
+	if err := p.updatePackage(context.Background()); err != nil {
+		return err
+	}
+	return p.pkg.WriteFlatOPC(w)
+}
+
 // Close closes the presentation and releases resources.
 func (p *presentationImpl) Close() error {
 	return p.pkg.Close()
@@ -257,6 +498,42 @@ func (p *presentationImpl) SetCoreProperties(props *common.CoreProperties) error
 	return p.pkg.SetCoreProperties(props)
 }
 
+// CustomProperties returns the presentation custom properties (docProps/custom.xml).
+func (p *presentationImpl) CustomProperties() (*common.CustomProperties, error) {
+	return p.pkg.CustomProperties()
+}
+
+// SetCustomProperties replaces all presentation custom properties.
+func (p *presentationImpl) SetCustomProperties(props *common.CustomProperties) error {
+	return p.pkg.SetCustomProperties(props)
+}
+
+// CustomProperty returns a custom property by name (case-insensitive).
+func (p *presentationImpl) CustomProperty(name string) (*common.CustomProperty, error) {
+	return p.pkg.CustomProperty(name)
+}
+
+// SetCustomProperty adds or replaces a custom property. The vt: type follows
+// the Go type of value: string, int, float64, bool or time.Time.
+func (p *presentationImpl) SetCustomProperty(name string, value interface{}) error {
+	return p.pkg.SetCustomProperty(name, value)
+}
+
+// DeleteCustomProperty removes a custom property.
+func (p *presentationImpl) DeleteCustomProperty(name string) error {
+	return p.pkg.DeleteCustomProperty(name)
+}
+
+// Thumbnail returns the package thumbnail image and its content type.
+func (p *presentationImpl) Thumbnail() (string, []byte, error) {
+	return p.pkg.Thumbnail()
+}
+
+// SetThumbnail sets the package thumbnail image shown by file browsers.
+func (p *presentationImpl) SetThumbnail(contentType string, data []byte) error {
+	return p.pkg.SetThumbnail(contentType, data)
+}
+
 // Properties returns the presentation properties.
 func (p *presentationImpl) Properties() PresentationProperties {
 	props, _ := p.pkg.CoreProperties()
@@ -434,7 +711,7 @@ func (p *presentationImpl) DuplicateSlide(index int) Slide {
 	// Copy content (deep copy of the slide structure)
 	if source.slide.CSld != nil {
 		if s, ok := newSlide.(*slideImpl); ok {
-			s.slide.CSld = copyCSld(source.slide.CSld)
+			s.slide.CSld = p.copyCSld(source.slide.CSld)
 		}
 	}
 
@@ -515,7 +792,7 @@ func (p *presentationImpl) initPackage() error {
 	return nil
 }
 
-func (p *presentationImpl) parsePresentation() error {
+func (p *presentationImpl) parsePresentation(ctx context.Context) error {
 	part, err := p.pkg.GetPart(packaging.PresentationPath)
 	if err != nil {
 		return err
@@ -526,10 +803,10 @@ func (p *presentationImpl) parsePresentation() error {
 	}
 
 	p.presentation = &pml.Presentation{}
-	return utils.UnmarshalXML(data, p.presentation)
+	return p.pkg.DecodeXMLContext(ctx, data, p.presentation)
 }
 
-func (p *presentationImpl) parseSlides() error {
+func (p *presentationImpl) parseSlides(ctx context.Context) error {
 	defer func() {
 		if len(p.slides) == 0 {
 			rels := p.pkg.GetRelationships(packaging.PresentationPath)
@@ -547,7 +824,7 @@ func (p *presentationImpl) parseSlides() error {
 					continue
 				}
 				slide := &pml.Sld{}
-				if err := utils.UnmarshalXML(data, slide); err != nil {
+				if err := p.pkg.DecodeXMLContext(ctx, data, slide); err != nil {
 					continue
 				}
 				slideImpl := &slideImpl{
@@ -557,8 +834,8 @@ func (p *presentationImpl) parseSlides() error {
 					index: len(p.slides),
 					path:  slidePath,
 				}
-				slideImpl.comments = p.parseSlideComments(slidePath)
-				slideImpl.notes = p.parseSlideNotes(slidePath)
+				slideImpl.comments = p.parseSlideComments(ctx, slidePath)
+				slideImpl.notes = p.parseSlideNotes(ctx, slidePath)
 				p.slides = append(p.slides, slideImpl)
 			}
 		}
@@ -573,7 +850,11 @@ func (p *presentationImpl) parseSlides() error {
 		return nil
 	}
 
-	for i, sldId := range p.presentation.SldIdLst.SldId {
+	var dropped map[string]bool
+	for _, sldId := range p.presentation.SldIdLst.SldId {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		// Find the relationship
 		var slidePath string
 		for _, rel := range rels.Relationships {
@@ -588,17 +869,18 @@ func (p *presentationImpl) parseSlides() error {
 		}
 
 		// Parse slide
+		slide := &pml.Sld{}
 		part, err := p.pkg.GetPart(slidePath)
-		if err != nil {
-			continue
+		if err == nil {
+			err = p.decodePart(ctx, part, slide)
 		}
-		data, err := part.Content()
 		if err != nil {
-			continue
-		}
-
-		slide := &pml.Sld{}
-		if err := utils.UnmarshalXML(data, slide); err != nil {
+			if p.pkg.RecoverPart(slidePath, err, packaging.SeverityError, "dropped slide") == nil {
+				if dropped == nil {
+					dropped = make(map[string]bool)
+				}
+				dropped[sldId.RID] = true
+			}
 			continue
 		}
 
@@ -607,11 +889,11 @@ func (p *presentationImpl) parseSlides() error {
 			slide: slide,
 			id:    sldId.ID,
 			relID: sldId.RID,
-			index: i,
+			index: len(p.slides),
 			path:  slidePath,
 		}
-		slideImpl.comments = p.parseSlideComments(slidePath)
-		slideImpl.notes = p.parseSlideNotes(slidePath)
+		slideImpl.comments = p.parseSlideComments(ctx, slidePath)
+		slideImpl.notes = p.parseSlideNotes(ctx, slidePath)
 
 		p.slides = append(p.slides, slideImpl)
 
@@ -620,9 +902,30 @@ func (p *presentationImpl) parseSlides() error {
 		}
 	}
 
+	// Saving pairs slides with the slide list by position, so dropped
+	// slides leave the list too.
+	if len(dropped) > 0 {
+		kept := p.presentation.SldIdLst.SldId[:0]
+		for _, sldId := range p.presentation.SldIdLst.SldId {
+			if !dropped[sldId.RID] {
+				kept = append(kept, sldId)
+			}
+		}
+		p.presentation.SldIdLst.SldId = kept
+	}
+
 	return nil
 }
 
+// decodePart reads and decodes the XML of a part, stopping once ctx is done.
+func (p *presentationImpl) decodePart(ctx context.Context, part *packaging.Part, v interface{}) error {
+	data, err := part.Content()
+	if err != nil {
+		return err
+	}
+	return p.pkg.DecodeXMLContext(ctx, data, v)
+}
+
 func (p *presentationImpl) parseMastersAndLayouts() {
 	rels := p.pkg.GetRelationships(packaging.PresentationPath)
 	if rels == nil {
@@ -672,7 +975,7 @@ func (p *presentationImpl) parseNotesMaster() {
 		return
 	}
 		notesMaster := &pml.NotesMaster{}
-		if err := utils.UnmarshalXML(data, notesMaster); err != nil {
+		if err := p.pkg.DecodeXML(data, notesMaster); err != nil {
 			return
 		}
 	p.notesMaster = notesMaster
@@ -706,7 +1009,7 @@ func (p *presentationImpl) ensureNotesMaster() error {
 			continue
 		}
 		notesMaster := &pml.NotesMaster{}
-		if err := utils.UnmarshalXML(data, notesMaster); err != nil {
+		if err := p.pkg.DecodeXML(data, notesMaster); err != nil {
 			continue
 		}
 		p.notesMaster = notesMaster
@@ -800,7 +1103,7 @@ func (p *presentationImpl) selectNotesMasterTheme() []byte {
 	return nil
 }
 
-func (p *presentationImpl) parseSlideComments(slidePath string) *pml.CommentList {
+func (p *presentationImpl) parseSlideComments(ctx context.Context, slidePath string) *pml.CommentList {
 	rels := p.pkg.GetRelationships(slidePath)
 	if rels == nil {
 		return nil
@@ -814,18 +1117,15 @@ func (p *presentationImpl) parseSlideComments(slidePath string) *pml.CommentList
 	if err != nil {
 		return nil
 	}
-	data, err := part.Content()
-	if err != nil {
-		return nil
-	}
 	comments := &pml.CommentList{}
-	if err := utils.UnmarshalXML(data, comments); err != nil {
+	if err := p.decodePart(ctx, part, comments); err != nil {
+		_ = p.pkg.RecoverPart(target, err, packaging.SeverityWarning, "dropped comments part")
 		return nil
 	}
 	return comments
 }
 
-func (p *presentationImpl) parseSlideNotes(slidePath string) *pml.Notes {
+func (p *presentationImpl) parseSlideNotes(ctx context.Context, slidePath string) *pml.Notes {
 	rels := p.pkg.GetRelationships(slidePath)
 	if rels == nil {
 		return nil
@@ -839,18 +1139,15 @@ func (p *presentationImpl) parseSlideNotes(slidePath string) *pml.Notes {
 	if err != nil {
 		return nil
 	}
-	data, err := part.Content()
-	if err != nil {
-		return nil
-	}
 	notes := &pml.Notes{}
-	if err := utils.UnmarshalXML(data, notes); err != nil {
+	if err := p.decodePart(ctx, part, notes); err != nil {
+		_ = p.pkg.RecoverPart(target, err, packaging.SeverityWarning, "dropped notes slide")
 		return nil
 	}
 	return notes
 }
 
-func (p *presentationImpl) parseCommentAuthors() {
+func (p *presentationImpl) parseCommentAuthors(ctx context.Context) {
 	rels := p.pkg.GetRelationships(packaging.PresentationPath)
 	if rels == nil {
 		return
@@ -864,17 +1161,48 @@ func (p *presentationImpl) parseCommentAuthors() {
 	if err != nil {
 		return
 	}
-	data, err := part.Content()
-	if err != nil {
-		return
-	}
 	authors := &pml.AuthorList{}
-	if err := utils.UnmarshalXML(data, authors); err != nil {
+	if err := p.decodePart(ctx, part, authors); err != nil {
+		_ = p.pkg.RecoverPart(target, err, packaging.SeverityWarning, "dropped comment authors part")
 		return
 	}
 	p.commentAuthors = authors
 }
 
+// removeDeletedSlideRels drops presentation relationships to slides that
+// are no longer in the slide list.
+func (p *presentationImpl) removeDeletedSlideRels() {
+	live := make(map[string]bool, len(p.slides))
+	for _, slide := range p.slides {
+		live[slide.relID] = true
+	}
+	rels := p.pkg.GetRelationships(packaging.PresentationPath)
+	var stale []string
+	for _, rel := range rels.ByType(packaging.RelTypeSlide) {
+		if !live[rel.ID] {
+			stale = append(stale, rel.ID)
+		}
+	}
+	for _, id := range stale {
+		rels.Remove(id)
+	}
+}
+
+// pruneRemovedParts forgets captured parts that are no longer in the package,
+// so orphans dropped on save are not written back next time.
+func (p *presentationImpl) pruneRemovedParts() {
+	for partPath := range p.extraParts {
+		if !p.pkg.PartExists(partPath) {
+			delete(p.extraParts, partPath)
+		}
+	}
+	for partPath := range p.themeParts {
+		if !p.pkg.PartExists(partPath) {
+			delete(p.themeParts, partPath)
+		}
+	}
+}
+
 func (p *presentationImpl) captureAdvancedParts() {
 	if p.pkg == nil {
 		return
@@ -1106,7 +1434,9 @@ func (l *slideLayoutImpl) Path() string {
 	return l.path
 }
 
-func (p *presentationImpl) updatePackage() error {
+// updatePackage writes the presentation state into the package, stopping
+// between slides and shapes once ctx is done.
+func (p *presentationImpl) updatePackage(ctx context.Context) error {
 	if err := p.ensureNotesMaster(); err != nil {
 		return err
 	}
@@ -1122,14 +1452,17 @@ func (p *presentationImpl) updatePackage() error {
 	}
 	dataStr := normalizePresentationXML(data)
 	data = []byte(dataStr)
-	if _, err := p.pkg.AddPart(packaging.PresentationPath, packaging.ContentTypePresentation, data); err != nil {
+	if _, err := p.pkg.AddPart(packaging.PresentationPath, p.mainContentType(), data); err != nil {
 		return err
 	}
 
 	// Save each slide
 	for i, slide := range p.slides {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		slidePath := fmt.Sprintf("ppt/slides/slide%d.xml", i+1)
-		slideData, err := utils.MarshalXMLWithHeader(slide.slide)
+		slideData, err := utils.MarshalXMLContext(ctx, slide.slide)
 		if err != nil {
 			return err
 		}
@@ -1291,7 +1624,7 @@ func (p *presentationImpl) updatePackage() error {
 	}
 	dataStr = normalizePresentationXML(data)
 	data = []byte(dataStr)
-	if _, err := p.pkg.AddPart(packaging.PresentationPath, packaging.ContentTypePresentation, data); err != nil {
+	if _, err := p.pkg.AddPart(packaging.PresentationPath, p.mainContentType(), data); err != nil {
 		return err
 	}
 
@@ -1336,6 +1669,16 @@ func normalizePresentationXML(data []byte) string {
 	return dataStr
 }
 
+// mainContentType keeps the template or macro-enabled content type of an
+// opened presentation.
+func (p *presentationImpl) mainContentType() string {
+	contentType := p.pkg.GetContentType(packaging.PresentationPath)
+	if contentType == "" || contentType == packaging.ContentTypeXML {
+		return packaging.ContentTypePresentation
+	}
+	return contentType
+}
+
 func (p *presentationImpl) reorderPresentationRels() {
 	if p.pkg == nil {
 		return
@@ -1427,6 +1770,9 @@ func (p *presentationImpl) writeAdvancedParts() error {
 		if part == nil {
 			continue
 		}
+		if existing, err := p.pkg.GetPart(partPath); err == nil && existing == part {
+			continue // still in the package untouched; copied through on write
+		}
 		content, err := part.Content()
 		if err != nil {
 			return err
@@ -1464,7 +1810,9 @@ func (p *presentationImpl) addImagePart(imagePath string) (string, error) {
 	}
 	imageName := fmt.Sprintf("ppt/media/image%d.%s", p.nextImageID, ext)
 	p.nextImageID++
-	if _, err := p.pkg.AddPart(imageName, contentType, data); err != nil {
+	// Identical images share one media part.
+	imageName, err = p.pkg.AddMedia(imageName, contentType, data)
+	if err != nil {
 		return "", err
 	}
 	return strings.TrimPrefix(imageName, "ppt/"), nil
@@ -1510,7 +1858,7 @@ func createBlankSlide() *pml.Sld {
 	}
 }
 
-func copyCSld(src *pml.CSld) *pml.CSld {
+func (p *presentationImpl) copyCSld(src *pml.CSld) *pml.CSld {
 	if src == nil {
 		return nil
 	}
@@ -1519,7 +1867,7 @@ func copyCSld(src *pml.CSld) *pml.CSld {
 		return &pml.CSld{Name: src.Name}
 	}
 	copied := &pml.CSld{}
-	if err := utils.UnmarshalXML(data, copied); err != nil {
+	if err := p.pkg.DecodeXML(data, copied); err != nil {
 		return &pml.CSld{Name: src.Name}
 	}
 	return copied
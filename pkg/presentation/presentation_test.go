@@ -1,6 +1,10 @@
 package presentation
 
 import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
@@ -8,6 +12,7 @@ import (
 
 	"github.com/rcarmo/go-ooxml/internal/testutil"
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/dml"
 	"github.com/rcarmo/go-ooxml/pkg/packaging"
 )
 
@@ -186,6 +191,235 @@ func TestDeleteSlide(t *testing.T) {
 	}
 }
 
+func TestPresentation_OpenRecover(t *testing.T) {
+	p := testutil.NewResource(t, New)
+	for _, notes := range []string{"first", "second", "third"} {
+		p.AddSlide(0).SetNotes(notes)
+	}
+	var src bytes.Buffer
+	if _, err := p.WriteTo(&src); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := packaging.OpenBytes(src.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = pkg.AddPart("ppt/slides/slide2.xml", packaging.ContentTypeSlide, []byte("<p:sld><p:cSld>"))
+	var damaged bytes.Buffer
+	if err := pkg.WriteTo(&damaged); err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := OpenReaderWithOptions(bytes.NewReader(damaged.Bytes()), int64(damaged.Len()), &OpenOptions{Recover: true})
+	if err != nil {
+		t.Fatalf("OpenReaderWithOptions() error = %v", err)
+	}
+	defer p2.Close()
+	diags := p2.Diagnostics()
+	if len(diags) != 1 {
+		t.Fatalf("Diagnostics() = %v, want one", diags)
+	}
+	if d := diags[0]; d.PartURI != "ppt/slides/slide2.xml" || d.Severity != SeverityError || d.Action != "dropped slide" {
+		t.Errorf("Diagnostics()[0] = %+v", d)
+	}
+	if p2.SlideCount() != 2 {
+		t.Fatalf("SlideCount() = %d, want 2", p2.SlideCount())
+	}
+	if got := p2.Slides()[1].Notes(); got != "third" {
+		t.Errorf("Slides()[1].Notes() = %q, want %q", got, "third")
+	}
+
+	var out bytes.Buffer
+	if _, err := p2.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	p3, err := OpenBytes(out.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer p3.Close()
+	if p3.SlideCount() != 2 || len(p3.Diagnostics()) != 0 {
+		t.Fatalf("reopened presentation has %d slides and diagnostics %v", p3.SlideCount(), p3.Diagnostics())
+	}
+	if got := p3.Slides()[1].Notes(); got != "third" {
+		t.Errorf("reopened Slides()[1].Notes() = %q, want %q", got, "third")
+	}
+}
+
+func TestPresentation_FlatOPCRoundTrip(t *testing.T) {
+	p := testutil.NewResource(t, New)
+	slide := p.AddSlide(0)
+	if err := slide.AddTextBox(0, 0, 914400, 914400).SetText("flat & friendly"); err != nil {
+		t.Fatalf("SetText() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteFlatOPC(&buf); err != nil {
+		t.Fatalf("WriteFlatOPC() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `<?mso-application progid="PowerPoint.Show"?>`) {
+		t.Error("flat output missing PowerPoint program ID")
+	}
+
+	reopened, err := OpenFlatOPC(&buf)
+	if err != nil {
+		t.Fatalf("OpenFlatOPC() error = %v", err)
+	}
+	defer reopened.Close()
+	if reopened.SlideCount() != 1 {
+		t.Fatalf("SlideCount() = %d, want 1", reopened.SlideCount())
+	}
+	found := false
+	for _, shape := range reopened.Slides()[0].Shapes() {
+		if shape.Text() == "flat & friendly" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("text box not found after Flat OPC round trip")
+	}
+}
+
+func TestPresentation_UpdateExtendedProperties(t *testing.T) {
+	p := testutil.NewResource(t, New)
+
+	addTitle := func(slide Slide, text string) {
+		shape := slide.AddTextBox(0, 0, 914400, 914400)
+		shape.(*shapeImpl).sp.NvSpPr.NvPr.Ph = &dml.Ph{Type: "title"}
+		if err := shape.SetText(text); err != nil {
+			t.Fatalf("SetText() error = %v", err)
+		}
+	}
+	first := p.AddSlide(0)
+	addTitle(first, "Quarterly Review")
+	second := p.AddSlide(0)
+	addTitle(second, "Next Steps")
+	second.SetHidden(true)
+	if err := second.SetNotes("speaker notes"); err != nil {
+		t.Fatalf("SetNotes() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "stats.pptx")
+	if err := p.SaveAsWithOptions(path, &SaveOptions{UpdateExtendedProperties: true}); err != nil {
+		t.Fatalf("SaveAsWithOptions() error = %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reopened.Close()
+	props, err := reopened.ExtendedProperties()
+	if err != nil {
+		t.Fatalf("ExtendedProperties() error = %v", err)
+	}
+	if props.Slides != 2 || props.HiddenSlides != 1 || props.Notes != 1 {
+		t.Errorf("Slides/Hidden/Notes = %d/%d/%d, want 2/1/1", props.Slides, props.HiddenSlides, props.Notes)
+	}
+	if props.Words != 4 {
+		t.Errorf("Words = %d, want 4", props.Words)
+	}
+	titles := props.PartTitles("Slide Titles")
+	if len(titles) != 2 || titles[0] != "Quarterly Review" || titles[1] != "Next Steps" {
+		t.Errorf("Slide Titles = %v", titles)
+	}
+	if themes := props.PartTitles("Theme"); len(themes) != 1 {
+		t.Errorf("Theme titles = %v, want the template theme kept", themes)
+	}
+}
+
+func TestDeleteSlide_RemoveOrphanParts(t *testing.T) {
+	p := testutil.NewResource(t, New)
+	dir := t.TempDir()
+
+	imagePath := filepath.Join(dir, "pixel.png")
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(imagePath, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p.AddSlide(0)
+	slide := p.AddSlide(0)
+	if _, err := slide.AddPicture(imagePath, 0, 0, 914400, 914400); err != nil {
+		t.Fatalf("AddPicture() error = %v", err)
+	}
+	path := filepath.Join(dir, "orphans.pptx")
+	if err := p.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+
+	if err := p.DeleteSlide(2); err != nil {
+		t.Fatalf("DeleteSlide(2) error = %v", err)
+	}
+	if err := p.SaveAsWithOptions(path, &SaveOptions{RemoveOrphanParts: true}); err != nil {
+		t.Fatalf("SaveAsWithOptions() error = %v", err)
+	}
+
+	pkg, err := packaging.Open(path)
+	if err != nil {
+		t.Fatalf("packaging.Open() error = %v", err)
+	}
+	defer pkg.Close()
+	for _, part := range pkg.Parts() {
+		if strings.HasPrefix(part.URI(), "ppt/media/") || part.URI() == "ppt/slides/slide2.xml" {
+			t.Errorf("orphan part %s was kept", part.URI())
+		}
+	}
+	if !pkg.PartExists("ppt/slides/slide1.xml") {
+		t.Error("remaining slide was removed")
+	}
+}
+
+func TestAddPicture_SharesMediaPart(t *testing.T) {
+	p := testutil.NewResource(t, New)
+	dir := t.TempDir()
+
+	imagePath := filepath.Join(dir, "logo.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(imagePath, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := p.AddSlide(0).AddPicture(imagePath, 0, 0, 914400, 914400); err != nil {
+			t.Fatalf("AddPicture() error = %v", err)
+		}
+	}
+	path := filepath.Join(dir, "logos.pptx")
+	if err := p.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+
+	pkg, err := packaging.Open(path)
+	if err != nil {
+		t.Fatalf("packaging.Open() error = %v", err)
+	}
+	defer pkg.Close()
+	var media []string
+	for _, part := range pkg.Parts() {
+		if strings.HasPrefix(part.URI(), "ppt/media/") {
+			media = append(media, part.URI())
+		}
+	}
+	if len(media) != 1 {
+		t.Fatalf("media parts = %v, want one shared part", media)
+	}
+	for i := 1; i <= 3; i++ {
+		slidePath := fmt.Sprintf("ppt/slides/slide%d.xml", i)
+		rel := pkg.GetRelationships(slidePath).FirstByType(packaging.RelTypeImage)
+		if rel == nil || packaging.ResolveRelationshipTarget(slidePath, rel.Target) != media[0] {
+			t.Errorf("%s image relationship = %+v", slidePath, rel)
+		}
+	}
+}
+
 func TestDuplicateSlide(t *testing.T) {
 	p := testutil.NewResource(t, New)
 
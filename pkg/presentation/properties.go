@@ -0,0 +1,60 @@
+package presentation
+
+import (
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+)
+
+// ExtendedProperties returns the presentation extended properties (docProps/app.xml).
+func (p *presentationImpl) ExtendedProperties() (*common.ExtendedProperties, error) {
+	return p.pkg.ExtendedProperties()
+}
+
+// SetExtendedProperties sets the presentation extended properties.
+func (p *presentationImpl) SetExtendedProperties(props *common.ExtendedProperties) error {
+	return p.pkg.SetExtendedProperties(props)
+}
+
+// UpdateExtendedProperties recomputes the slide statistics and slide titles
+// in docProps/app.xml. Other title groups (themes, fonts) are kept. Save and
+// SaveAs do not call it; use it or SaveOptions.UpdateExtendedProperties.
+func (p *presentationImpl) UpdateExtendedProperties() error {
+	props, err := p.pkg.ExtendedProperties()
+	if err != nil {
+		return err
+	}
+
+	words, paragraphs, notes, hidden := 0, 0, 0, 0
+	titles := make([]string, 0, len(p.slides))
+	for _, slide := range p.slides {
+		titles = append(titles, slide.Title())
+		if slide.Hidden() {
+			hidden++
+		}
+		if slide.HasNotes() {
+			notes++
+		}
+		for _, shape := range slide.Shapes() {
+			if !shape.HasTextFrame() {
+				continue
+			}
+			for _, para := range shape.TextFrame().Paragraphs() {
+				text := para.Text()
+				if strings.TrimSpace(text) == "" {
+					continue
+				}
+				paragraphs++
+				words += len(strings.Fields(text))
+			}
+		}
+	}
+	props.Slides = len(p.slides)
+	props.Notes = notes
+	props.HiddenSlides = hidden
+	props.Words = words
+	props.Paragraphs = paragraphs
+	props.SetPartTitles("Slide Titles", titles)
+
+	return p.pkg.SetExtendedProperties(props)
+}
@@ -253,8 +253,9 @@ func (ws *worksheetImpl) buildGraphicContent(sheetPath string, kind drawingKind,
 		case "tif", "tiff":
 			contentType = packaging.ContentTypeTIFF
 		}
-		imageName := fmt.Sprintf("xl/media/image%d.%s", shapeID, ext)
-		if _, err := ws.workbook.pkg.AddPart(imageName, contentType, data); err != nil {
+		// Identical images share one media part.
+		imageName, err := ws.workbook.pkg.AddMedia(fmt.Sprintf("xl/media/image%d.%s", shapeID, ext), contentType, data)
+		if err != nil {
 			return "", err
 		}
 		relID := drawingRels.NextID()
@@ -1,19 +1,81 @@
 package spreadsheet
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/sml"
+	"github.com/rcarmo/go-ooxml/pkg/packaging"
+)
+
+// SaveOptions configures how the workbook package is written.
+type SaveOptions = packaging.SaveOptions
+
+// OpenOptions configures how the workbook package is opened.
+type OpenOptions = packaging.OpenOptions
+
+// Variant identifies the regular, template and macro-enabled flavours of the workbook format.
+type Variant = packaging.Variant
+
+// Workbook variants.
+const (
+	VariantDefault              = packaging.VariantDefault
+	VariantTemplate             = packaging.VariantTemplate
+	VariantMacroEnabled         = packaging.VariantMacroEnabled
+	VariantMacroEnabledTemplate = packaging.VariantMacroEnabledTemplate
+)
+
+// EmbeddedObject describes an object embedded in the workbook, such as a chart's
+// data workbook.
+type EmbeddedObject = packaging.EmbeddedObject
+
+// Diagnostic describes a part that could not be loaded when opening the
+// workbook with OpenOptions.Recover, and what was done about it.
+type Diagnostic = packaging.Diagnostic
+
+// Severity ranks a Diagnostic.
+type Severity = packaging.Severity
+
+// Diagnostic severities.
+const (
+	SeverityInfo    = packaging.SeverityInfo
+	SeverityWarning = packaging.SeverityWarning
+	SeverityError   = packaging.SeverityError
 )
 
 // Workbook represents an Excel workbook.
 type Workbook interface {
 	Save() error
 	SaveAs(path string) error
+	SaveWithOptions(opts *SaveOptions) error
+	SaveAsWithOptions(path string, opts *SaveOptions) error
+	WriteTo(w io.Writer) (int64, error)
+	SaveContext(ctx context.Context, opts *SaveOptions) error
+	SaveAsContext(ctx context.Context, path string, opts *SaveOptions) error
+	WriteToContext(ctx context.Context, w io.Writer, opts *SaveOptions) (int64, error)
+	SaveAsTemplate(path string) error
+	Variant() Variant
+	SetVariant(v Variant) error
+	HasMacros() bool
+	IsStrict() bool
+	Diagnostics() []Diagnostic
+	EmbeddedObjects() []*EmbeddedObject
+	WriteDir(dir string, pretty bool) error
 	Close() error
 	CoreProperties() (*common.CoreProperties, error)
 	SetCoreProperties(props *common.CoreProperties) error
+	CustomProperties() (*common.CustomProperties, error)
+	SetCustomProperties(props *common.CustomProperties) error
+	CustomProperty(name string) (*common.CustomProperty, error)
+	SetCustomProperty(name string, value interface{}) error
+	DeleteCustomProperty(name string) error
+	ExtendedProperties() (*common.ExtendedProperties, error)
+	SetExtendedProperties(props *common.ExtendedProperties) error
+	UpdateExtendedProperties() error
+	Thumbnail() (contentType string, data []byte, err error)
+	SetThumbnail(contentType string, data []byte) error
 	Sheets() []Worksheet
 	Sheet(nameOrIndex interface{}) (Worksheet, error)
 	AddSheet(name string) Worksheet
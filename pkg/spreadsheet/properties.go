@@ -0,0 +1,50 @@
+package spreadsheet
+
+import (
+	"strings"
+
+	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
+)
+
+// ExtendedProperties returns the workbook extended properties (docProps/app.xml).
+func (w *workbookImpl) ExtendedProperties() (*common.ExtendedProperties, error) {
+	return w.pkg.ExtendedProperties()
+}
+
+// SetExtendedProperties sets the workbook extended properties.
+func (w *workbookImpl) SetExtendedProperties(props *common.ExtendedProperties) error {
+	return w.pkg.SetExtendedProperties(props)
+}
+
+// UpdateExtendedProperties brings the worksheet and named range titles in
+// docProps/app.xml in line with the workbook. Save and SaveAs do not call
+// it; use it or SaveOptions.UpdateExtendedProperties.
+func (w *workbookImpl) UpdateExtendedProperties() error {
+	props, err := w.pkg.ExtendedProperties()
+	if err != nil {
+		return err
+	}
+
+	sheetNames := make([]string, 0, len(w.sheets))
+	for _, sheet := range w.sheets {
+		sheetNames = append(sheetNames, sheet.Name())
+	}
+	props.SetPartTitles("Worksheets", sheetNames)
+
+	var names []string
+	if w.workbook != nil && w.workbook.DefinedNames != nil {
+		for _, dn := range w.workbook.DefinedNames.DefinedName {
+			if dn.Hidden != nil && *dn.Hidden {
+				continue
+			}
+			name := strings.TrimPrefix(dn.Name, "_xlnm.")
+			if dn.LocalSheetID != nil && *dn.LocalSheetID >= 0 && *dn.LocalSheetID < len(sheetNames) {
+				name = sheetNames[*dn.LocalSheetID] + "!" + name
+			}
+			names = append(names, name)
+		}
+	}
+	props.SetPartTitles("Named Ranges", names)
+
+	return w.pkg.SetExtendedProperties(props)
+}
@@ -74,13 +74,8 @@ func (ss *SharedStrings) Index(s string) int {
 	return -1
 }
 
-// parse parses shared strings XML data.
-func (ss *SharedStrings) parse(data []byte) error {
-	var sst SST
-	if err := utils.UnmarshalXML(data, &sst); err != nil {
-		return err
-	}
-
+// load adds the strings of a parsed shared strings table.
+func (ss *SharedStrings) load(sst *SST) {
 	for _, si := range sst.SI {
 		var s string
 		if si.T != "" {
@@ -93,8 +88,6 @@ func (ss *SharedStrings) parse(data []byte) error {
 		}
 		ss.Add(s)
 	}
-
-	return nil
 }
 
 // marshal returns the shared strings XML data.
@@ -1,6 +1,10 @@
 package spreadsheet
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -8,6 +12,7 @@ import (
 	"github.com/rcarmo/go-ooxml/internal/testutil"
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
 	"github.com/rcarmo/go-ooxml/pkg/packaging"
+	"github.com/rcarmo/go-ooxml/pkg/utils"
 )
 
 // =============================================================================
@@ -340,6 +345,379 @@ func TestDeleteSheet(t *testing.T) {
 	}
 }
 
+func TestDeleteSheet_RemoveOrphanParts(t *testing.T) {
+	w := testutil.NewResource(t, New)
+	w.AddSheet("Sheet2")
+	w.AddSheet("Sheet3")
+
+	path := filepath.Join(t.TempDir(), "orphans.xlsx")
+	if err := w.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	if err := w.DeleteSheet("Sheet3"); err != nil {
+		t.Fatalf("DeleteSheet() error = %v", err)
+	}
+	if err := w.SaveAsWithOptions(path, &SaveOptions{RemoveOrphanParts: true}); err != nil {
+		t.Fatalf("SaveAsWithOptions() error = %v", err)
+	}
+
+	pkg, err := packaging.Open(path)
+	if err != nil {
+		t.Fatalf("packaging.Open() error = %v", err)
+	}
+	defer pkg.Close()
+	if pkg.PartExists("xl/worksheets/sheet3.xml") {
+		t.Error("deleted sheet part was kept")
+	}
+	issues, err := pkg.Validate()
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("unexpected issue: %s", issue)
+	}
+}
+
+func TestWorkbook_PasswordRoundTrip(t *testing.T) {
+	w := testutil.NewResource(t, New)
+	sheet, _ := w.SheetRaw(0)
+	sheet.Cell("A1").SetValue("salary")
+
+	path := filepath.Join(t.TempDir(), "protected.xlsx")
+	if err := w.SaveAsWithOptions(path, &SaveOptions{Password: "hr-only"}); err != nil {
+		t.Fatalf("SaveAsWithOptions() error = %v", err)
+	}
+
+	if _, err := Open(path); !errors.Is(err, utils.ErrPasswordRequired) {
+		t.Fatalf("Open() error = %v, want ErrPasswordRequired", err)
+	}
+	reopened, err := OpenWithOptions(path, &OpenOptions{Password: "hr-only"})
+	if err != nil {
+		t.Fatalf("OpenWithOptions() error = %v", err)
+	}
+	defer reopened.Close()
+	sheet, _ = reopened.SheetRaw(0)
+	if got := sheet.Cell("A1").String(); got != "salary" {
+		t.Errorf("A1 = %q, want %q", got, "salary")
+	}
+}
+
+func TestWorkbook_DeterministicSave(t *testing.T) {
+	dir := t.TempDir()
+	save := func(name string) []byte {
+		w := testutil.NewResource(t, New)
+		sheet, _ := w.SheetRaw(0)
+		sheet.Cell("A1").SetValue("total")
+		sheet.Cell("B1").SetValue(42)
+		path := filepath.Join(dir, name)
+		if err := w.SaveAsWithOptions(path, &SaveOptions{Deterministic: true}); err != nil {
+			t.Fatalf("SaveAsWithOptions() error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	first := save("first.xlsx")
+	if !bytes.Equal(first, save("second.xlsx")) {
+		t.Error("identical workbooks saved to different bytes")
+	}
+
+	pkg, err := packaging.OpenBytes(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pkg.Close()
+	part, err := pkg.GetPart("xl/workbook.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := part.Content()
+	for _, machineSpecific := range []string{"absPath", "revisionPtr"} {
+		if bytes.Contains(content, []byte(machineSpecific)) {
+			t.Errorf("workbook.xml contains %s", machineSpecific)
+		}
+	}
+}
+
+// writeTestXLSM saves a new workbook to dir and turns it into an .xlsm with
+// a VBA project, returning its path.
+func writeTestXLSM(t *testing.T, dir string) string {
+	t.Helper()
+	src := filepath.Join(dir, "src.xlsx")
+	w := testutil.NewResource(t, New)
+	if err := w.SaveAs(src); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := packaging.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pkg.Close()
+	_, _ = pkg.AddPart("xl/vbaProject.bin", packaging.ContentTypeVBAProject, []byte{0xD0, 0xCF, 0x11, 0xE0})
+	pkg.AddRelationship(packaging.ExcelWorkbookPath, "vbaProject.bin", packaging.RelTypeVBAProject)
+	if err := pkg.SetVariant(packaging.VariantMacroEnabled); err != nil {
+		t.Fatal(err)
+	}
+	xlsm := filepath.Join(dir, "book.xlsm")
+	if err := pkg.SaveAs(xlsm); err != nil {
+		t.Fatal(err)
+	}
+	return xlsm
+}
+
+func TestWorkbook_MacroEnabledRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	xlsm := writeTestXLSM(t, dir)
+
+	w2, err := Open(xlsm)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer w2.Close()
+	if w2.Variant() != VariantMacroEnabled || !w2.HasMacros() {
+		t.Fatalf("Variant() = %v, HasMacros() = %v", w2.Variant(), w2.HasMacros())
+	}
+	xltm := filepath.Join(dir, "book.xltm")
+	if err := w2.SaveAsTemplate(xltm); err != nil {
+		t.Fatalf("SaveAsTemplate() error = %v", err)
+	}
+
+	saved, err := packaging.Open(xltm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+	if ct := saved.MainContentType(); ct != packaging.ContentTypeWorkbookTemplateMacroEnabled {
+		t.Errorf("main content type = %q, want %q", ct, packaging.ContentTypeWorkbookTemplateMacroEnabled)
+	}
+	if !saved.PartExists("xl/vbaProject.bin") {
+		t.Error("vbaProject.bin not preserved")
+	}
+}
+
+func TestWorkbook_MacroEnabledSaveValidates(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(writeTestXLSM(t, dir))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer w.Close()
+	out := filepath.Join(dir, "saved.xlsm")
+	if err := w.SaveAs(out); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+
+	pkg, err := packaging.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pkg.Close()
+	issues, err := pkg.Validate()
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("unexpected issue: %s", issue)
+	}
+}
+
+func TestWorkbook_MacroEnabledSaveKeepsVBAProject(t *testing.T) {
+	for _, removeOrphans := range []bool{false, true} {
+		t.Run(fmt.Sprintf("RemoveOrphanParts=%v", removeOrphans), func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := Open(writeTestXLSM(t, dir))
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer w.Close()
+			out := filepath.Join(dir, "saved.xlsm")
+			if err := w.SaveAsWithOptions(out, &SaveOptions{RemoveOrphanParts: removeOrphans}); err != nil {
+				t.Fatalf("SaveAsWithOptions() error = %v", err)
+			}
+
+			pkg, err := packaging.Open(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pkg.Close()
+			rel := pkg.GetRelationships(packaging.ExcelWorkbookPath).FirstByType(packaging.RelTypeVBAProject)
+			if rel == nil {
+				t.Fatal("vbaProject relationship not preserved")
+			}
+			if target := packaging.ResolveRelationshipTarget(packaging.ExcelWorkbookPath, rel.Target); target != "xl/vbaProject.bin" {
+				t.Errorf("vbaProject relationship target = %q, want %q", target, "xl/vbaProject.bin")
+			}
+			if !pkg.PartExists("xl/vbaProject.bin") {
+				t.Error("vbaProject.bin not preserved")
+			}
+		})
+	}
+}
+
+// rowCancelContext is canceled after Done has been polled n times, i.e.
+// part-way through decoding a worksheet.
+type rowCancelContext struct {
+	context.Context
+	n    int
+	done chan struct{}
+}
+
+func (c *rowCancelContext) Done() <-chan struct{} {
+	if c.n--; c.n == 0 {
+		close(c.done)
+	}
+	return c.done
+}
+
+func (c *rowCancelContext) Err() error {
+	select {
+	case <-c.done:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+func TestWorkbook_Context(t *testing.T) {
+	dir := t.TempDir()
+	w := testutil.NewResource(t, New)
+	sheet, _ := w.SheetRaw(0)
+	for row := 1; row <= 500; row++ {
+		sheet.Cell(fmt.Sprintf("A%d", row)).SetValue(row)
+	}
+	path := filepath.Join(dir, "rows.xlsx")
+	if err := w.SaveAsContext(context.Background(), path, nil); err != nil {
+		t.Fatalf("SaveAsContext() error = %v", err)
+	}
+
+	w2, err := OpenContext(context.Background(), path, nil)
+	if err != nil {
+		t.Fatalf("OpenContext() error = %v", err)
+	}
+	sheet2, _ := w2.SheetRaw(0)
+	if got, _ := sheet2.Cell("A500").Float64(); got != 500 {
+		t.Errorf("A500 = %v, want 500", got)
+	}
+	w2.Close()
+
+	ctx := &rowCancelContext{Context: context.Background(), n: 1000, done: make(chan struct{})}
+	if _, err := OpenContext(ctx, path, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("OpenContext() canceled while reading rows error = %v, want context.Canceled", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	partial := filepath.Join(dir, "partial.xlsx")
+	if err := w.SaveAsContext(canceled, partial, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("SaveAsContext() error = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("canceled save left a file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := w.WriteToContext(context.Background(), &buf, nil)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteToContext() = %d, %v; wrote %d bytes", n, err, buf.Len())
+	}
+	if _, err := OpenReader(bytes.NewReader(buf.Bytes()), n); err != nil {
+		t.Errorf("OpenReader() error = %v", err)
+	}
+}
+
+func TestWorkbook_OpenRecover(t *testing.T) {
+	w := testutil.NewResource(t, New)
+	first, _ := w.SheetRaw(0)
+	first.Cell("A1").SetValue("kept")
+	w.AddSheet("Broken").Cell("A1").SetValue("lost")
+	var src bytes.Buffer
+	if _, err := w.WriteTo(&src); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := packaging.OpenBytes(src.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = pkg.AddPart("xl/worksheets/sheet2.xml", packaging.ContentTypeWorksheet, []byte("<worksheet><sheetData>"))
+	var damaged bytes.Buffer
+	if err := pkg.WriteTo(&damaged); err != nil {
+		t.Fatal(err)
+	}
+
+	w2, err := OpenReaderWithOptions(bytes.NewReader(damaged.Bytes()), int64(damaged.Len()), &OpenOptions{Recover: true})
+	if err != nil {
+		t.Fatalf("OpenReaderWithOptions() error = %v", err)
+	}
+	defer w2.Close()
+	diags := w2.Diagnostics()
+	if len(diags) != 1 {
+		t.Fatalf("Diagnostics() = %v, want one", diags)
+	}
+	if d := diags[0]; d.PartURI != "xl/worksheets/sheet2.xml" || d.Severity != SeverityError || d.Action != "replaced worksheet with an empty sheet" {
+		t.Errorf("Diagnostics()[0] = %+v", d)
+	}
+	if w2.SheetCount() != 2 {
+		t.Fatalf("SheetCount() = %d, want 2", w2.SheetCount())
+	}
+	sheet1, _ := w2.SheetRaw(0)
+	sheet2, _ := w2.SheetRaw(1)
+	if got := sheet1.Cell("A1").String(); got != "kept" {
+		t.Errorf("A1 = %q, want %q", got, "kept")
+	}
+	if sheet2.Name() != "Broken" || sheet2.Cell("A1").String() != "" {
+		t.Errorf("recovered sheet %q has A1 = %q", sheet2.Name(), sheet2.Cell("A1").String())
+	}
+
+	var out bytes.Buffer
+	if _, err := w2.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	w3, err := OpenBytes(out.Bytes())
+	if err != nil {
+		t.Fatalf("OpenBytes() error = %v", err)
+	}
+	defer w3.Close()
+	if w3.SheetCount() != 2 || len(w3.Diagnostics()) != 0 {
+		t.Errorf("reopened workbook has %d sheets and diagnostics %v", w3.SheetCount(), w3.Diagnostics())
+	}
+}
+
+func TestWorkbook_StrictRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	w := testutil.NewResource(t, New)
+	sheet, _ := w.SheetRaw(0)
+	sheet.Cell("A1").SetValue("strict")
+	sheet.Cell("B1").SetValue(42)
+	strictPath := filepath.Join(dir, "strict.xlsx")
+	if err := w.SaveAsWithOptions(strictPath, &SaveOptions{Strict: true}); err != nil {
+		t.Fatalf("SaveAsWithOptions() error = %v", err)
+	}
+
+	w2, err := Open(strictPath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer w2.Close()
+	if !w2.IsStrict() {
+		t.Error("IsStrict() = false, want true")
+	}
+	sheet2, err := w2.SheetRaw(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sheet2.Cell("A1").String(); got != "strict" {
+		t.Errorf("A1 = %q, want %q", got, "strict")
+	}
+	if got, err := sheet2.Cell("B1").Float64(); err != nil || got != 42 {
+		t.Errorf("B1 = %v, %v, want 42", got, err)
+	}
+}
+
 func TestSheet(t *testing.T) {
 	w := testutil.NewResource(t, New)
 
@@ -3,10 +3,13 @@ package spreadsheet
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/rcarmo/go-ooxml/pkg/ooxml/common"
@@ -52,19 +55,6 @@ func New() (Workbook, error) {
 			WorkbookPr: &sml.WorkbookPr{
 				DefaultThemeVersion: "202300",
 			},
-			AlternateContent: &sml.AlternateContent{
-				Choice: &sml.AlternateContentChoice{
-					Requires: "x15",
-					AbsPath:  &sml.AbsPath{URL: "/Users/rcarmo/Build/Agents/go-ooxml/artifacts/"},
-				},
-			},
-			RevisionPtr: &sml.RevisionPtr{
-				RevIDLastSave:     "0",
-				DocumentID:        "8_{F8DC38FB-4823-364C-8287-E48A85FB77D7}",
-				CoauthVersionLast: "47",
-				CoauthVersionMax:  "47",
-				UIDLastSave:       "{00000000-0000-0000-0000-000000000000}",
-			},
 			Sheets: &sml.Sheets{},
 			BookViews: &sml.BookViews{
 				WorkbookView: []*sml.WorkbookView{{
@@ -110,29 +100,90 @@ func New() (Workbook, error) {
 
 // Open opens an existing workbook from a file path.
 func Open(path string) (Workbook, error) {
-	pkg, err := packaging.Open(path)
+	return OpenWithOptions(path, nil)
+}
+
+// OpenWithOptions opens an existing workbook using opts, e.g. to supply the
+// password of an encrypted file.
+func OpenWithOptions(path string, opts *OpenOptions) (Workbook, error) {
+	pkg, err := packaging.OpenWithOptions(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	w, err := openFromPackage(context.Background(), pkg)
 	if err != nil {
 		return nil, err
 	}
+	w.path = path
+	return w, nil
+}
 
-	w, err := openFromPackage(pkg)
+// OpenContext opens an existing workbook like OpenWithOptions, giving up with
+// ctx.Err() once ctx is done. Cancellation is checked between parts and
+// while rows are decoded. opts may be nil.
+func OpenContext(ctx context.Context, path string, opts *OpenOptions) (Workbook, error) {
+	pkg, err := packaging.OpenContext(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	w, err := openFromPackage(ctx, pkg)
+	if err == nil {
+		// Optional parts are parsed leniently; report a cancellation they hid.
+		err = ctx.Err()
+	}
 	if err != nil {
+		pkg.Close()
 		return nil, err
 	}
 	w.path = path
 	return w, nil
 }
 
+// OpenBytes opens a workbook held in memory.
+func OpenBytes(data []byte) (Workbook, error) {
+	return OpenReader(bytes.NewReader(data), int64(len(data)))
+}
+
 // OpenReader opens a workbook from an io.ReaderAt.
+//
+// Parts are read from r on demand, so r must remain readable until the
+// package is closed.
 func OpenReader(r io.ReaderAt, size int64) (Workbook, error) {
-	pkg, err := packaging.OpenReader(r, size)
+	return OpenReaderWithOptions(r, size, nil)
+}
+
+// OpenReaderWithOptions opens a workbook from an io.ReaderAt using opts.
+// Unless the package is encrypted, r must remain readable until it is
+// closed.
+func OpenReaderWithOptions(r io.ReaderAt, size int64, opts *OpenOptions) (Workbook, error) {
+	pkg, err := packaging.OpenReaderWithOptions(r, size, opts)
 	if err != nil {
 		return nil, err
 	}
-	return openFromPackage(pkg)
+	return openFromPackage(context.Background(), pkg)
 }
 
-func openFromPackage(pkg *packaging.Package) (*workbookImpl, error) {
+// OpenEmbedded opens a workbook embedded in another package. Save writes
+// the changes back into the parent, which must then be saved itself.
+func OpenEmbedded(obj *EmbeddedObject) (Workbook, error) {
+	pkg, err := obj.Open()
+	if err != nil {
+		return nil, err
+	}
+	return openFromPackage(context.Background(), pkg)
+}
+
+// OpenFS opens a workbook from an unzipped directory tree, such as one written
+// by WriteDir or bundled with embed.FS.
+func OpenFS(fsys fs.FS) (Workbook, error) {
+	pkg, err := packaging.OpenFS(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return openFromPackage(context.Background(), pkg)
+}
+
+func openFromPackage(ctx context.Context, pkg *packaging.Package) (*workbookImpl, error) {
 	w := &workbookImpl{
 		pkg:           pkg,
 		sheets:        make([]*worksheetImpl, 0),
@@ -146,23 +197,23 @@ func openFromPackage(pkg *packaging.Package) (*workbookImpl, error) {
 	}
 
 	// Parse workbook.xml
-	if err := w.parseWorkbook(); err != nil {
+	if err := w.parseWorkbook(ctx); err != nil {
 		return nil, err
 	}
 
 	// Parse shared strings
-	w.parseSharedStrings()
+	w.parseSharedStrings(ctx)
 
 	// Parse styles
-	w.parseStyles()
+	w.parseStyles(ctx)
 
 	// Parse worksheets
-	if err := w.parseSheets(); err != nil {
+	if err := w.parseSheets(ctx); err != nil {
 		return nil, err
 	}
 
 	// Parse worksheet comments
-	w.parseComments()
+	w.parseComments(ctx)
 
 	w.captureAdvancedParts()
 
@@ -193,25 +244,6 @@ func openFromPackage(pkg *packaging.Package) (*workbookImpl, error) {
 		}
 	}
 
-	if w.workbook != nil && w.workbook.RevisionPtr == nil {
-		w.workbook.RevisionPtr = &sml.RevisionPtr{
-			RevIDLastSave:     "0",
-			DocumentID:        "8_{F8DC38FB-4823-364C-8287-E48A85FB77D7}",
-			CoauthVersionLast: "47",
-			CoauthVersionMax:  "47",
-			UIDLastSave:       "{00000000-0000-0000-0000-000000000000}",
-		}
-	}
-
-	if w.workbook != nil && w.workbook.AlternateContent == nil {
-		w.workbook.AlternateContent = &sml.AlternateContent{
-			Choice: &sml.AlternateContentChoice{
-				Requires: "x15",
-				AbsPath:  &sml.AbsPath{URL: "/Users/rcarmo/Build/Agents/go-ooxml/artifacts/"},
-			},
-		}
-	}
-
 	w.writeDefaultTheme()
 
 	return w, nil
@@ -219,6 +251,13 @@ func openFromPackage(pkg *packaging.Package) (*workbookImpl, error) {
 
 // Save saves the workbook to its original path.
 func (w *workbookImpl) Save() error {
+	if w.path == "" && w.pkg.Embedded() != nil {
+		// Opened with OpenEmbedded: write back into the parent package.
+		if err := w.updatePackage(context.Background()); err != nil {
+			return err
+		}
+		return w.pkg.Save()
+	}
 	if w.path == "" {
 		return utils.ErrPathNotSet
 	}
@@ -227,12 +266,158 @@ func (w *workbookImpl) Save() error {
 
 // SaveAs saves the workbook to a new path.
 func (w *workbookImpl) SaveAs(path string) error {
-	if err := w.updatePackage(); err != nil {
+	if err := w.updatePackage(context.Background()); err != nil {
 		return err
 	}
 	return w.pkg.SaveAs(path)
 }
 
+// SaveWithOptions saves the workbook to its original path using opts.
+func (w *workbookImpl) SaveWithOptions(opts *SaveOptions) error {
+	return w.saveWithOptions(context.Background(), opts)
+}
+
+func (w *workbookImpl) saveWithOptions(ctx context.Context, opts *SaveOptions) error {
+	if w.path == "" && w.pkg.Embedded() != nil {
+		// Opened with OpenEmbedded: write back into the parent package.
+		if err := w.prepareSave(ctx, opts); err != nil {
+			return err
+		}
+		if err := w.pkg.SaveContext(ctx, opts); err != nil {
+			return err
+		}
+		w.pruneRemovedParts()
+		return nil
+	}
+	if w.path == "" {
+		return utils.ErrPathNotSet
+	}
+	return w.saveAsWithOptions(ctx, w.path, opts)
+}
+
+// SaveAsWithOptions saves the workbook to a new path using opts.
+func (w *workbookImpl) SaveAsWithOptions(path string, opts *SaveOptions) error {
+	return w.saveAsWithOptions(context.Background(), path, opts)
+}
+
+func (w *workbookImpl) saveAsWithOptions(ctx context.Context, path string, opts *SaveOptions) error {
+	if err := w.prepareSave(ctx, opts); err != nil {
+		return err
+	}
+	if err := w.pkg.SaveAsContext(ctx, path, opts); err != nil {
+		return err
+	}
+	w.pruneRemovedParts()
+	return nil
+}
+
+// WriteTo writes the workbook to dst as a ZIP archive without touching disk,
+// encrypted like SaveAs when a password is set, and returns the number of
+// bytes written, implementing io.WriterTo.
+func (w *workbookImpl) WriteTo(dst io.Writer) (int64, error) {
+	return w.writeTo(context.Background(), dst, nil)
+}
+
+// SaveContext saves the workbook to its original path like SaveWithOptions,
+// stopping between sheets, rows and parts once ctx is done. The target is
+// only replaced once complete.
+func (w *workbookImpl) SaveContext(ctx context.Context, opts *SaveOptions) error {
+	return w.saveWithOptions(ctx, opts)
+}
+
+// SaveAsContext saves the workbook to a new path like SaveAsWithOptions,
+// stopping between sheets, rows and parts once ctx is done. The target is
+// only replaced once complete.
+func (w *workbookImpl) SaveAsContext(ctx context.Context, path string, opts *SaveOptions) error {
+	return w.saveAsWithOptions(ctx, path, opts)
+}
+
+// WriteToContext writes the workbook to dst as a ZIP archive, stopping once
+// ctx is done, and returns the number of bytes written.
+func (w *workbookImpl) WriteToContext(ctx context.Context, dst io.Writer, opts *SaveOptions) (int64, error) {
+	return w.writeTo(ctx, dst, opts)
+}
+
+// writeTo runs the SaveAsWithOptions pipeline against dst instead of a file.
+func (w *workbookImpl) writeTo(ctx context.Context, dst io.Writer, opts *SaveOptions) (int64, error) {
+	if err := w.prepareSave(ctx, opts); err != nil {
+		return 0, err
+	}
+	n, err := w.pkg.WriteToContext(ctx, dst, opts)
+	if err != nil {
+		return n, err
+	}
+	w.pruneRemovedParts()
+	return n, nil
+}
+
+// prepareSave writes the workbook state into the package before it is saved
+// with opts.
+func (w *workbookImpl) prepareSave(ctx context.Context, opts *SaveOptions) error {
+	if err := w.updatePackage(ctx); err != nil {
+		return err
+	}
+	if opts != nil && opts.RemoveOrphanParts {
+		// Without their relationships, deleted sheets become orphans.
+		w.removeDeletedSheetRels()
+	}
+	if opts != nil && opts.UpdateExtendedProperties {
+		return w.UpdateExtendedProperties()
+	}
+	return nil
+}
+
+// SaveAsTemplate saves the workbook as a template (.xltx or .xltm, keeping
+// macros) to a new path. The workbook remains a template afterwards.
+func (w *workbookImpl) SaveAsTemplate(path string) error {
+	if err := w.SetVariant(w.Variant().WithTemplate(true)); err != nil {
+		return err
+	}
+	return w.SaveAs(path)
+}
+
+// Variant reports whether the workbook is a regular, template or macro-enabled file.
+func (w *workbookImpl) Variant() Variant {
+	return w.pkg.Variant()
+}
+
+// SetVariant converts the workbook to another variant. Converting to a variant
+// that is not macro-enabled removes the VBA project.
+func (w *workbookImpl) SetVariant(v Variant) error {
+	return w.pkg.SetVariant(v)
+}
+
+// HasMacros reports whether the workbook contains a VBA project.
+func (w *workbookImpl) HasMacros() bool {
+	return w.pkg.HasMacros()
+}
+
+// IsStrict reports whether the workbook was read from a Strict Open XML file.
+// Save with SaveOptions.Strict to keep that conformance class.
+func (w *workbookImpl) IsStrict() bool {
+	return w.pkg.IsStrict()
+}
+
+// Diagnostics returns the problems recovered from when the workbook was
+// opened with OpenOptions.Recover.
+func (w *workbookImpl) Diagnostics() []Diagnostic {
+	return w.pkg.Diagnostics()
+}
+
+// EmbeddedObjects lists the OLE objects and packages embedded in the workbook.
+func (w *workbookImpl) EmbeddedObjects() []*EmbeddedObject {
+	return w.pkg.EmbeddedObjects()
+}
+
+// WriteDir writes the workbook as an unzipped directory tree, optionally with
+// indented XML for review. OpenFS reads it back.
+func (w *workbookImpl) WriteDir(dir string, pretty bool) error {
+	if err := w.updatePackage(context.Background()); err != nil {
+		return err
+	}
+	return w.pkg.WriteDir(dir, pretty)
+}
+
 // Close closes the workbook and releases resources.
 func (w *workbookImpl) Close() error {
 	return w.pkg.Close()
@@ -248,6 +433,42 @@ func (w *workbookImpl) SetCoreProperties(props *common.CoreProperties) error {
 	return w.pkg.SetCoreProperties(props)
 }
 
+// CustomProperties returns the workbook custom properties (docProps/custom.xml).
+func (w *workbookImpl) CustomProperties() (*common.CustomProperties, error) {
+	return w.pkg.CustomProperties()
+}
+
+// SetCustomProperties replaces all workbook custom properties.
+func (w *workbookImpl) SetCustomProperties(props *common.CustomProperties) error {
+	return w.pkg.SetCustomProperties(props)
+}
+
+// CustomProperty returns a custom property by name (case-insensitive).
+func (w *workbookImpl) CustomProperty(name string) (*common.CustomProperty, error) {
+	return w.pkg.CustomProperty(name)
+}
+
+// SetCustomProperty adds or replaces a custom property. The vt: type follows
+// the Go type of value: string, int, float64, bool or time.Time.
+func (w *workbookImpl) SetCustomProperty(name string, value interface{}) error {
+	return w.pkg.SetCustomProperty(name, value)
+}
+
+// DeleteCustomProperty removes a custom property.
+func (w *workbookImpl) DeleteCustomProperty(name string) error {
+	return w.pkg.DeleteCustomProperty(name)
+}
+
+// Thumbnail returns the package thumbnail image and its content type.
+func (w *workbookImpl) Thumbnail() (string, []byte, error) {
+	return w.pkg.Thumbnail()
+}
+
+// SetThumbnail sets the package thumbnail image shown by file browsers.
+func (w *workbookImpl) SetThumbnail(contentType string, data []byte) error {
+	return w.pkg.SetThumbnail(contentType, data)
+}
+
 // Styles returns the workbook styles manager.
 func (w *workbookImpl) Styles() Styles {
 	if w.styles == nil {
@@ -403,17 +624,7 @@ func (w *workbookImpl) Table(name string) (Table, error) {
 func (w *workbookImpl) AddSheet(name string) Worksheet {
 	relID := fmt.Sprintf("rId%d", len(w.sheets)+1)
 
-	worksheet := &sml.Worksheet{
-		SheetViews: &sml.SheetViews{
-			SheetView: []*sml.SheetView{{
-				WorkbookViewID: 0,
-			}},
-		},
-		SheetFormatPr: &sml.SheetFormatPr{
-			DefaultRowHeight: 15,
-		},
-		SheetData: &sml.SheetData{},
-	}
+	worksheet := newWorksheetXML()
 
 	sheet := &worksheetImpl{
 		workbook:  w,
@@ -494,10 +705,11 @@ func (w *workbookImpl) DeleteSheet(nameOrIndex interface{}) error {
 func (w *workbookImpl) initPackage() error {
 	// Add main relationship
 	w.pkg.AddRelationship("/", "xl/workbook.xml", packaging.RelTypeOfficeDocument)
+	w.pkg.ContentTypes().AddOverride(packaging.ExcelWorkbookPath, packaging.ContentTypeWorkbook)
 	return nil
 }
 
-func (w *workbookImpl) parseWorkbook() error {
+func (w *workbookImpl) parseWorkbook(ctx context.Context) error {
 	part, err := w.pkg.GetPart(packaging.ExcelWorkbookPath)
 	if err != nil {
 		return err
@@ -508,41 +720,61 @@ func (w *workbookImpl) parseWorkbook() error {
 	}
 
 	w.workbook = &sml.Workbook{}
-	return utils.UnmarshalXML(data, w.workbook)
+	return w.pkg.DecodeXMLContext(ctx, data, w.workbook)
 }
 
-func (w *workbookImpl) parseSharedStrings() {
-	part, err := w.pkg.GetPart(packaging.ExcelSharedStringsPath)
-	if err != nil {
-		return // Shared strings are optional
+// newWorksheetXML returns the worksheet XML of a new, empty sheet.
+func newWorksheetXML() *sml.Worksheet {
+	return &sml.Worksheet{
+		SheetViews: &sml.SheetViews{
+			SheetView: []*sml.SheetView{{
+				WorkbookViewID: 0,
+			}},
+		},
+		SheetFormatPr: &sml.SheetFormatPr{
+			DefaultRowHeight: 15,
+		},
+		SheetData: &sml.SheetData{},
 	}
+}
+
+// decodePart reads and decodes the XML of a part, stopping once ctx is done.
+func (w *workbookImpl) decodePart(ctx context.Context, part *packaging.Part, v interface{}) error {
 	data, err := part.Content()
 	if err != nil {
-		return
+		return err
 	}
+	return w.pkg.DecodeXMLContext(ctx, data, v)
+}
 
-	if err := w.sharedStrings.parse(data); err != nil {
+func (w *workbookImpl) parseSharedStrings(ctx context.Context) {
+	part, err := w.pkg.GetPart(packaging.ExcelSharedStringsPath)
+	if err != nil {
+		return // Shared strings are optional
+	}
+
+	var sst SST
+	if err := w.decodePart(ctx, part, &sst); err != nil {
+		_ = w.pkg.RecoverPart(packaging.ExcelSharedStringsPath, err, packaging.SeverityError, "dropped shared strings part")
 		return
 	}
+	w.sharedStrings.load(&sst)
 }
 
-func (w *workbookImpl) parseStyles() {
+func (w *workbookImpl) parseStyles(ctx context.Context) {
 	part, err := w.pkg.GetPart(packaging.ExcelStylesPath)
 	if err != nil {
 		return
 	}
-	data, err := part.Content()
-	if err != nil {
-		return
-	}
 	stylesXML := &sml.StyleSheet{}
-	if err := utils.UnmarshalXML(data, stylesXML); err != nil {
+	if err := w.decodePart(ctx, part, stylesXML); err != nil {
+		_ = w.pkg.RecoverPart(packaging.ExcelStylesPath, err, packaging.SeverityWarning, "dropped styles part")
 		return
 	}
 	w.styles = newStyles(stylesXML)
 }
 
-func (w *workbookImpl) parseSheets() error {
+func (w *workbookImpl) parseSheets(ctx context.Context) error {
 	if w.workbook.Sheets == nil {
 		return nil
 	}
@@ -554,6 +786,9 @@ func (w *workbookImpl) parseSheets() error {
 	}
 
 	for i, sheetRef := range w.workbook.Sheets.Sheet {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		// Find the relationship
 		var sheetPath string
 		for _, rel := range rels.Relationships {
@@ -571,18 +806,24 @@ func (w *workbookImpl) parseSheets() error {
 		}
 
 		// Parse worksheet
+		worksheet := &sml.Worksheet{}
 		part, err := w.pkg.GetPart(sheetPath)
-		if err != nil {
-			continue
+		if err == nil {
+			err = w.decodePart(ctx, part, worksheet)
 		}
-		data, err := part.Content()
 		if err != nil {
-			continue
-		}
-
-		worksheet := &sml.Worksheet{}
-		if err := utils.UnmarshalXML(data, worksheet); err != nil {
-			continue
+			if !w.pkg.CanRecover(err) {
+				continue
+			}
+			// Keep the sheet's name and position so formulas and defined
+			// names that refer to it stay valid.
+			worksheet = newWorksheetXML()
+			w.pkg.AddDiagnostic(packaging.Diagnostic{
+				PartURI:  sheetPath,
+				Severity: packaging.SeverityError,
+				Err:      err,
+				Action:   "replaced worksheet with an empty sheet",
+			})
 		}
 
 		sheet := &worksheetImpl{
@@ -593,10 +834,10 @@ func (w *workbookImpl) parseSheets() error {
 			relID:     sheetRef.ID,
 			index:     i,
 			path:      sheetPath,
-			comments:  w.commentsForSheet(sheetPath),
+			comments:  w.commentsForSheet(ctx, sheetPath),
 		}
 
-		if err := w.parseTables(sheet); err != nil {
+		if err := w.parseTables(ctx, sheet); err != nil {
 			return err
 		}
 
@@ -610,13 +851,13 @@ func (w *workbookImpl) parseSheets() error {
 	return nil
 }
 
-func (w *workbookImpl) parseComments() {
+func (w *workbookImpl) parseComments(ctx context.Context) {
 	for _, sheet := range w.sheets {
-		sheet.comments = w.commentsForSheet(sheet.path)
+		sheet.comments = w.commentsForSheet(ctx, sheet.path)
 	}
 }
 
-func (w *workbookImpl) commentsForSheet(sheetPath string) *SheetComments {
+func (w *workbookImpl) commentsForSheet(ctx context.Context, sheetPath string) *SheetComments {
 	if sheetPath == "" {
 		return nil
 	}
@@ -637,12 +878,9 @@ func (w *workbookImpl) commentsForSheet(sheetPath string) *SheetComments {
 	if err != nil {
 		return nil
 	}
-	data, err := part.Content()
-	if err != nil {
-		return nil
-	}
 	commentsXML := &sml.Comments{}
-	if err := utils.UnmarshalXML(data, commentsXML); err != nil {
+	if err := w.decodePart(ctx, part, commentsXML); err != nil {
+		_ = w.pkg.RecoverPart(commentPath, err, packaging.SeverityWarning, "dropped comments part")
 		return nil
 	}
 	comments := newSheetComments(commentPath, commentRel.ID, commentsXML)
@@ -654,7 +892,7 @@ func (w *workbookImpl) commentsForSheet(sheetPath string) *SheetComments {
 	return comments
 }
 
-func (w *workbookImpl) parseTables(sheet *worksheetImpl) error {
+func (w *workbookImpl) parseTables(ctx context.Context, sheet *worksheetImpl) error {
 	if sheet.worksheet.TableParts == nil || len(sheet.worksheet.TableParts.TablePart) == 0 {
 		return nil
 	}
@@ -664,6 +902,7 @@ func (w *workbookImpl) parseTables(sheet *worksheetImpl) error {
 		return nil
 	}
 
+	var dropped map[string]bool
 	for _, tablePart := range sheet.worksheet.TableParts.TablePart {
 		rel := sheetRels.ByID(tablePart.ID)
 		if rel == nil {
@@ -674,12 +913,14 @@ func (w *workbookImpl) parseTables(sheet *worksheetImpl) error {
 		if err != nil {
 			continue
 		}
-		data, err := part.Content()
-		if err != nil {
-			continue
-		}
 		tableXML := &sml.Table{}
-		if err := utils.UnmarshalXML(data, tableXML); err != nil {
+		if err := w.decodePart(ctx, part, tableXML); err != nil {
+			if w.pkg.RecoverPart(tablePath, err, packaging.SeverityWarning, "dropped table part") == nil {
+				if dropped == nil {
+					dropped = make(map[string]bool)
+				}
+				dropped[tablePart.ID] = true
+			}
 			continue
 		}
 		if tableXML.HeaderRowCount == 0 {
@@ -696,6 +937,15 @@ func (w *workbookImpl) parseTables(sheet *worksheetImpl) error {
 			w.nextTableID = tableXML.ID + 1
 		}
 	}
+	if len(dropped) > 0 {
+		kept := sheet.worksheet.TableParts.TablePart[:0]
+		for _, tablePart := range sheet.worksheet.TableParts.TablePart {
+			if !dropped[tablePart.ID] {
+				kept = append(kept, tablePart)
+			}
+		}
+		sheet.worksheet.TableParts.TablePart = kept
+	}
 	return nil
 }
 
@@ -710,12 +960,16 @@ func (w *workbookImpl) captureAdvancedParts() {
 		if err != nil {
 			continue
 		}
-		if data, err := part.Content(); err == nil {
-			w.themeParts[target] = data
+		data, err := part.Content()
+		if err != nil {
+			_ = w.pkg.RecoverPart(target, err, packaging.SeverityInfo, "dropped theme part")
+			continue
 		}
+		w.themeParts[target] = data
 	}
 	for _, sheet := range w.sheets {
 		rels := w.pkg.GetRelationships(sheet.path)
+		var missing []packaging.Diagnostic
 		for _, rel := range rels.Relationships {
 			switch rel.Type {
 			case packaging.RelTypeDrawing, packaging.RelTypeVML:
@@ -725,11 +979,55 @@ func (w *workbookImpl) captureAdvancedParts() {
 			target := packaging.ResolveRelationshipTarget(sheet.path, rel.Target)
 			part, err := w.pkg.GetPart(target)
 			if err != nil {
+				missing = append(missing, packaging.Diagnostic{PartURI: target, Err: err})
 				continue
 			}
 			w.extraParts[target] = part
 			w.captureRelatedParts(target, 2)
 		}
+		w.recoverMissingParts(missing)
+	}
+}
+
+// recoverMissingParts removes, in recover mode, the relationships to parts
+// that are not in the package.
+func (w *workbookImpl) recoverMissingParts(missing []packaging.Diagnostic) {
+	for _, d := range missing {
+		_ = w.pkg.RecoverPart(d.PartURI, d.Err, packaging.SeverityWarning, "removed relationship to missing part")
+	}
+}
+
+// removeDeletedSheetRels drops workbook relationships to worksheets that
+// are no longer in the sheet list.
+func (w *workbookImpl) removeDeletedSheetRels() {
+	live := make(map[string]bool, len(w.sheets))
+	for _, sheet := range w.sheets {
+		live[sheet.relID] = true
+	}
+	rels := w.pkg.GetRelationships(packaging.ExcelWorkbookPath)
+	var stale []string
+	for _, rel := range rels.ByType(packaging.RelTypeWorksheet) {
+		if !live[rel.ID] {
+			stale = append(stale, rel.ID)
+		}
+	}
+	for _, id := range stale {
+		rels.Remove(id)
+	}
+}
+
+// pruneRemovedParts forgets captured parts that are no longer in the package,
+// so orphans dropped on save are not written back next time.
+func (w *workbookImpl) pruneRemovedParts() {
+	for partPath := range w.extraParts {
+		if !w.pkg.PartExists(partPath) {
+			delete(w.extraParts, partPath)
+		}
+	}
+	for partPath := range w.themeParts {
+		if !w.pkg.PartExists(partPath) {
+			delete(w.themeParts, partPath)
+		}
 	}
 }
 
@@ -738,6 +1036,7 @@ func (w *workbookImpl) captureRelatedParts(sourcePath string, depth int) {
 		return
 	}
 	rels := w.pkg.GetRelationships(sourcePath)
+	var missing []packaging.Diagnostic
 	for _, rel := range rels.Relationships {
 		switch rel.Type {
 		case packaging.RelTypeChart, packaging.RelTypeChartStyle, packaging.RelTypeChartColorStyle,
@@ -753,25 +1052,37 @@ func (w *workbookImpl) captureRelatedParts(sourcePath string, depth int) {
 		}
 		part, err := w.pkg.GetPart(target)
 		if err != nil {
+			missing = append(missing, packaging.Diagnostic{PartURI: target, Err: err})
 			continue
 		}
 		w.extraParts[target] = part
 		w.captureRelatedParts(target, depth-1)
 	}
+	w.recoverMissingParts(missing)
 }
 
-func (w *workbookImpl) updatePackage() error {
+// updatePackage writes the workbook state into the package, stopping
+// between sheets and rows once ctx is done.
+func (w *workbookImpl) updatePackage(ctx context.Context) error {
 	// Save workbook.xml
 	data, err := utils.MarshalXMLWithHeader(w.workbook)
 	if err != nil {
 		return err
 	}
-	if _, err := w.pkg.AddPart(packaging.ExcelWorkbookPath, packaging.ContentTypeWorkbook, data); err != nil {
+	// Keep the template or macro-enabled content type of an opened workbook
+	contentType := w.pkg.GetContentType(packaging.ExcelWorkbookPath)
+	if contentType == "" || contentType == packaging.ContentTypeXML {
+		contentType = packaging.ContentTypeWorkbook
+	}
+	if _, err := w.pkg.AddPart(packaging.ExcelWorkbookPath, contentType, data); err != nil {
 		return err
 	}
 
 	// Save each worksheet
 	for i, sheet := range w.sheets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		sheetPath := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
 
 		w.ensureSheetMetadata(sheet, i)
@@ -785,7 +1096,7 @@ func (w *workbookImpl) updatePackage() error {
 			return err
 		}
 
-		sheetData, err := utils.MarshalXMLWithHeader(sheet.worksheet)
+		sheetData, err := utils.MarshalXMLContext(ctx, sheet.worksheet)
 		if err != nil {
 			return err
 		}
@@ -849,6 +1160,12 @@ func (w *workbookImpl) updatePackage() error {
 	return nil
 }
 
+// workbookRelOrder is the relationship order Excel writes for its default workbook.
+var workbookRelOrder = []string{"rId8", "rId3", "rId7", "rId2", "rId1", "rId6", "rId5", "rId4"}
+
+// reorderWorkbookRels orders the workbook relationships the way Excel writes
+// them. Only existing relationships are reordered; others keep their order
+// after the known ones.
 func (w *workbookImpl) reorderWorkbookRels() {
 	if w.pkg == nil {
 		return
@@ -857,16 +1174,19 @@ func (w *workbookImpl) reorderWorkbookRels() {
 	if rels == nil {
 		return
 	}
-	rels.Relationships = []packaging.Relationship{
-		{ID: "rId8", Type: packaging.RelTypeCalcChain, Target: "calcChain.xml"},
-		{ID: "rId3", Type: packaging.RelTypeWorksheet, Target: "worksheets/sheet3.xml"},
-		{ID: "rId7", Type: packaging.RelTypeSharedStrings, Target: "sharedStrings.xml"},
-		{ID: "rId2", Type: packaging.RelTypeWorksheet, Target: "worksheets/sheet2.xml"},
-		{ID: "rId1", Type: packaging.RelTypeWorksheet, Target: "worksheets/sheet1.xml"},
-		{ID: "rId6", Type: packaging.RelTypeStyles, Target: "styles.xml"},
-		{ID: "rId5", Type: packaging.RelTypeTheme, Target: "theme/theme1.xml"},
-		{ID: "rId4", Type: packaging.RelTypeWorksheet, Target: "worksheets/sheet4.xml"},
+	rank := make(map[string]int, len(workbookRelOrder))
+	for i, id := range workbookRelOrder {
+		rank[id] = i
+	}
+	rankOf := func(id string) int {
+		if r, ok := rank[id]; ok {
+			return r
+		}
+		return len(workbookRelOrder)
 	}
+	sort.SliceStable(rels.Relationships, func(i, j int) bool {
+		return rankOf(rels.Relationships[i].ID) < rankOf(rels.Relationships[j].ID)
+	})
 }
 
 func (w *workbookImpl) ensureSheetMetadata(sheet *worksheetImpl, index int) {
@@ -1064,6 +1384,9 @@ func (w *workbookImpl) writeAdvancedParts() error {
 		if part == nil {
 			continue
 		}
+		if existing, err := w.pkg.GetPart(partPath); err == nil && existing == part {
+			continue // still in the package untouched; copied through on write
+		}
 		content, err := part.Content()
 		if err != nil {
 			return err
@@ -47,6 +47,22 @@ var (
 	ErrCellNotFound    = errors.New("cell not found")
 	// ErrInvalidValue is returned when a cell value cannot be set.
 	ErrInvalidValue    = errors.New("invalid value")
+	// ErrSignatureNotFound is returned when a package carries no digital signatures.
+	ErrSignatureNotFound = errors.New("signature not found")
+	// ErrSignatureInvalid is returned when a digital signature fails verification.
+	ErrSignatureInvalid = errors.New("signature invalid")
+	// ErrPasswordRequired is returned when opening an encrypted package without a password.
+	ErrPasswordRequired = errors.New("password required for encrypted package")
+	// ErrInvalidPassword is returned when the password does not decrypt the package.
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrUnsupportedEncryption is returned for encryption schemes that cannot be processed.
+	ErrUnsupportedEncryption = errors.New("unsupported encryption")
+	// ErrIntegrityCheckFailed is returned when encrypted data fails its integrity check.
+	ErrIntegrityCheckFailed = errors.New("encrypted package failed integrity check")
+	// ErrPropertyNotFound is returned when a custom document property does not exist.
+	ErrPropertyNotFound = errors.New("property not found")
+	// ErrLimitExceeded is returned when input exceeds a configured resource limit.
+	ErrLimitExceeded = errors.New("resource limit exceeded")
 )
 
 // ValidationError provides detailed validation failure info.
@@ -72,3 +88,24 @@ func NewValidationError(field, message string, value interface{}) *ValidationErr
 		Value:   value,
 	}
 }
+
+// LimitError reports which resource limit untrusted input exceeded.
+// It matches ErrLimitExceeded with errors.Is.
+type LimitError struct {
+	Limit string // e.g. "part size", "xml depth"
+	Max   int64
+	Part  string // offending part, when known
+}
+
+// Error returns a formatted limit error string.
+func (e *LimitError) Error() string {
+	if e.Part != "" {
+		return fmt.Sprintf("%s: %s exceeds limit of %d", e.Part, e.Limit, e.Max)
+	}
+	return fmt.Sprintf("%s exceeds limit of %d", e.Limit, e.Max)
+}
+
+// Unwrap returns ErrLimitExceeded.
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
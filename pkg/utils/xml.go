@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"io"
 	"strings"
@@ -20,6 +21,38 @@ func MarshalXMLWithHeader(v interface{}) ([]byte, error) {
 	return append([]byte(XMLHeader), data...), nil
 }
 
+// MarshalXMLContext marshals v like MarshalXMLWithHeader and stops with
+// ctx.Err() once ctx is done. The context is checked whenever the encoder
+// flushes a few kilobytes of output, so cancellation takes effect between
+// rows or paragraphs of a large part.
+func MarshalXMLContext(ctx context.Context, v interface{}) ([]byte, error) {
+	if ctx.Done() == nil {
+		return MarshalXMLWithHeader(v)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := xml.NewEncoder(&contextWriter{ctx: ctx, w: &buf}).Encode(v); err != nil {
+		return nil, err
+	}
+	data := normalizeRelationshipPrefixes(buf.Bytes())
+	return append([]byte(XMLHeader), data...), nil
+}
+
+// contextWriter fails with ctx.Err() once ctx is done.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c *contextWriter) Write(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(b)
+}
+
 // MarshalXMLIndentWithHeader marshals v to indented XML with the standard XML declaration.
 func MarshalXMLIndentWithHeader(v interface{}, prefix, indent string) ([]byte, error) {
 	data, err := xml.MarshalIndent(v, prefix, indent)
@@ -402,6 +435,38 @@ func UnmarshalXML(data []byte, v interface{}) error {
 	return xml.Unmarshal(data, v)
 }
 
+// XMLLimits bounds the work done decoding untrusted XML. Zero fields are
+// unlimited. Exceeding a limit fails with a *LimitError.
+type XMLLimits struct {
+	// MaxDepth is the maximum element nesting depth.
+	MaxDepth int
+	// MaxTokens is the maximum number of tokens (elements, text, comments...)
+	// in a single document.
+	MaxTokens int64
+}
+
+// IsZero reports whether no limit is set.
+func (l XMLLimits) IsZero() bool {
+	return l.MaxDepth <= 0 && l.MaxTokens <= 0
+}
+
+// UnmarshalXMLWithLimits unmarshals XML data like UnmarshalXML, enforcing limits.
+func UnmarshalXMLWithLimits(data []byte, v interface{}, limits XMLLimits) error {
+	return UnmarshalXMLContext(context.Background(), data, v, limits)
+}
+
+// UnmarshalXMLContext unmarshals XML data like UnmarshalXMLWithLimits and
+// stops with ctx.Err() once ctx is done. The context is checked at every
+// start element, so cancellation takes effect between rows or paragraphs
+// of a large part.
+func UnmarshalXMLContext(ctx context.Context, data []byte, v interface{}, limits XMLLimits) error {
+	if limits.IsZero() && ctx.Done() == nil {
+		return UnmarshalXML(data, v)
+	}
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	return NewXMLDecoderContext(ctx, bytes.NewReader(data), limits).Decode(v)
+}
+
 // NewXMLDecoder creates an XML decoder that handles common OOXML quirks.
 func NewXMLDecoder(r io.Reader) *xml.Decoder {
 	d := xml.NewDecoder(r)
@@ -412,6 +477,57 @@ func NewXMLDecoder(r io.Reader) *xml.Decoder {
 	return d
 }
 
+// NewXMLDecoderWithLimits creates an XML decoder like NewXMLDecoder that
+// fails with a *LimitError once the input exceeds limits.
+func NewXMLDecoderWithLimits(r io.Reader, limits XMLLimits) *xml.Decoder {
+	return NewXMLDecoderContext(context.Background(), r, limits)
+}
+
+// NewXMLDecoderContext creates an XML decoder like NewXMLDecoderWithLimits
+// that also fails with ctx.Err() once ctx is done.
+func NewXMLDecoderContext(ctx context.Context, r io.Reader, limits XMLLimits) *xml.Decoder {
+	if limits.IsZero() && ctx.Done() == nil {
+		return NewXMLDecoder(r)
+	}
+	return xml.NewTokenDecoder(&limitedTokenReader{d: NewXMLDecoder(r), limits: limits, ctx: ctx})
+}
+
+// limitedTokenReader counts raw tokens and nesting depth; the wrapping
+// decoder performs namespace translation and element matching as usual.
+type limitedTokenReader struct {
+	d      *xml.Decoder
+	limits XMLLimits
+	ctx    context.Context
+	depth  int
+	tokens int64
+}
+
+func (l *limitedTokenReader) Token() (xml.Token, error) {
+	tok, err := l.d.RawToken()
+	if tok == nil {
+		return nil, err
+	}
+	l.tokens++
+	if l.limits.MaxTokens > 0 && l.tokens > l.limits.MaxTokens {
+		return nil, &LimitError{Limit: "xml tokens", Max: l.limits.MaxTokens}
+	}
+	switch tok.(type) {
+	case xml.StartElement:
+		l.depth++
+		if l.limits.MaxDepth > 0 && l.depth > l.limits.MaxDepth {
+			return nil, &LimitError{Limit: "xml depth", Max: int64(l.limits.MaxDepth)}
+		}
+		select {
+		case <-l.ctx.Done():
+			return nil, l.ctx.Err()
+		default:
+		}
+	case xml.EndElement:
+		l.depth--
+	}
+	return tok, err
+}
+
 // EscapeXMLText escapes special characters in XML text content.
 func EscapeXMLText(s string) string {
 	var buf strings.Builder
@@ -2,7 +2,10 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -124,6 +127,112 @@ func TestUnmarshalXML(t *testing.T) {
 	}
 }
 
+func TestUnmarshalXMLWithLimits(t *testing.T) {
+	type TestStruct struct {
+		XMLName xml.Name `xml:"urn:test root"`
+		Value   string   `xml:"urn:test value"`
+	}
+	input := []byte(`<t:root xmlns:t="urn:test"><t:value>ok</t:value></t:root>`)
+	deep := []byte(`<t:root xmlns:t="urn:test">` + strings.Repeat("<a>", 50) + strings.Repeat("</a>", 50) + `</t:root>`)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		limits  XMLLimits
+		want    string
+		wantErr bool
+	}{
+		{name: "no limits", input: input, want: "ok"},
+		{name: "within limits", input: input, limits: XMLLimits{MaxDepth: 2, MaxTokens: 10}, want: "ok"},
+		{name: "deep within limits", input: deep, limits: XMLLimits{MaxDepth: 51}},
+		{name: "too deep", input: deep, limits: XMLLimits{MaxDepth: 10}, wantErr: true},
+		{name: "too many tokens", input: input, limits: XMLLimits{MaxTokens: 3}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result TestStruct
+			err := UnmarshalXMLWithLimits(tt.input, &result, tt.limits)
+			if tt.wantErr {
+				var limitErr *LimitError
+				if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimitExceeded) {
+					t.Fatalf("UnmarshalXMLWithLimits() error = %v, want *LimitError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalXMLWithLimits() error = %v", err)
+			}
+			if result.Value != tt.want {
+				t.Errorf("Value = %q, want %q", result.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalXMLContext(t *testing.T) {
+	type TestStruct struct {
+		XMLName xml.Name `xml:"urn:test root"`
+		Value   string   `xml:"urn:test value"`
+	}
+	input := []byte(`<t:root xmlns:t="urn:test"><t:value>ok</t:value></t:root>`)
+
+	var result TestStruct
+	if err := UnmarshalXMLContext(context.Background(), input, &result, XMLLimits{}); err != nil || result.Value != "ok" {
+		t.Fatalf("UnmarshalXMLContext() = %q, %v", result.Value, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := UnmarshalXMLContext(ctx, input, &result, XMLLimits{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("UnmarshalXMLContext() with canceled context error = %v, want context.Canceled", err)
+	}
+}
+
+// countdownContext reports context.Canceled once Err has been called n times.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Done() <-chan struct{} { return make(chan struct{}) }
+
+func (c *countdownContext) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestMarshalXMLContext(t *testing.T) {
+	type Rows struct {
+		XMLName xml.Name `xml:"rows"`
+		Row     []string `xml:"row"`
+	}
+	v := Rows{Row: make([]string, 10000)}
+	for i := range v.Row {
+		v.Row[i] = "value"
+	}
+
+	want, err := MarshalXMLWithHeader(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := MarshalXMLContext(&countdownContext{Context: context.Background(), n: 1000}, v)
+	if err != nil {
+		t.Fatalf("MarshalXMLContext() error = %v", err)
+	}
+	if string(got) != string(want) {
+		t.Error("MarshalXMLContext() output differs from MarshalXMLWithHeader()")
+	}
+
+	// Canceled after the first flush, long before the last row.
+	if _, err := MarshalXMLContext(&countdownContext{Context: context.Background(), n: 2}, v); !errors.Is(err, context.Canceled) {
+		t.Errorf("MarshalXMLContext() canceled mid-way error = %v, want context.Canceled", err)
+	}
+}
+
 func TestEscapeXMLText(t *testing.T) {
 	tests := []struct {
 		input string